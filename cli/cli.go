@@ -0,0 +1,341 @@
+// Package cli 命令行入口, serve 启动服务, 其余子命令通过 HTTP 接口操作正在运行的服务,
+// 或者使用 -local 在当前进程中启动浏览器执行一次
+package cli
+
+import (
+	"browsertools/client"
+	"browsertools/httpserver"
+	"browsertools/pkg/config"
+	"browsertools/pkg/errors"
+	"browsertools/pkg/processmanager"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// EnvServer 子命令连接的服务地址
+	EnvServer = config.EnvPrefix + "SERVER"
+	// EnvToken 子命令使用的令牌, 未设置时使用服务端的 auth.token 环境变量, 方便在容器中直接执行
+	EnvToken      = config.EnvPrefix + "TOKEN"
+	envAuthToken  = config.EnvPrefix + "AUTH_TOKEN"
+	defaultServer = "http://localhost:8888"
+)
+
+type command struct {
+	name string
+	// args 参数说明, 显示在帮助中
+	args    string
+	summary string
+	run     func(ctx context.Context, name string, args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "[config flags]", "Start the HTTP server (default when no command is given)", nil},
+		{"open", "<url>", "Open a URL in a new tab and print the tab", cmdOpen},
+		{"tabs", "", "List open tabs", cmdTabs},
+		{"close", "<page_id>", "Close a tab", cmdClose},
+		{"pin", "<page_id>", "Pin a tab so it is never evicted, -unpin to undo", cmdPin},
+		{"screenshot", "", "Save a PNG screenshot of a tab", cmdScreenshot},
+		{"logs", "", "Print console logs of a tab, -follow to stream new messages and network events", cmdLogs},
+		{"eval", "<script>", "Evaluate JavaScript in a tab and print the result", cmdEval},
+		{"markdown", "", "Print the readable content of a tab as Markdown", cmdMarkdown},
+		{"extract", "<kind>", "Extract links, forms, tables, metadata or structured_data from a tab", cmdExtract},
+		{"run", "<steps.json|->", "Run steps from a /browser/run request body or a steps array", cmdRun},
+		{"sessions", "", "List browser sessions", cmdSessions},
+		{"status", "", "Check whether the server and its browser are ready", cmdStatus},
+	}
+}
+
+// Run 执行命令行, 返回进程退出码
+// 没有子命令或者第一个参数是参数标志时启动服务, 与加入子命令之前的用法兼容
+func Run(name string, args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && args[0] != "-h" && args[0] != "-help" && args[0] != "--help" {
+		return serve(name, args)
+	}
+
+	switch args[0] {
+	case "serve":
+		return serve(name+" serve", args[1:])
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout, name)
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] || cmd.run == nil {
+			continue
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+
+		err := cmd.run(ctx, name+" "+cmd.name, args[1:])
+		switch {
+		case err == nil:
+			return 0
+		case err == flag.ErrHelp:
+			return 0
+		case errors.Is(err, errUsage):
+			return 2
+		}
+
+		var codeError errors.CodeError
+		if errors.As(err, &codeError) {
+			fmt.Fprintf(os.Stderr, "error: %s (code %d)\n", codeError.Error(), codeError.Code())
+		} else {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	usage(os.Stderr, name)
+
+	return 2
+}
+
+func usage(w io.Writer, name string) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags] [args]\n\nCommands:\n", name)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s <command> -h' for the flags of a command.\n", name)
+	fmt.Fprintf(w, "Commands connect to %s, or $%s when set; use -local to launch a browser in this process.\n", defaultServer, EnvServer)
+}
+
+func serve(name string, args []string) int {
+	cfg, err := config.Load(name, args)
+	if err == flag.ErrHelp {
+		return 0
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load config error: %v\n", err)
+		return 2
+	}
+
+	// 虚拟显示器等辅助进程需要在浏览器启动之前运行
+	pm := newProcessManager(cfg.Processes)
+	if pm != nil {
+		if cfg.MCP.Stdio {
+			pm.SetStdout(os.Stderr)
+		}
+
+		if err := pm.StartAll(); err != nil {
+			fmt.Fprintf(os.Stderr, "start processes error: %v\n", err)
+			pm.StopAll()
+			return 1
+		}
+		defer pm.StopAll()
+	}
+
+	server := httpserver.New(cfg)
+	if pm != nil {
+		server.WithProcessManager(pm)
+	}
+
+	if cfg.MCP.Stdio {
+		server.ServeStdio()
+		return 0
+	}
+
+	server.Start()
+
+	return 0
+}
+
+// newProcessManager 没有配置进程时返回 nil
+func newProcessManager(processes []config.Process) *processmanager.ProcessManager {
+	if len(processes) == 0 {
+		return nil
+	}
+
+	pm := processmanager.NewProcessManager()
+	for _, p := range processes {
+		process := pm.AddProcess(p.Name, p.Executable, p.Args, p.DependsOn, p.MaxRestarts)
+		if p.RestartTimeout > 0 {
+			process.SetRestartTimeout(p.RestartTimeout)
+		}
+	}
+
+	return pm
+}
+
+// errUsage 参数错误, 帮助信息已经输出
+var errUsage = errors.New("usage error")
+
+// options 所有子命令共用的参数
+type options struct {
+	fs      *flag.FlagSet
+	server  string
+	token   string
+	session string
+	local   bool
+	config  string
+	verbose bool
+	json    bool
+	timeout time.Duration
+}
+
+func newOptions(name string, args string) *options {
+	o := &options{fs: flag.NewFlagSet(name, flag.ContinueOnError)}
+
+	server := os.Getenv(EnvServer)
+	if server == "" {
+		server = defaultServer
+	}
+
+	token := os.Getenv(EnvToken)
+	if token == "" {
+		token = os.Getenv(envAuthToken)
+	}
+
+	o.fs.StringVar(&o.server, "server", server, "server address, default $"+EnvServer)
+	o.fs.StringVar(&o.token, "token", token, "API token or JWT when the server requires auth, default $"+EnvToken+" or $"+envAuthToken)
+	o.fs.StringVar(&o.session, "session", "", "named session, empty for the default browser")
+	o.fs.BoolVar(&o.local, "local", false, "launch a browser in this process instead of connecting to a server")
+	o.fs.StringVar(&o.config, "config", os.Getenv(config.EnvConfigFile), "config file for -local")
+	o.fs.BoolVar(&o.verbose, "v", false, "print server logs of -local to stderr")
+	o.fs.BoolVar(&o.json, "json", false, "print JSON instead of human-readable output")
+	o.fs.DurationVar(&o.timeout, "timeout", 2*time.Minute, "request timeout")
+
+	o.fs.Usage = func() {
+		fmt.Fprintf(o.fs.Output(), "Usage: %s [flags] %s\n\nFlags:\n", name, args)
+		o.fs.PrintDefaults()
+	}
+
+	return o
+}
+
+// parse 解析参数, 参数标志可以写在位置参数之后, "--" 之后的都是位置参数
+func (o *options) parse(args []string, min int, max int) ([]string, error) {
+	positional := make([]string, 0)
+
+	for {
+		if err := o.fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+
+		rest := o.fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+
+		if len(rest) == 0 {
+			break
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	if len(positional) < min || max >= 0 && len(positional) > max {
+		o.fs.Usage()
+		return nil, errUsage
+	}
+
+	return positional, nil
+}
+
+// connect 返回连接到服务的客户端, -local 时在当前进程启动服务, 使用完之后调用 close 关闭浏览器
+func (o *options) connect() (c *client.Client, closeFn func(), err error) {
+	httpClient := &http.Client{Timeout: o.timeout}
+	if !o.local {
+		opts := []client.Option{client.WithHTTPClient(httpClient)}
+		if o.token != "" {
+			opts = append(opts, client.WithToken(o.token))
+		}
+
+		return client.New(o.server, opts...), func() {}, nil
+	}
+
+	cfg, err := o.localConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 标准输出只用于命令的结果
+	gin.DefaultWriter = io.Discard
+	if !o.verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	server := httpserver.New(cfg)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		server.Close()
+		return nil, nil, errors.WithMessage(err, "listen error")
+	}
+
+	srv := &http.Server{Handler: server.Handler()}
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	closeFn = func() {
+		_ = srv.Close()
+		server.Close()
+	}
+
+	return client.New("http://"+lis.Addr().String(), client.WithHTTPClient(httpClient)), closeFn, nil
+}
+
+// localConfig -local 使用的配置, 服务只在当前进程中使用
+func (o *options) localConfig() (*config.Config, error) {
+	args := make([]string, 0, 2)
+	if o.config != "" {
+		args = append(args, "-config", o.config)
+	}
+
+	cfg, err := config.Load(o.fs.Name(), args)
+	if err != nil {
+		return nil, errors.WithMessage(err, "load config error")
+	}
+	cfg.MCP.Stdio = false
+	// 只在本机回环地址上提供给当前进程使用
+	cfg.Auth.Enabled = false
+	// 总是启动新的浏览器, 退出时会关闭所有标签页, 不能连接到用户正在使用的浏览器
+	cfg.Browser.CDPEndpoint = ""
+
+	return cfg, nil
+}
+
+// output -json 时输出 JSON, 否则调用 human 输出可读的格式
+func (o *options) output(v interface{}, human func(w io.Writer)) error {
+	if o.json || human == nil {
+		return printJSON(os.Stdout, v)
+	}
+
+	human(os.Stdout)
+
+	return nil
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(v)
+}
@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"browsertools/httpserver/model"
+	"browsertools/pkg/browser"
+	"browsertools/pkg/response"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsParse(t *testing.T) {
+	o := newTabOptions("test", "<script>")
+	positional, err := o.parse([]string{"document.title", "-page", "p1", "-json"}, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"document.title"}, positional)
+	assert.Equal(t, "p1", o.pageID)
+	assert.True(t, o.json)
+
+	o = newTabOptions("test", "<script>")
+	positional, err = o.parse([]string{"-page", "p1", "--", "-1"}, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-1"}, positional)
+
+	o = newTabOptions("test", "<script>")
+	o.fs.SetOutput(io.Discard)
+	_, err = o.parse([]string{"a", "b"}, 1, 1)
+	assert.ErrorIs(t, err, errUsage)
+
+	o2 := newOptions("test", "")
+	o2.fs.SetOutput(io.Discard)
+	_, err = o2.parse([]string{"-unknown"}, 0, 0)
+	assert.ErrorIs(t, err, errUsage)
+}
+
+func TestReadRunRequest(t *testing.T) {
+	dir := t.TempDir()
+
+	steps := filepath.Join(dir, "steps.json")
+	require.NoError(t, os.WriteFile(steps, []byte(` [{"action":"navigate","url":"https://example.com"}]`), 0o644))
+
+	req, err := readRunRequest(steps)
+	require.NoError(t, err)
+	require.Len(t, req.Steps, 1)
+	assert.Equal(t, "https://example.com", req.Steps[0].URL)
+
+	body := filepath.Join(dir, "run.json")
+	require.NoError(t, os.WriteFile(body, []byte(`{"page_id":"p1","steps":[{"action":"click","selector":"#go"}]}`), 0o644))
+
+	req, err = readRunRequest(body)
+	require.NoError(t, err)
+	assert.Equal(t, "p1", req.PageID)
+	require.Len(t, req.Steps, 1)
+	assert.Equal(t, "#go", req.Steps[0].Selector)
+}
+
+func TestLocalConfig(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("browser:\n  cdp_endpoint: \"http://localhost:9222\"\nmcp:\n  stdio: true\n"), 0o644))
+
+	o := newOptions("test", "")
+	_, err := o.parse([]string{"-local", "-config", file}, 0, 0)
+	require.NoError(t, err)
+
+	// -local 退出时会关闭所有标签页, 不能连接到配置中的浏览器
+	cfg, err := o.localConfig()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Browser.CDPEndpoint)
+	assert.False(t, cfg.MCP.Stdio)
+	assert.False(t, cfg.Auth.Enabled)
+}
+
+func TestRunCommands(t *testing.T) {
+	var runs []model.RequestRun
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		var data interface{}
+		switch r.URL.Path {
+		case "/browser/tab/list":
+			data = model.ResponseList{Total: 2, List: []model.ResponseTab{
+				{PageID: "p1", Url: "https://example.com", Title: "Example", Active: true, State: "ok"},
+				{PageID: "p2", Url: "about:blank", State: "ok"},
+			}}
+		case "/browser/run":
+			var req model.RequestRun
+			if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&req)) || !assert.Len(t, req.Steps, 1) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			runs = append(runs, req)
+
+			result := model.ResponseStepResult{Action: req.Steps[0].Action, Status: browser.StepStatusOK, Value: "Example"}
+			if req.Steps[0].Script == "throw 1" {
+				result = model.ResponseStepResult{Action: req.Steps[0].Action, Status: browser.StepStatusFailed, Error: "uncaught 1"}
+			}
+			data = model.ResponseRun{PageID: req.PageID, Steps: []model.ResponseStepResult{result}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(response.New(data))
+	}))
+	defer server.Close()
+
+	t.Setenv(EnvServer, server.URL)
+	t.Setenv(EnvToken, "secret")
+
+	out, code := captureStdout(t, func() int { return Run("test", []string{"tabs", "-json"}) })
+	require.Equal(t, 0, code)
+
+	var tabs []model.ResponseTab
+	require.NoError(t, json.Unmarshal([]byte(out), &tabs))
+	require.Len(t, tabs, 2)
+	assert.Equal(t, "p1", tabs[0].PageID)
+
+	out, code = captureStdout(t, func() int { return Run("test", []string{"tabs"}) })
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "PAGE ID")
+	assert.Contains(t, out, "https://example.com")
+
+	// 字符串结果直接输出
+	out, code = captureStdout(t, func() int { return Run("test", []string{"eval", "document.title", "-page", "p2"}) })
+	require.Equal(t, 0, code)
+	assert.Equal(t, "Example\n", out)
+
+	require.Len(t, runs, 1)
+	assert.Equal(t, "p2", runs[0].PageID)
+	assert.Equal(t, browser.StepEvaluate, runs[0].Steps[0].Action)
+	assert.Equal(t, "document.title", runs[0].Steps[0].Script)
+
+	_, code = captureStdout(t, func() int { return Run("test", []string{"eval", "throw 1"}) })
+	assert.Equal(t, 1, code)
+
+	_, code = captureStdout(t, func() int { return Run("test", []string{"eval"}) })
+	assert.Equal(t, 2, code)
+}
+
+// captureStdout 返回 fn 执行期间写到标准输出的内容, 标准错误被丢弃
+func captureStdout(t *testing.T, fn func() int) (string, int) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	require.NoError(t, err)
+	defer devNull.Close()
+
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, devNull
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+	}()
+
+	output := make(chan string)
+	go func() {
+		var b strings.Builder
+		_, _ = io.Copy(&b, r)
+		output <- b.String()
+	}()
+
+	code := fn()
+	_ = w.Close()
+
+	return <-output, code
+}
@@ -0,0 +1,636 @@
+package cli
+
+import (
+	"browsertools/client"
+	"browsertools/httpserver/model"
+	"browsertools/pkg/browser"
+	"browsertools/pkg/errors"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+)
+
+// tabOptions 操作单个标签页的命令共用的参数
+type tabOptions struct {
+	*options
+	pageID string
+	url    string
+}
+
+func newTabOptions(name string, args string) *tabOptions {
+	o := &tabOptions{options: newOptions(name, args)}
+	o.fs.StringVar(&o.pageID, "page", "", "tab ID from the tabs command, empty for the active tab")
+	o.fs.StringVar(&o.url, "url", "", "open this URL in a new tab first, useful with -local")
+
+	return o
+}
+
+// open -url 不为空时先打开页面, 之后的操作作用在新打开的活动标签页上
+func (o *tabOptions) open(ctx context.Context, c *client.Client) error {
+	if o.url == "" {
+		return nil
+	}
+
+	return c.OpenTab(ctx, model.RequestBrowserOpenTab{SessionID: o.session, Url: o.url})
+}
+
+// runStep 通过 /browser/run 执行单个步骤, 步骤失败时返回错误
+func (o *tabOptions) runStep(ctx context.Context, c *client.Client, step model.RequestStep) (*model.ResponseStepResult, error) {
+	resp, err := c.Run(ctx, model.RequestRun{SessionID: o.session, PageID: o.pageID, Steps: []model.RequestStep{step}})
+	if err != nil {
+		return nil, err
+	}
+
+	result := resp.Steps[0]
+	if result.Status != browser.StepStatusOK {
+		return nil, fmt.Errorf("%s failed: %s", step.Action, result.Error)
+	}
+
+	return &result, nil
+}
+
+func cmdOpen(ctx context.Context, name string, args []string) error {
+	o := newOptions(name, "<url>")
+	positional, err := o.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if err := c.OpenTab(ctx, model.RequestBrowserOpenTab{SessionID: o.session, Url: positional[0]}); err != nil {
+		return err
+	}
+
+	tabs, err := c.ListTabs(ctx, model.RequestTab{SessionID: o.session})
+	if err != nil {
+		return err
+	}
+
+	for _, tab := range tabs {
+		if tab.Active {
+			return o.output(tab, func(w io.Writer) {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", tab.PageID, tab.Url, tab.Title)
+			})
+		}
+	}
+
+	return nil
+}
+
+func cmdTabs(ctx context.Context, name string, args []string) error {
+	o := newOptions(name, "")
+	if _, err := o.parse(args, 0, 0); err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	tabs, err := c.ListTabs(ctx, model.RequestTab{SessionID: o.session})
+	if err != nil {
+		return err
+	}
+
+	return o.output(tabs, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "PAGE ID\tACTIVE\tPINNED\tSTATE\tURL\tTITLE")
+		for _, tab := range tabs {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", tab.PageID, mark(tab.Active), mark(tab.Pinned), tab.State, tab.Url, truncate(tab.Title, 60))
+		}
+		_ = tw.Flush()
+	})
+}
+
+func cmdClose(ctx context.Context, name string, args []string) error {
+	o := newOptions(name, "<page_id>")
+	positional, err := o.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	return c.CloseTab(ctx, model.RequestTab{SessionID: o.session, PageID: positional[0]})
+}
+
+func cmdPin(ctx context.Context, name string, args []string) error {
+	o := newOptions(name, "<page_id>")
+	unpin := o.fs.Bool("unpin", false, "unpin the tab")
+	positional, err := o.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	return c.PinTab(ctx, model.RequestTabPin{SessionID: o.session, PageID: positional[0], Pinned: !*unpin})
+}
+
+func cmdScreenshot(ctx context.Context, name string, args []string) error {
+	o := newTabOptions(name, "")
+	file := o.fs.String("o", "screenshot.png", "output file, - for stdout")
+	viewport := o.fs.Bool("viewport", false, "capture only the visible viewport instead of the full page")
+	if _, err := o.parse(args, 0, 0); err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if err := o.open(ctx, c); err != nil {
+		return err
+	}
+
+	result, err := o.runStep(ctx, c, model.RequestStep{Action: browser.StepScreenshot, FullPage: !*viewport})
+	if err != nil {
+		return err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(result.Artifact.Data)
+	if err != nil {
+		return errors.WithMessage(err, "decode screenshot error")
+	}
+
+	if *file == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(*file, data, 0o644); err != nil {
+		return err
+	}
+
+	return o.output(map[string]interface{}{"path": *file, "size": len(data)}, func(w io.Writer) {
+		fmt.Fprintf(w, "saved %d bytes to %s\n", len(data), *file)
+	})
+}
+
+func cmdLogs(ctx context.Context, name string, args []string) error {
+	o := newTabOptions(name, "")
+	follow := o.fs.Bool("follow", false, "keep streaming new console messages until interrupted or the tab is closed")
+	types := o.fs.String("types", browser.ActivityConsole, "comma separated event types for -follow: console, request, response, requestfailed")
+	if _, err := o.parse(args, 0, 0); err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if err := o.open(ctx, c); err != nil {
+		return err
+	}
+
+	// -json -follow 只输出新的事件, 每行一个 JSON
+	if !*follow || !o.json {
+		logs, err := c.GetConsoleLogs(ctx, model.RequestConsoleLogs{SessionID: o.session, PageID: o.pageID})
+		if err != nil {
+			return err
+		}
+
+		if !*follow {
+			return o.output(logs, func(w io.Writer) {
+				for _, line := range logs {
+					fmt.Fprintln(w, line)
+				}
+			})
+		}
+
+		for _, line := range logs {
+			fmt.Println(line)
+		}
+	}
+
+	req := model.RequestPageEvents{SessionID: o.session, PageID: o.pageID, Types: strings.Split(*types, ",")}
+	encoder := json.NewEncoder(os.Stdout)
+
+	return c.WatchPageEvents(ctx, req, func(event model.ResponsePageEvent) error {
+		if o.json {
+			return encoder.Encode(event)
+		}
+
+		printEvent(os.Stdout, event)
+		return nil
+	})
+}
+
+func printEvent(w io.Writer, event model.ResponsePageEvent) {
+	if event.Dropped > 0 {
+		fmt.Fprintf(w, "... %d events dropped\n", event.Dropped)
+	}
+
+	switch event.Type {
+	case browser.ActivityConsole:
+		if event.Level == "log" {
+			fmt.Fprintln(w, event.Text)
+		} else {
+			fmt.Fprintf(w, "[%s] %s\n", event.Level, event.Text)
+		}
+	case browser.ActivityRequest:
+		fmt.Fprintf(w, "-> %s %s (%s)\n", event.Method, event.URL, event.ResourceType)
+	case browser.ActivityResponse:
+		fmt.Fprintf(w, "<- %d %s %s\n", event.Status, event.Method, event.URL)
+	case browser.ActivityRequestFailed:
+		fmt.Fprintf(w, "!! %s %s: %s\n", event.Method, event.URL, event.Error)
+	}
+}
+
+func cmdEval(ctx context.Context, name string, args []string) error {
+	o := newTabOptions(name, "<script>")
+	arg := o.fs.String("arg", "", "JSON argument passed to the function")
+	positional, err := o.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	step := model.RequestStep{Action: browser.StepEvaluate, Script: positional[0]}
+	if *arg != "" {
+		if err := json.Unmarshal([]byte(*arg), &step.Arg); err != nil {
+			return errors.WithMessage(err, "invalid -arg")
+		}
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if err := o.open(ctx, c); err != nil {
+		return err
+	}
+
+	result, err := o.runStep(ctx, c, step)
+	if err != nil {
+		return err
+	}
+
+	// 字符串结果直接输出, 方便在脚本中使用
+	return o.output(result.Value, func(w io.Writer) {
+		if s, ok := result.Value.(string); ok {
+			fmt.Fprintln(w, s)
+			return
+		}
+
+		_ = printJSON(w, result.Value)
+	})
+}
+
+func cmdMarkdown(ctx context.Context, name string, args []string) error {
+	o := newTabOptions(name, "")
+	fullPage := o.fs.Bool("full", false, "keep navigation, headers and footers")
+	maxTokens := o.fs.Int("max-tokens", 0, "split into pages of at most this many estimated tokens")
+	page := o.fs.Int("n", 1, "page number to print when split")
+	if _, err := o.parse(args, 0, 0); err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if err := o.open(ctx, c); err != nil {
+		return err
+	}
+
+	doc, err := c.Markdown(ctx, model.RequestMarkdown{
+		SessionID: o.session,
+		PageID:    o.pageID,
+		FullPage:  *fullPage,
+		MaxTokens: *maxTokens,
+		Page:      *page,
+	})
+	if err != nil {
+		return err
+	}
+
+	return o.output(doc, func(w io.Writer) {
+		fmt.Fprintln(w, doc.Markdown)
+		if doc.TotalPages > 1 {
+			fmt.Fprintf(os.Stderr, "page %d of %d, use -n for the others\n", doc.Page, doc.TotalPages)
+		}
+	})
+}
+
+func cmdExtract(ctx context.Context, name string, args []string) error {
+	o := newTabOptions(name, "<links|forms|tables|metadata|structured_data>")
+	scope := o.fs.String("scope", "", "CSS selector limiting links, forms and tables to part of the page")
+	format := o.fs.String("format", "", "table format: objects, rows or csv")
+	positional, err := o.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if err := o.open(ctx, c); err != nil {
+		return err
+	}
+
+	req := model.RequestExtract{SessionID: o.session, PageID: o.pageID, Scope: *scope}
+
+	switch positional[0] {
+	case browser.ExtractLinks:
+		links, err := c.ExtractLinks(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		return o.output(links, func(w io.Writer) {
+			tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			for _, link := range links {
+				fmt.Fprintf(tw, "%s\t%s\n", truncate(link.Text, 60), link.Href)
+			}
+			_ = tw.Flush()
+		})
+	case browser.ExtractForms:
+		forms, err := c.ExtractForms(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		return o.output(forms, nil)
+	case browser.ExtractTables:
+		tables, err := c.ExtractTables(ctx, model.RequestExtractTables{SessionID: o.session, PageID: o.pageID, Scope: *scope, Format: *format})
+		if err != nil {
+			return err
+		}
+
+		if *format != "csv" {
+			return o.output(tables, nil)
+		}
+
+		return o.output(tables, func(w io.Writer) {
+			for i, table := range tables {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprint(w, table.CSV)
+			}
+		})
+	case browser.ExtractMetadata:
+		metadata, err := c.ExtractMetadata(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		return o.output(metadata, nil)
+	case browser.ExtractStructuredData:
+		data, err := c.ExtractStructuredData(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		return o.output(data, nil)
+	}
+
+	o.fs.Usage()
+	return errUsage
+}
+
+func cmdRun(ctx context.Context, name string, args []string) error {
+	o := newTabOptions(name, "<steps.json|->")
+	artifacts := o.fs.String("artifacts", "", "directory to save screenshots taken by the steps")
+	positional, err := o.parse(args, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	req, err := readRunRequest(positional[0])
+	if err != nil {
+		return err
+	}
+
+	if o.session != "" {
+		req.SessionID = o.session
+	}
+	if o.pageID != "" {
+		req.PageID = o.pageID
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if err := o.open(ctx, c); err != nil {
+		return err
+	}
+
+	resp, err := c.Run(ctx, *req)
+	if err != nil {
+		return err
+	}
+
+	if *artifacts != "" {
+		if err := saveArtifacts(*artifacts, resp); err != nil {
+			return err
+		}
+	}
+
+	err = o.output(resp, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, step := range resp.Steps {
+			label := step.Action
+			if step.Name != "" {
+				label += " " + step.Name
+			}
+
+			fmt.Fprintf(tw, "%s\t#%d %s\t%v\t%s\n", step.Status, step.Index, label,
+				time.Duration(step.DurationMs)*time.Millisecond, step.Error)
+		}
+		_ = tw.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	if !resp.Success {
+		return errors.New("run failed")
+	}
+
+	return nil
+}
+
+// readRunRequest 支持完整的 /browser/run 请求体或者只有步骤的数组, - 表示从标准输入读取
+func readRunRequest(file string) (*model.RequestRun, error) {
+	var (
+		data []byte
+		err  error
+	)
+
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var req model.RequestRun
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &req.Steps)
+	} else {
+		err = json.Unmarshal(data, &req)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "parse %s error", file)
+	}
+
+	return &req, nil
+}
+
+func saveArtifacts(dir string, resp *model.ResponseRun) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	for _, step := range resp.Steps {
+		if step.Artifact == nil {
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(step.Artifact.Data)
+		if err != nil {
+			return errors.WithMessage(err, "decode artifact error")
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("step-%d.%s", step.Index, step.Artifact.Type))
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cmdSessions(ctx context.Context, name string, args []string) error {
+	o := newOptions(name, "")
+	if _, err := o.parse(args, 0, 0); err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	sessions, err := c.ListSessions(ctx)
+	if err != nil {
+		return err
+	}
+
+	return o.output(sessions, func(w io.Writer) {
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "SESSION\tENGINE\tMODE\tTABS\tLAST USED")
+		for _, s := range sessions {
+			lastUsed := "-"
+			if s.LastUsed > 0 {
+				lastUsed = time.UnixMilli(s.LastUsed).Format(time.DateTime)
+			}
+
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", s.SessionID, s.Engine, s.HeadlessMode, s.PageCount, lastUsed)
+		}
+		_ = tw.Flush()
+	})
+}
+
+func cmdStatus(ctx context.Context, name string, args []string) error {
+	o := newOptions(name, "")
+	if _, err := o.parse(args, 0, 0); err != nil {
+		return err
+	}
+
+	c, closeFn, err := o.connect()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	health, readyErr := c.Readyz(ctx)
+	// 服务不可达时没有检查结果
+	if health.Status == "" {
+		return readyErr
+	}
+
+	err = o.output(health, func(w io.Writer) {
+		fmt.Fprintf(w, "status:    %s\n", health.Status)
+		fmt.Fprintf(w, "uptime:    %v\n", time.Duration(health.Uptime)*time.Second)
+		fmt.Fprintf(w, "engine:    %s %s\n", health.Engine, health.HeadlessMode)
+		fmt.Fprintf(w, "connected: %v, %d tabs\n", health.Connected, health.PageCount)
+		if health.LastLaunchError != "" {
+			fmt.Fprintf(w, "launch error: %s\n", health.LastLaunchError)
+		}
+
+		for _, check := range health.Checks {
+			fmt.Fprintf(w, "  %-4s %s %s\n", okText(check.OK), check.Name, check.Message)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return readyErr
+}
+
+func mark(v bool) string {
+	if v {
+		return "*"
+	}
+
+	return ""
+}
+
+func okText(ok bool) string {
+	if ok {
+		return "ok"
+	}
+
+	return "fail"
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n-1]) + "…"
+}
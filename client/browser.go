@@ -0,0 +1,222 @@
+package client
+
+import (
+	"browsertools/httpserver/model"
+	"browsertools/pkg/browser"
+	"context"
+)
+
+// Screenshot 截取活动标签页, Data 为 base64 编码的图片
+func (c *Client) Screenshot(ctx context.Context, req model.RequestScreenshot) (*model.ResponseScreenshot, error) {
+	var resp model.ResponseScreenshot
+	if err := c.post(ctx, "/browser/screenshot", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) OpenTab(ctx context.Context, req model.RequestBrowserOpenTab) error {
+	return c.post(ctx, "/browser/openTab", req, nil)
+}
+
+// GetConsoleLogs PageID 为空时返回活动标签页的日志
+func (c *Client) GetConsoleLogs(ctx context.Context, req model.RequestConsoleLogs) ([]string, error) {
+	var logs []string
+	err := c.postList(ctx, "/browser/getConsoleLogs", req, &logs)
+
+	return logs, err
+}
+
+func (c *Client) ListTabs(ctx context.Context, req model.RequestTab) ([]model.ResponseTab, error) {
+	var tabs []model.ResponseTab
+	err := c.postList(ctx, "/browser/tab/list", req, &tabs)
+
+	return tabs, err
+}
+
+func (c *Client) PinTab(ctx context.Context, req model.RequestTabPin) error {
+	return c.post(ctx, "/browser/tab/pin", req, nil)
+}
+
+func (c *Client) CloseTab(ctx context.Context, req model.RequestTab) error {
+	return c.post(ctx, "/browser/tab/close", req, nil)
+}
+
+func (c *Client) CreateSession(ctx context.Context, req model.RequestSessionCreate) (*model.ResponseSession, error) {
+	var resp model.ResponseSession
+	if err := c.post(ctx, "/browser/session/create", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) CloseSession(ctx context.Context, req model.RequestSession) error {
+	return c.post(ctx, "/browser/session/close", req, nil)
+}
+
+func (c *Client) ListSessions(ctx context.Context) ([]model.ResponseSession, error) {
+	var sessions []model.ResponseSession
+	err := c.postList(ctx, "/browser/session/list", nil, &sessions)
+
+	return sessions, err
+}
+
+// ListRecoveryEvents 最近的崩溃恢复事件, 最新的在前
+func (c *Client) ListRecoveryEvents(ctx context.Context) ([]model.ResponseRecoveryEvent, error) {
+	var events []model.ResponseRecoveryEvent
+	err := c.postList(ctx, "/browser/recovery/events", nil, &events)
+
+	return events, err
+}
+
+// LeaseBrowser 从浏览器池租用浏览器, 返回的 LeaseID 可以作为其他请求的 SessionID
+func (c *Client) LeaseBrowser(ctx context.Context, req model.RequestPoolLease) (*model.ResponseLease, error) {
+	var resp model.ResponseLease
+	if err := c.post(ctx, "/browser/pool/lease", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) ReturnBrowser(ctx context.Context, req model.RequestPoolReturn) error {
+	return c.post(ctx, "/browser/pool/return", req, nil)
+}
+
+func (c *Client) PoolStatus(ctx context.Context) (*model.ResponsePoolStatus, error) {
+	var resp model.ResponsePoolStatus
+	if err := c.post(ctx, "/browser/pool/status", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) StartRecording(ctx context.Context, req model.RequestRecordingStart) (*model.ResponseRecording, error) {
+	var resp model.ResponseRecording
+	if err := c.post(ctx, "/browser/recording/start", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) StopRecording(ctx context.Context, req model.RequestRecording) (*model.ResponseRecording, error) {
+	var resp model.ResponseRecording
+	if err := c.post(ctx, "/browser/recording/stop", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) DeleteRecording(ctx context.Context, req model.RequestRecording) error {
+	return c.post(ctx, "/browser/recording/delete", req, nil)
+}
+
+func (c *Client) ListRecordings(ctx context.Context) ([]model.ResponseRecording, error) {
+	var recordings []model.ResponseRecording
+	err := c.postList(ctx, "/browser/recording/list", nil, &recordings)
+
+	return recordings, err
+}
+
+func (c *Client) VisualCompare(ctx context.Context, req model.RequestVisualCompare) (*model.ResponseVisualCompare, error) {
+	var resp model.ResponseVisualCompare
+	if err := c.post(ctx, "/browser/visual/compare", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) VisualApprove(ctx context.Context, req model.RequestVisualApprove) error {
+	return c.post(ctx, "/browser/visual/approve", req, nil)
+}
+
+func (c *Client) ListBaselines(ctx context.Context) ([]model.ResponseBaseline, error) {
+	var baselines []model.ResponseBaseline
+	err := c.postList(ctx, "/browser/visual/baselines", nil, &baselines)
+
+	return baselines, err
+}
+
+func (c *Client) Markdown(ctx context.Context, req model.RequestMarkdown) (*model.ResponseMarkdown, error) {
+	var resp model.ResponseMarkdown
+	if err := c.post(ctx, "/browser/markdown", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// Run 执行步骤, 步骤失败不会返回错误, 需要检查 Success 和每个步骤的状态
+func (c *Client) Run(ctx context.Context, req model.RequestRun) (*model.ResponseRun, error) {
+	var resp model.ResponseRun
+	if err := c.post(ctx, "/browser/run", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) StartInteraction(ctx context.Context, req model.RequestInteractionStart) (*model.ResponseInteraction, error) {
+	var resp model.ResponseInteraction
+	if err := c.post(ctx, "/browser/interaction/start", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// StopInteraction 返回的 Steps 可以直接作为 Run 的步骤回放
+func (c *Client) StopInteraction(ctx context.Context, req model.RequestInteractionStop) (*model.ResponseInteraction, error) {
+	var resp model.ResponseInteraction
+	if err := c.post(ctx, "/browser/interaction/stop", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) ExtractLinks(ctx context.Context, req model.RequestExtract) ([]browser.Link, error) {
+	var links []browser.Link
+	err := c.postList(ctx, "/browser/extract/links", req, &links)
+
+	return links, err
+}
+
+func (c *Client) ExtractForms(ctx context.Context, req model.RequestExtract) ([]browser.Form, error) {
+	var forms []browser.Form
+	err := c.postList(ctx, "/browser/extract/forms", req, &forms)
+
+	return forms, err
+}
+
+// ExtractTables Rows 为 JSON 解码后的原始值, Format 为 objects 时元素是对象, rows 时是字符串数组, csv 时使用 CSV 字段
+func (c *Client) ExtractTables(ctx context.Context, req model.RequestExtractTables) ([]model.ResponseTable, error) {
+	var tables []model.ResponseTable
+	err := c.postList(ctx, "/browser/extract/tables", req, &tables)
+
+	return tables, err
+}
+
+func (c *Client) ExtractMetadata(ctx context.Context, req model.RequestExtract) (*browser.Metadata, error) {
+	var resp browser.Metadata
+	if err := c.post(ctx, "/browser/extract/metadata", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (c *Client) ExtractStructuredData(ctx context.Context, req model.RequestExtract) (*browser.StructuredData, error) {
+	var resp browser.StructuredData
+	if err := c.post(ctx, "/browser/extract/structuredData", req, &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
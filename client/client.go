@@ -0,0 +1,265 @@
+package client
+
+import (
+	"browsertools/httpserver/model"
+	"browsertools/pkg/auth"
+	"browsertools/pkg/errors"
+	"browsertools/pkg/response"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout = 2 * time.Minute
+	// maxEventSize 单个页面事件的最大长度, 控制台消息可能很长
+	maxEventSize = 1 << 20
+)
+
+// Client browsertools HTTP 接口的客户端
+// 失败的请求返回 errors.CodeError, 可以用 errors.EqualCodeError 与 errors.ErrPageNotFound 等预定义错误比较
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	header     http.Header
+	// hmacKeyID 不为空时使用 HMAC 签名请求
+	hmacKeyID  string
+	hmacSecret string
+}
+
+type Option func(c *Client)
+
+// WithHTTPClient 使用自定义的 http.Client, 默认超时 2 分钟, 执行较长的步骤时需要调大
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader 每个请求都带上的请求头
+func WithHeader(key string, value string) Option {
+	return func(c *Client) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithToken 使用静态令牌或 JWT 认证
+func WithToken(token string) Option {
+	return WithHeader("Authorization", "Bearer "+token)
+}
+
+// WithHMAC 使用 HMAC 签名认证, 签名包含请求体, 请求体会被读入内存
+func WithHMAC(keyID string, secret string) Option {
+	return func(c *Client) {
+		c.hmacKeyID = keyID
+		c.hmacSecret = secret
+	}
+}
+
+// New 创建客户端, baseURL 例如 http://localhost:8888
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		header:     make(http.Header),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// post 发送 JSON 请求, 把响应的 data 解码到 data 中, data 为 nil 时忽略结果
+func (c *Client) post(ctx context.Context, path string, req interface{}, data interface{}) error {
+	if req == nil {
+		req = struct{}{}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return errors.WithMessage(err, "marshal request error")
+	}
+
+	return c.do(ctx, http.MethodPost, path, bytes.NewReader(body), data)
+}
+
+// postList 列表接口的 data 为 ResponseList, 元素解码到 list 中
+func (c *Client) postList(ctx context.Context, path string, req interface{}, list interface{}) error {
+	return c.post(ctx, path, req, &model.ResponseList{List: list})
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body io.Reader, data interface{}) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.WithMessage(err, "read response error")
+	}
+
+	// 接口的错误通过 code 返回, 只有超时等少数情况使用 HTTP 状态码且没有统一的响应格式
+	result := response.Response{Data: data}
+	if err := json.Unmarshal(content, &result); err != nil || result.ErrorCode == 0 {
+		if resp.StatusCode != http.StatusOK {
+			return errors.NewWithInfo(resp.StatusCode, strings.TrimSpace(string(content)))
+		}
+		return fmt.Errorf("decode response of %s error: %v", path, err)
+	}
+
+	return result.GetError()
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
+	var payload []byte
+	if body != nil && c.hmacKeyID != "" {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, errors.WithMessage(err, "read request body error")
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, errors.WithMessage(err, "create request error")
+	}
+
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if c.hmacKeyID != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		req.Header.Set(auth.HeaderKeyID, c.hmacKeyID)
+		req.Header.Set(auth.HeaderTimestamp, timestamp)
+		req.Header.Set(auth.HeaderSignature, auth.Sign(c.hmacSecret, timestamp, method, req.URL.RequestURI(), payload))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "request %s error", path)
+	}
+
+	return resp, nil
+}
+
+// Livez 存活检查
+func (c *Client) Livez(ctx context.Context) (*model.ResponseHealth, error) {
+	var health model.ResponseHealth
+	if err := c.do(ctx, http.MethodGet, "/livez", nil, &health); err != nil {
+		return nil, err
+	}
+
+	return &health, nil
+}
+
+// Readyz 就绪检查, 检查失败时同时返回检查结果和错误
+func (c *Client) Readyz(ctx context.Context) (*model.ResponseHealth, error) {
+	var health model.ResponseHealth
+	err := c.do(ctx, http.MethodGet, "/readyz", nil, &health)
+
+	return &health, err
+}
+
+// DownloadRecording 把录制的视频写入 w
+func (c *Client) DownloadRecording(ctx context.Context, id string, w io.Writer) error {
+	resp, err := c.send(ctx, http.MethodGet, "/browser/recording/download?id="+url.QueryEscape(id), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 出错时返回统一的响应格式
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		_, err = io.Copy(w, resp.Body)
+		return errors.WithMessage(err, "download recording error")
+	}
+
+	var result response.Response
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return errors.NewWithInfo(resp.StatusCode, "decode response error")
+	}
+
+	return result.GetError()
+}
+
+// WatchPageEvents 订阅标签页的控制台消息和网络事件, 每个事件调用一次 fn, 直到 ctx 结束、标签页关闭或 fn 返回错误
+// 订阅是长连接, 不受 http.Client 的超时限制
+func (c *Client) WatchPageEvents(ctx context.Context, req model.RequestPageEvents, fn func(event model.ResponsePageEvent) error) error {
+	query := url.Values{}
+	if req.SessionID != "" {
+		query.Set("session_id", req.SessionID)
+	}
+	if req.PageID != "" {
+		query.Set("page_id", req.PageID)
+	}
+	for _, typ := range req.Types {
+		query.Add("types", typ)
+	}
+
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+	stream := *c
+	stream.httpClient = &httpClient
+
+	resp, err := stream.send(ctx, http.MethodGet, "/browser/events?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// 标签页不存在等错误在开始推送之前以统一的响应格式返回
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var result response.Response
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return errors.NewWithInfo(resp.StatusCode, "decode response error")
+		}
+
+		return result.GetError()
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventSize)
+
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Bytes()
+
+		switch {
+		case bytes.HasPrefix(line, []byte("data:")):
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))...)
+		case len(line) == 0 && len(data) > 0:
+			var event model.ResponsePageEvent
+			if err := json.Unmarshal(data, &event); err != nil {
+				return errors.WithMessage(err, "decode page event error")
+			}
+			data = data[:0]
+
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	return errors.WithMessage(scanner.Err(), "read page events error")
+}
@@ -0,0 +1,188 @@
+package client
+
+import (
+	"browsertools/httpserver/model"
+	"browsertools/pkg/auth"
+	"browsertools/pkg/errors"
+	"browsertools/pkg/response"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return New(srv.URL+"/", WithHeader("X-Test", "1"))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestClientDecodeData(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/browser/session/create", r.URL.Path)
+		assert.Equal(t, "1", r.Header.Get("X-Test"))
+
+		var req model.RequestSessionCreate
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "s1", req.SessionID)
+
+		writeJSON(w, http.StatusOK, response.New(model.ResponseSession{SessionID: "s1", PageCount: 1}))
+	})
+
+	session, err := c.CreateSession(context.Background(), model.RequestSessionCreate{SessionID: "s1"})
+	require.NoError(t, err)
+	assert.Equal(t, "s1", session.SessionID)
+	assert.Equal(t, 1, session.PageCount)
+}
+
+func TestClientDecodeList(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		tabs := []model.ResponseTab{{PageID: "p1", Active: true}, {PageID: "p2"}}
+		writeJSON(w, http.StatusOK, response.New(model.ResponseList{Total: 2, List: tabs}))
+	})
+
+	tabs, err := c.ListTabs(context.Background(), model.RequestTab{})
+	require.NoError(t, err)
+	require.Len(t, tabs, 2)
+	assert.Equal(t, "p1", tabs[0].PageID)
+	assert.True(t, tabs[0].Active)
+}
+
+func TestClientCodeError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, response.Err(errors.ErrPageNotFound))
+	})
+
+	err := c.CloseTab(context.Background(), model.RequestTab{PageID: "missing"})
+	require.Error(t, err)
+	assert.True(t, errors.EqualCodeError(err, errors.ErrPageNotFound))
+}
+
+func TestClientHTTPError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/readyz":
+			resp := response.Fail(http.StatusServiceUnavailable, "browser check failed")
+			resp.Data = model.ResponseHealth{Status: "fail"}
+			writeJSON(w, http.StatusServiceUnavailable, resp)
+		default:
+			http.Error(w, "Request Timeout", http.StatusRequestTimeout)
+		}
+	})
+
+	health, err := c.Readyz(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "fail", health.Status)
+	assert.Equal(t, "browser check failed", err.Error())
+
+	_, err = c.Run(context.Background(), model.RequestRun{})
+	var codeError errors.CodeError
+	require.True(t, errors.As(err, &codeError))
+	assert.Equal(t, http.StatusRequestTimeout, codeError.Code())
+	assert.Equal(t, "Request Timeout", codeError.Error())
+}
+
+func TestDownloadRecording(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "r1" {
+			w.Header().Set("Content-Type", "video/x-msvideo")
+			_, _ = w.Write([]byte("RIFF"))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, response.Err(errors.ErrRecordingNotFound))
+	})
+
+	var buf bytesBuffer
+	require.NoError(t, c.DownloadRecording(context.Background(), "r1", &buf))
+	assert.Equal(t, "RIFF", string(buf))
+
+	err := c.DownloadRecording(context.Background(), "r2", &buf)
+	assert.True(t, errors.EqualCodeError(err, errors.ErrRecordingNotFound))
+}
+
+func TestWatchPageEvents(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page_id") == "missing" {
+			writeJSON(w, http.StatusOK, response.Err(errors.ErrPageNotFound))
+			return
+		}
+
+		assert.Equal(t, []string{"console", "response"}, r.URL.Query()["types"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("event: console\ndata: {\"type\":\"console\",\"page_id\":\"p1\",\"text\":\"hello\"}\n\n"))
+		_, _ = w.Write([]byte("event: response\ndata: {\"type\":\"response\",\"status\":404,\"dropped\":2}\n\n"))
+	})
+
+	var events []model.ResponsePageEvent
+	err := c.WatchPageEvents(context.Background(), model.RequestPageEvents{PageID: "p1", Types: []string{"console", "response"}},
+		func(event model.ResponsePageEvent) error {
+			events = append(events, event)
+			return nil
+		})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "hello", events[0].Text)
+	assert.Equal(t, 404, events[1].Status)
+	assert.Equal(t, 2, events[1].Dropped)
+
+	err = c.WatchPageEvents(context.Background(), model.RequestPageEvents{PageID: "missing"}, func(model.ResponsePageEvent) error { return nil })
+	assert.True(t, errors.EqualCodeError(err, errors.ErrPageNotFound))
+}
+
+type bytesBuffer []byte
+
+func (b *bytesBuffer) Write(p []byte) (int, error) {
+	*b = append(*b, p...)
+	return len(p), nil
+}
+
+func TestClientAuth(t *testing.T) {
+	a, err := auth.New(auth.Options{
+		Tokens:   []auth.Token{{Name: "ops", Token: "ops-token-0123456789", Scopes: []string{auth.ScopeAdmin}}},
+		HMACKeys: []auth.HMACKey{{ID: "ci", Secret: "ci-secret-0123456789", Scopes: []string{auth.ScopeInteract}}},
+	})
+	require.NoError(t, err)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.Authenticate(r)
+		if err != nil {
+			writeJSON(w, http.StatusOK, response.Err(errors.ErrInvalidToken))
+			return
+		}
+
+		var req model.RequestBrowserOpenTab
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "https://example.com", req.Url)
+
+		writeJSON(w, http.StatusOK, response.New(p.Subject))
+	}
+
+	req := model.RequestBrowserOpenTab{Url: "https://example.com"}
+
+	srv := httptest.NewServer(http.HandlerFunc(handler))
+	t.Cleanup(srv.Close)
+
+	var subject string
+	require.NoError(t, New(srv.URL, WithToken("ops-token-0123456789")).post(context.Background(), "/browser/openTab", req, &subject))
+	assert.Equal(t, "ops", subject)
+
+	require.NoError(t, New(srv.URL, WithHMAC("ci", "ci-secret-0123456789")).post(context.Background(), "/browser/openTab?x=1", req, &subject))
+	assert.Equal(t, "ci", subject)
+
+	err = New(srv.URL, WithHMAC("ci", "wrong-secret-0123456789")).OpenTab(context.Background(), req)
+	assert.True(t, errors.EqualCodeError(err, errors.ErrInvalidToken))
+}
@@ -2,21 +2,92 @@ package httpserver
 
 import (
 	"browsertools/httpserver/model"
+	"browsertools/log"
 	"browsertools/pkg/browser"
+	"browsertools/pkg/config"
 	"browsertools/pkg/errors"
+	"browsertools/pkg/markdown"
+	"browsertools/pkg/processmanager"
 	"browsertools/pkg/response"
+	"browsertools/pkg/visual"
+	"browsertools/pkg/webhook"
 	"browsertools/pkg/xgin"
+	"context"
+	"encoding/json"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	liveStreamBoundary = "frame"
+	// pageEventBuffer 客户端接收慢时缓存的页面事件, 超出的事件被丢弃并计入 dropped
+	pageEventBuffer = 256
+	// pageClosedCheckInterval 检查订阅的标签页是否已经关闭的间隔
+	pageClosedCheckInterval = time.Second
 )
 
 type APIController struct {
-	manager *browser.BrowserManager
+	manager   *browser.BrowserManager
+	recorder  *browser.VideoRecorder
+	baselines *visual.BaselineStore
+	// processes 可选, 设置后就绪检查包含进程状态
+	processes *processmanager.ProcessManager
+	// webhooks 可选, 配置了推送地址时推送浏览器和进程事件
+	webhooks  *webhook.Dispatcher
+	startTime time.Time
+	// shutdown 服务关闭时关闭, 结束实时画面等长连接
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+func NewController(cfg *config.Config) *APIController {
+	manager := browser.NewBrowserManagerWithOptions(cfg.BrowserOptions())
+	if cfg.Pool.Enabled {
+		manager.EnablePool(cfg.BrowserOptions().Pool)
+	}
+
+	recorder := browser.NewVideoRecorder(cfg.RecordingDir())
+	recorder.SetRetention(cfg.RecordingRetention())
+
+	ctrl := &APIController{
+		manager:   manager,
+		recorder:  recorder,
+		baselines: visual.NewBaselineStore(cfg.BaselineDir()),
+		startTime: time.Now(),
+		shutdown:  make(chan struct{}),
+	}
+
+	if len(cfg.Webhook.Endpoints) > 0 {
+		ctrl.webhooks = webhook.New(cfg.WebhookOptions())
+		manager.OnEvent(ctrl.publishBrowserEvent)
+	}
+
+	return ctrl
 }
 
-func NewController() *APIController {
-	return &APIController{
-		manager: browser.NewBrowserManager(),
+// beginShutdown 通知长连接结束, 让 http.Server.Shutdown 可以等到所有请求完成
+func (a *APIController) beginShutdown() {
+	a.shutdownOnce.Do(func() {
+		close(a.shutdown)
+	})
+}
+
+// Close 停止录制并关闭所有浏览器和 Playwright 驱动, 在 HTTP 服务关闭后调用
+func (a *APIController) Close() {
+	a.beginShutdown()
+	a.recorder.StopAll()
+	a.manager.Close()
+
+	if a.webhooks != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookCloseTimeout)
+		defer cancel()
+
+		a.webhooks.Close(ctx)
 	}
 }
 
@@ -25,8 +96,7 @@ func (a *APIController) Screenshot(c *gin.Context) {
 
 	xgin.MustBindContext(c, &req)
 
-	b, err := a.manager.GetOrCreateBrowser()
-	errors.Check(err, "get browser error")
+	b := a.getBrowser(req.SessionID)
 
 	bytes, err := b.Screenshot()
 	errors.Check(err, "screenshot error")
@@ -44,21 +114,925 @@ func (a *APIController) OpenTab(c *gin.Context) {
 	var req model.RequestBrowserOpenTab
 	xgin.MustBindContext(c, &req)
 
-	b, err := a.manager.GetOrCreateBrowser()
-	errors.Check(err, "get browser error")
+	b := a.getBrowser(req.SessionID)
 
-	err = b.OpenTab(c, req.Url)
+	err := b.OpenTab(c, req.Url)
 	errors.Check(err, "open browser error")
 
 	c.JSON(http.StatusOK, response.New(nil))
 }
 
 func (a *APIController) GetConsoleLogs(c *gin.Context) {
-	b, err := a.manager.GetOrCreateBrowser()
-	errors.Check(err, "get browser error")
+	var req model.RequestConsoleLogs
+
+	// 兼容不带请求体的旧调用方式
+	if c.Request.ContentLength != 0 {
+		xgin.MustBindContext(c, &req)
+	}
 
-	logs := b.GetLogs()
+	logs := a.consoleLogs(req.SessionID, req.PageID)
 	resp := model.ResponseList{Total: int64(len(logs)), List: logs}
 
 	c.JSON(http.StatusOK, response.New(resp))
 }
+
+func (a *APIController) consoleLogs(sessionID string, pageID string) []string {
+	if pageID == "" {
+		return a.getBrowser(sessionID).GetLogs()
+	}
+
+	return a.getTab(sessionID, pageID).GetLogs()
+}
+
+func (a *APIController) StartRecording(c *gin.Context) {
+	var req model.RequestRecordingStart
+	xgin.MustBindContext(c, &req)
+
+	c.JSON(http.StatusOK, response.New(a.startRecording(req)))
+}
+
+func (a *APIController) startRecording(req model.RequestRecordingStart) model.ResponseRecording {
+	page := a.getTab(req.SessionID, req.PageID)
+
+	opt := browser.RecordingOptions{
+		FPS:         req.FPS,
+		Quality:     req.Quality,
+		MaxWidth:    req.MaxWidth,
+		MaxHeight:   req.MaxHeight,
+		MaxDuration: time.Duration(req.MaxDurationSec) * time.Second,
+		MaxBytes:    int64(req.MaxSizeMB) << 20,
+	}
+
+	recording, err := a.recorder.Start(page, opt)
+	errors.Check(err, "start recording error")
+
+	return toResponseRecording(recording)
+}
+
+func (a *APIController) StopRecording(c *gin.Context) {
+	var req model.RequestRecording
+	xgin.MustBindContext(c, &req)
+
+	recording, err := a.recorder.Stop(req.ID)
+	errors.Check(err, "stop recording error")
+
+	c.JSON(http.StatusOK, response.New(toResponseRecording(recording)))
+}
+
+// DeleteRecording 删除录制和文件, 正在进行的录制先停止
+func (a *APIController) DeleteRecording(c *gin.Context) {
+	var req model.RequestRecording
+	xgin.MustBindContext(c, &req)
+
+	errors.Check(a.recorder.Delete(req.ID), "delete recording error")
+
+	c.JSON(http.StatusOK, response.New(nil))
+}
+
+func (a *APIController) ListRecordings(c *gin.Context) {
+	list := a.listRecordings()
+
+	c.JSON(http.StatusOK, response.New(model.ResponseList{Total: int64(len(list)), List: list}))
+}
+
+func (a *APIController) listRecordings() []model.ResponseRecording {
+	recordings := a.recorder.List()
+
+	list := make([]model.ResponseRecording, 0, len(recordings))
+	for _, recording := range recordings {
+		list = append(list, toResponseRecording(recording))
+	}
+
+	return list
+}
+
+func (a *APIController) DownloadRecording(c *gin.Context) {
+	var req model.RequestRecording
+	xgin.MustBindQuery(c, &req)
+
+	recording, err := a.recorder.Get(req.ID)
+	errors.Check(err, "get recording error")
+
+	if recording.Status == browser.RecordingRunning {
+		errors.Throw(errors.ErrRecordingRunning)
+	}
+
+	c.FileAttachment(recording.Path, recording.ID+".avi")
+}
+
+// LiveStream 以 MJPEG(multipart/x-mixed-replace) 推送活动页面的实时画面, 直到客户端断开
+func (a *APIController) LiveStream(c *gin.Context) {
+	var req model.RequestLiveStream
+	xgin.MustBindQuery(c, &req)
+
+	stream, release := a.startLiveStream(req)
+	defer release()
+	defer stream.Close()
+
+	c.Header("Content-Type", "multipart/x-mixed-replace; boundary="+liveStreamBoundary)
+	c.Header("Cache-Control", "no-cache, no-store")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-a.shutdown:
+			return
+		case frame := <-stream.Frames():
+			_, err := fmt.Fprintf(c.Writer, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n",
+				liveStreamBoundary, len(frame.Data))
+			if err == nil {
+				_, err = c.Writer.Write(frame.Data)
+			}
+			if err == nil {
+				_, err = c.Writer.WriteString("\r\n")
+			}
+
+			if err != nil {
+				log.Infof("live stream client disconnected: %v", err)
+				return
+			}
+
+			c.Writer.Flush()
+		}
+	}
+}
+
+// PageEvents 以 Server-Sent Events 推送标签页的控制台消息和网络事件, 直到客户端断开或标签页关闭
+func (a *APIController) PageEvents(c *gin.Context) {
+	var req model.RequestPageEvents
+	xgin.MustBindQuery(c, &req)
+
+	page, release := a.useTab(req.SessionID, req.PageID)
+	defer release()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache, no-store")
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	err := a.watchPageEvents(c.Request.Context(), page, req.Types, func(activity browser.PageActivity, dropped int) error {
+		data, err := json.Marshal(toResponsePageEvent(activity, dropped))
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", activity.Type, data); err != nil {
+			return err
+		}
+
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		log.Infof("page events client disconnected: %v", err)
+	}
+}
+
+// watchPageEvents 把标签页的事件逐个交给 send, 直到 ctx 结束、标签页关闭、服务关闭或 send 返回错误
+// 事件回调在 playwright 的事件协程中执行, 不能阻塞, 缓存满时丢弃事件, 丢弃的数量随下一个事件传给 send
+func (a *APIController) watchPageEvents(ctx context.Context, page *browser.PageHandler, types []string,
+	send func(activity browser.PageActivity, dropped int) error) error {
+	filter := make(map[string]bool, len(types))
+	for _, typ := range types {
+		filter[typ] = true
+	}
+
+	events := make(chan browser.PageActivity, pageEventBuffer)
+	var dropped atomic.Int32
+
+	unsubscribe := page.OnActivity(func(activity browser.PageActivity) {
+		if len(filter) > 0 && !filter[activity.Type] {
+			return
+		}
+
+		select {
+		case events <- activity:
+		default:
+			dropped.Add(1)
+		}
+	})
+	defer unsubscribe()
+
+	ticker := time.NewTicker(pageClosedCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-a.shutdown:
+			return nil
+		case <-ticker.C:
+			if page.IsClosed() {
+				return nil
+			}
+		case activity := <-events:
+			if err := send(activity, int(dropped.Swap(0))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toResponsePageEvent(activity browser.PageActivity, dropped int) model.ResponsePageEvent {
+	return model.ResponsePageEvent{
+		Type:         activity.Type,
+		PageID:       activity.PageID,
+		Time:         activity.Time.UnixMilli(),
+		Level:        activity.Level,
+		Text:         activity.Text,
+		Location:     activity.Location,
+		Method:       activity.Method,
+		URL:          activity.URL,
+		ResourceType: activity.ResourceType,
+		Status:       activity.Status,
+		Error:        activity.Error,
+		Dropped:      dropped,
+	}
+}
+
+// startLiveStream 返回的 release 在画面推送结束后调用, 之前会话不会因空闲被关闭
+func (a *APIController) startLiveStream(req model.RequestLiveStream) (*browser.LiveStream, func()) {
+	opt := browser.ScreencastOptions{Quality: req.Quality, MaxWidth: req.MaxWidth, MaxHeight: req.MaxHeight}
+	if opt.Quality == 0 {
+		opt.Quality = 60
+	}
+
+	b, release, err := a.manager.AcquireSession(req.SessionID)
+	errors.Check(err, "get browser error")
+
+	stream, err := b.StartLiveStream(opt, req.FPS)
+	if err != nil {
+		release()
+	}
+	errors.Check(err, "start live stream error")
+
+	return stream, release
+}
+
+// VisualCompare 截图并与基准图逐像素对比, 返回差异比例和标出差异区域的差异图
+func (a *APIController) VisualCompare(c *gin.Context) {
+	var req model.RequestVisualCompare
+	xgin.MustBindContext(c, &req)
+
+	resp, diffImage := a.visualCompare(req)
+	if diffImage != nil {
+		resp.DiffImage = Base64Encode(diffImage)
+	}
+
+	c.JSON(http.StatusOK, response.New(resp))
+}
+
+// visualCompare 返回对比结果和 PNG 格式的差异图, 新建基准图时差异图为 nil
+func (a *APIController) visualCompare(req model.RequestVisualCompare) (model.ResponseVisualCompare, []byte) {
+	if !visual.ValidName(req.Name) {
+		errors.Throw(errors.ErrArgument, "invalid baseline name")
+	}
+
+	actual := a.visualScreenshot(req.SessionID, req.PageID, req.ViewportOnly, req.MaskSelectors)
+
+	baseline, err := a.baselines.Load(req.Name)
+	if err != nil && errors.EqualCodeError(err, errors.ErrBaselineNotFound) && req.CreateIfMissing {
+		errors.Check(a.baselines.Save(req.Name, actual), "save baseline error")
+
+		return model.ResponseVisualCompare{
+			Name:            req.Name,
+			BaselineCreated: true,
+			Regions:         []model.ResponseRegion{},
+		}, nil
+	}
+	errors.Check(err, "load baseline error")
+
+	errors.Check(a.baselines.SaveActual(req.Name, actual), "save actual screenshot error")
+
+	result, err := visual.DiffPNG(baseline, actual, visual.Options{Threshold: req.Threshold, IncludeAA: req.IncludeAA})
+	errors.Check(err, "diff screenshot error")
+
+	diffImage, err := result.EncodeDiffImage()
+	errors.Check(err, "encode diff image error")
+
+	regions := make([]model.ResponseRegion, 0, len(result.Regions))
+	for _, region := range result.Regions {
+		regions = append(regions, model.ResponseRegion{
+			X:      region.Min.X,
+			Y:      region.Min.Y,
+			Width:  region.Dx(),
+			Height: region.Dy(),
+		})
+	}
+
+	return model.ResponseVisualCompare{
+		Name:            req.Name,
+		MismatchPercent: result.MismatchPercent,
+		DiffPixels:      result.DiffPixels,
+		TotalPixels:     result.TotalPixels,
+		SizeMismatch:    result.SizeMismatch,
+		Regions:         regions,
+	}, diffImage
+}
+
+// VisualApprove 更新基准图, 使用最近一次对比的截图或者重新截图
+func (a *APIController) VisualApprove(c *gin.Context) {
+	var req model.RequestVisualApprove
+	xgin.MustBindContext(c, &req)
+
+	a.visualApprove(req)
+
+	c.JSON(http.StatusOK, response.New(nil))
+}
+
+func (a *APIController) visualApprove(req model.RequestVisualApprove) {
+	if !visual.ValidName(req.Name) {
+		errors.Throw(errors.ErrArgument, "invalid baseline name")
+	}
+
+	if req.FromLastActual {
+		errors.Check(a.baselines.Approve(req.Name), "approve baseline error")
+	} else {
+		data := a.visualScreenshot(req.SessionID, req.PageID, req.ViewportOnly, req.MaskSelectors)
+		errors.Check(a.baselines.Save(req.Name, data), "save baseline error")
+	}
+}
+
+func (a *APIController) ListBaselines(c *gin.Context) {
+	list := a.listBaselines()
+
+	c.JSON(http.StatusOK, response.New(model.ResponseList{Total: int64(len(list)), List: list}))
+}
+
+func (a *APIController) listBaselines() []model.ResponseBaseline {
+	baselines, err := a.baselines.List()
+	errors.Check(err, "list baselines error")
+
+	list := make([]model.ResponseBaseline, 0, len(baselines))
+	for _, baseline := range baselines {
+		list = append(list, model.ResponseBaseline{
+			Name:       baseline.Name,
+			Size:       baseline.Size,
+			UpdateTime: baseline.UpdateTime.UnixMilli(),
+		})
+	}
+
+	return list
+}
+
+// Markdown 提取页面正文并转换为 Markdown, 超出预算时分页返回
+func (a *APIController) Markdown(c *gin.Context) {
+	var req model.RequestMarkdown
+	xgin.MustBindContext(c, &req)
+
+	c.JSON(http.StatusOK, response.New(a.markdown(req)))
+}
+
+func (a *APIController) markdown(req model.RequestMarkdown) model.ResponseMarkdown {
+	content, url, err := a.getTab(req.SessionID, req.PageID).Content()
+	errors.Check(err, "get page content error")
+
+	doc, err := markdown.Convert(content, url, markdown.Options{FullPage: req.FullPage})
+	errors.Check(err, "convert markdown error")
+
+	pages := markdown.Split(doc.Markdown, req.MaxChars, req.MaxTokens)
+
+	index := max(req.Page, 1)
+	if index > len(pages) {
+		errors.Throw(errors.ErrArgument, "page out of range")
+	}
+
+	return model.ResponseMarkdown{
+		Url:             url,
+		Title:           doc.Title,
+		Markdown:        pages[index-1],
+		Page:            index,
+		TotalPages:      len(pages),
+		TotalChars:      utf8.RuneCountInString(doc.Markdown),
+		EstimatedTokens: markdown.EstimateTokens(doc.Markdown),
+	}
+}
+
+func (a *APIController) ExtractLinks(c *gin.Context) {
+	var req model.RequestExtract
+	xgin.MustBindContext(c, &req)
+
+	links, err := a.getTab(req.SessionID, req.PageID).ExtractLinks(req.Scope)
+	errors.Check(err, "extract links error")
+
+	c.JSON(http.StatusOK, response.New(model.ResponseList{Total: int64(len(links)), List: links}))
+}
+
+func (a *APIController) ExtractForms(c *gin.Context) {
+	var req model.RequestExtract
+	xgin.MustBindContext(c, &req)
+
+	forms, err := a.getTab(req.SessionID, req.PageID).ExtractForms(req.Scope)
+	errors.Check(err, "extract forms error")
+
+	c.JSON(http.StatusOK, response.New(model.ResponseList{Total: int64(len(forms)), List: forms}))
+}
+
+// ExtractTables 提取表格, format 为 objects(默认)、rows 或 csv
+func (a *APIController) ExtractTables(c *gin.Context) {
+	var req model.RequestExtractTables
+	xgin.MustBindContext(c, &req)
+
+	list := a.extractTables(req)
+
+	c.JSON(http.StatusOK, response.New(model.ResponseList{Total: int64(len(list)), List: list}))
+}
+
+func (a *APIController) extractTables(req model.RequestExtractTables) []model.ResponseTable {
+	tables, err := a.getTab(req.SessionID, req.PageID).ExtractTables(req.Scope)
+	errors.Check(err, "extract tables error")
+
+	list := make([]model.ResponseTable, 0, len(tables))
+	for _, table := range tables {
+		item := model.ResponseTable{Index: table.Index, ID: table.ID, Caption: table.Caption, Headers: table.Columns()}
+
+		switch req.Format {
+		case "rows":
+			item.Headers = table.Headers
+			item.Rows = table.Rows
+		case "csv":
+			item.CSV, err = table.CSV()
+			errors.Check(err, "convert table to csv error")
+		default:
+			item.Rows = table.Objects()
+		}
+
+		list = append(list, item)
+	}
+
+	return list
+}
+
+func (a *APIController) ExtractMetadata(c *gin.Context) {
+	var req model.RequestExtract
+	xgin.MustBindContext(c, &req)
+
+	metadata, err := a.getTab(req.SessionID, req.PageID).ExtractMetadata()
+	errors.Check(err, "extract metadata error")
+
+	c.JSON(http.StatusOK, response.New(metadata))
+}
+
+func (a *APIController) ExtractStructuredData(c *gin.Context) {
+	var req model.RequestExtract
+	xgin.MustBindContext(c, &req)
+
+	data, err := a.getTab(req.SessionID, req.PageID).ExtractStructuredData()
+	errors.Check(err, "extract structured data error")
+
+	c.JSON(http.StatusOK, response.New(data))
+}
+
+// Run 在一个标签页上按顺序执行多个步骤, 步骤失败不影响响应状态, 结果中包含每个步骤的状态和耗时
+func (a *APIController) Run(c *gin.Context) {
+	var req model.RequestRun
+	xgin.MustBindContext(c, &req)
+
+	c.JSON(http.StatusOK, response.New(toResponseRun(a.run(c.Request.Context(), req))))
+}
+
+func (a *APIController) run(ctx context.Context, req model.RequestRun) *browser.RunResult {
+	steps := make([]browser.Step, 0, len(req.Steps))
+	for i, step := range req.Steps {
+		steps = append(steps, toStep(i, step))
+	}
+
+	opt := browser.RunOptions{
+		StepTimeout: time.Duration(req.TimeoutMs) * time.Millisecond,
+		RetryDelay:  time.Duration(req.RetryDelayMs) * time.Millisecond,
+	}
+	mustCheckSteps(steps, opt)
+
+	page, release := a.useTab(req.SessionID, req.PageID)
+	defer release()
+
+	return page.RunSteps(ctx, steps, opt)
+}
+
+// mustCheckSteps 参数错误的步骤一定会失败, 在打开页面之前返回参数错误
+func mustCheckSteps(steps []browser.Step, opt browser.RunOptions) {
+	if err := browser.CheckSteps(steps, opt); err != nil {
+		errors.Throw(errors.ErrArgument, err.Error())
+	}
+}
+
+// toStep 转换并检查步骤, 不同动作需要的参数无法全部通过 validate 标签表达
+func toStep(index int, step model.RequestStep) browser.Step {
+	needSelector := step.Action == browser.StepClick || step.Action == browser.StepFill || step.Action == browser.StepSelect ||
+		(step.Action == browser.StepExtract && step.Extract == browser.ExtractAttribute) ||
+		(step.Action == browser.StepAssert && (step.Condition == browser.AssertVisible || step.Condition == browser.AssertHidden))
+	if needSelector && step.Selector == "" {
+		errors.Throw(errors.ErrArgument, fmt.Sprintf("step %d: selector is required", index))
+	}
+
+	return browser.Step{
+		Name:            step.Name,
+		Action:          step.Action,
+		URL:             step.URL,
+		Selector:        step.Selector,
+		Value:           step.Value,
+		Secret:          step.Secret,
+		Key:             step.Key,
+		State:           step.State,
+		Delay:           time.Duration(step.DelayMs) * time.Millisecond,
+		Script:          step.Script,
+		Arg:             step.Arg,
+		Extract:         step.Extract,
+		Attribute:       step.Attribute,
+		FullPage:        step.FullPage,
+		Condition:       step.Condition,
+		Expected:        step.Expected,
+		Timeout:         time.Duration(step.TimeoutMs) * time.Millisecond,
+		Retries:         step.Retries,
+		ContinueOnError: step.ContinueOnError,
+	}
+}
+
+func toResponseRun(result *browser.RunResult) model.ResponseRun {
+	resp := model.ResponseRun{
+		PageID:     result.PageID,
+		Success:    result.Success,
+		DurationMs: result.Duration.Milliseconds(),
+		Steps:      make([]model.ResponseStepResult, 0, len(result.Steps)),
+	}
+
+	for _, step := range result.Steps {
+		item := model.ResponseStepResult{
+			Index:      step.Index,
+			Name:       step.Name,
+			Action:     step.Action,
+			Status:     step.Status,
+			Attempts:   step.Attempts,
+			DurationMs: step.Duration.Milliseconds(),
+			Error:      step.Error,
+			Value:      step.Value,
+		}
+
+		if step.Artifact != nil {
+			item.Artifact = &model.ResponseArtifact{Type: step.Artifact.Type, Data: Base64Encode(step.Artifact.Data)}
+		}
+
+		resp.Steps = append(resp.Steps, item)
+	}
+
+	return resp
+}
+
+// StartInteraction 开始录制标签页上的用户操作, 用于把通过 VNC 的手动操作转换为自动化脚本
+func (a *APIController) StartInteraction(c *gin.Context) {
+	var req model.RequestInteractionStart
+	xgin.MustBindContext(c, &req)
+
+	page, err := a.getBrowser(req.SessionID).StartInteractionRecording(req.PageID)
+	errors.Check(err, "start interaction recording error")
+
+	c.JSON(http.StatusOK, response.New(model.ResponseInteraction{PageID: page.GetPageID(), Steps: []model.RequestStep{}}))
+}
+
+// StopInteraction 停止录制, 返回可以回放的步骤, 可选生成 Playwright 测试代码
+func (a *APIController) StopInteraction(c *gin.Context) {
+	var req model.RequestInteractionStop
+	xgin.MustBindContext(c, &req)
+
+	c.JSON(http.StatusOK, response.New(a.stopInteraction(req)))
+}
+
+func (a *APIController) stopInteraction(req model.RequestInteractionStop) model.ResponseInteraction {
+	page, steps, err := a.getBrowser(req.SessionID).StopInteractionRecording(req.PageID, req.IncludeSecrets)
+	errors.Check(err, "stop interaction recording error")
+
+	resp := model.ResponseInteraction{PageID: page.GetPageID(), Steps: make([]model.RequestStep, 0, len(steps)), Codegen: req.Codegen}
+	for _, step := range steps {
+		resp.Steps = append(resp.Steps, model.RequestStep{
+			Name:     step.Name,
+			Action:   step.Action,
+			URL:      step.URL,
+			Selector: step.Selector,
+			Value:    step.Value,
+			Secret:   step.Secret,
+			Key:      step.Key,
+		})
+	}
+
+	if req.Codegen != "" {
+		resp.Code, err = browser.GenerateCode(steps, req.Codegen)
+		errors.Check(err, "generate code error")
+	}
+
+	return resp
+}
+
+func (a *APIController) ListTabs(c *gin.Context) {
+	var req model.RequestTab
+	xgin.MustBindContext(c, &req)
+
+	list := a.listTabs(req.SessionID)
+
+	c.JSON(http.StatusOK, response.New(model.ResponseList{Total: int64(len(list)), List: list}))
+}
+
+func (a *APIController) listTabs(sessionID string) []model.ResponseTab {
+	b := a.getBrowser(sessionID)
+	active := b.GetActiveTab()
+
+	list := make([]model.ResponseTab, 0)
+	for _, page := range b.GetTabs() {
+		if page.IsClosed() {
+			continue
+		}
+
+		// 页面可能正在导航, 获取标题失败时留空; 卡死的页面获取标题会一直等到超时
+		var title string
+		state := page.GetState()
+		if state == browser.TabStateOK {
+			title, _ = page.GetPage().Title()
+		}
+
+		list = append(list, model.ResponseTab{
+			PageID:      page.GetPageID(),
+			Url:         page.GetPage().URL(),
+			Title:       title,
+			Active:      page == active,
+			Pinned:      page.IsPinned(),
+			CreateTime:  page.GetCreateTime().UnixMilli(),
+			LastUsed:    page.GetLastUsed().UnixMilli(),
+			State:       state,
+			OpenerID:    page.GetOpenerID(),
+			Popup:       page.IsPopup(),
+			WindowID:    page.GetWindowID(),
+			Navigations: page.GetNavigationCount(),
+		})
+	}
+
+	return list
+}
+
+// PinTab 固定的标签页不会因为数量、空闲或内存限制被关闭
+func (a *APIController) PinTab(c *gin.Context) {
+	var req model.RequestTabPin
+	xgin.MustBindContext(c, &req)
+
+	a.getTab(req.SessionID, req.PageID).SetPinned(req.Pinned)
+
+	c.JSON(http.StatusOK, response.New(nil))
+}
+
+func (a *APIController) CloseTab(c *gin.Context) {
+	var req model.RequestTab
+	xgin.MustBindContext(c, &req)
+
+	errors.Check(a.getBrowser(req.SessionID).CloseTab(req.PageID), "close tab error")
+
+	c.JSON(http.StatusOK, response.New(nil))
+}
+
+func (a *APIController) CreateSession(c *gin.Context) {
+	var req model.RequestSessionCreate
+	xgin.MustBindContext(c, &req)
+
+	c.JSON(http.StatusOK, response.New(a.createSession(req)))
+}
+
+func (a *APIController) createSession(req model.RequestSessionCreate) model.ResponseSession {
+	opt := browser.SessionOptions{Engine: req.Engine, HeadlessMode: req.HeadlessMode}
+
+	id, err := a.manager.CreateSession(req.SessionID, opt)
+	errors.Check(err, "create session error")
+
+	return model.ResponseSession{SessionID: id}
+}
+
+func (a *APIController) CloseSession(c *gin.Context) {
+	var req model.RequestSession
+	xgin.MustBindContext(c, &req)
+
+	a.closeSession(req.SessionID)
+
+	c.JSON(http.StatusOK, response.New(nil))
+}
+
+func (a *APIController) closeSession(sessionID string) {
+	if sessionID == "" {
+		errors.Throw(errors.ErrArgument, "session_id is required")
+	}
+
+	errors.Check(a.manager.CloseSession(sessionID), "close session error")
+}
+
+func (a *APIController) ListSessions(c *gin.Context) {
+	list := a.listSessions()
+
+	c.JSON(http.StatusOK, response.New(model.ResponseList{Total: int64(len(list)), List: list}))
+}
+
+func (a *APIController) listSessions() []model.ResponseSession {
+	sessions := a.manager.ListSessions()
+
+	list := make([]model.ResponseSession, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, model.ResponseSession{
+			SessionID:    s.ID,
+			Engine:       s.Engine,
+			HeadlessMode: s.HeadlessMode,
+			CreateTime:   s.CreateTime.UnixMilli(),
+			LastUsed:     s.LastUsed.UnixMilli(),
+			PageCount:    s.PageCount,
+		})
+	}
+
+	return list
+}
+
+// ListRecoveryEvents 返回最近的浏览器崩溃恢复事件, 最新的在前
+func (a *APIController) ListRecoveryEvents(c *gin.Context) {
+	events := a.manager.GetRecoveryEvents()
+
+	list := make([]model.ResponseRecoveryEvent, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		list = append(list, model.ResponseRecoveryEvent{
+			Type:         e.Type,
+			Success:      e.Success,
+			SessionID:    e.SessionID,
+			PageID:       e.PageID,
+			Attempts:     e.Attempts,
+			RestoredTabs: e.RestoredTabs,
+			DurationMs:   e.Duration.Milliseconds(),
+			Error:        e.Error,
+			Time:         e.Time.UnixMilli(),
+		})
+	}
+
+	c.JSON(http.StatusOK, response.New(model.ResponseList{Total: int64(len(list)), List: list}))
+}
+
+// LeaseBrowser 从浏览器池中独占租用一个浏览器, 返回的 lease_id 可作为其他接口的 session_id 使用
+func (a *APIController) LeaseBrowser(c *gin.Context) {
+	var req model.RequestPoolLease
+	xgin.MustBindContext(c, &req)
+
+	c.JSON(http.StatusOK, response.New(a.leaseBrowser(c, req)))
+}
+
+func (a *APIController) leaseBrowser(ctx context.Context, req model.RequestPoolLease) model.ResponseLease {
+	lease, err := a.manager.GetPool().Lease(ctx, time.Duration(req.TimeoutMs)*time.Millisecond)
+	errors.Check(err, "lease browser error")
+
+	return model.ResponseLease{
+		LeaseID:   lease.ID,
+		BrowserID: lease.BrowserID,
+		LeaseTime: lease.LeaseTime.UnixMilli(),
+	}
+}
+
+func (a *APIController) ReturnBrowser(c *gin.Context) {
+	var req model.RequestPoolReturn
+	xgin.MustBindContext(c, &req)
+
+	errors.Check(a.manager.GetPool().Return(req.LeaseID), "return browser error")
+
+	c.JSON(http.StatusOK, response.New(nil))
+}
+
+func (a *APIController) PoolStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, response.New(a.poolStatus()))
+}
+
+func (a *APIController) poolStatus() model.ResponsePoolStatus {
+	status := a.manager.GetPool().Status()
+
+	return model.ResponsePoolStatus{
+		MinSize:  status.MinSize,
+		MaxSize:  status.MaxSize,
+		Size:     status.Size,
+		Idle:     status.Idle,
+		Leased:   status.Leased,
+		Starting: status.Starting,
+	}
+}
+
+func (a *APIController) getBrowser(sessionID string) *browser.BrowserHandler {
+	b, err := a.manager.GetSession(sessionID)
+	errors.Check(err, "get browser error")
+
+	return b
+}
+
+// useTab 与 getTab 相同, 在调用 release 之前会话不会因空闲被关闭, 用于执行时间较长的请求
+func (a *APIController) useTab(sessionID string, pageID string) (*browser.PageHandler, func()) {
+	b, release, err := a.manager.AcquireSession(sessionID)
+	errors.Check(err, "get browser error")
+
+	page, err := b.GetTab(pageID)
+	if err != nil {
+		release()
+	}
+	errors.Check(err, "get page error")
+
+	return page, release
+}
+
+func (a *APIController) getTab(sessionID string, pageID string) *browser.PageHandler {
+	page, err := a.getBrowser(sessionID).GetTab(pageID)
+	errors.Check(err, "get page error")
+
+	return page
+}
+
+func (a *APIController) visualScreenshot(sessionID string, pageID string, viewportOnly bool, maskSelectors []string) []byte {
+	data, err := a.getTab(sessionID, pageID).ScreenshotWithOptions(browser.ScreenshotOptions{FullPage: !viewportOnly, MaskSelectors: maskSelectors})
+	errors.Check(err, "screenshot error")
+
+	return data
+}
+
+func toResponseRecording(r browser.Recording) model.ResponseRecording {
+	resp := model.ResponseRecording{
+		ID:        r.ID,
+		PageID:    r.PageID,
+		Status:    r.Status,
+		StartTime: r.StartTime.UnixMilli(),
+		Duration:  r.Duration().Seconds(),
+		Frames:    r.Frames,
+		Size:      r.Size,
+		Error:     r.Error,
+	}
+
+	if !r.EndTime.IsZero() {
+		resp.EndTime = r.EndTime.UnixMilli()
+	}
+
+	return resp
+}
+
+// Livez 存活检查, 只要服务能处理请求就返回成功, 浏览器异常不应导致服务被重启
+func (a *APIController) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, response.New(model.ResponseHealth{
+		Status: "ok",
+		Uptime: int64(time.Since(a.startTime).Seconds()),
+		Checks: make([]model.ResponseHealthCheck, 0),
+	}))
+}
+
+// Readyz 就绪检查, 浏览器不可用时返回 503, 避免流量被路由到浏览器已经异常的实例
+func (a *APIController) Readyz(c *gin.Context) {
+	health := a.manager.Health()
+
+	checks := make([]model.ResponseHealthCheck, 0, len(health.Checks)+1)
+	for _, check := range health.Checks {
+		checks = append(checks, model.ResponseHealthCheck{Name: check.Name, OK: check.OK, Message: check.Message})
+	}
+
+	if a.processes != nil {
+		checks = append(checks, processesCheck(a.processes.Status()))
+	}
+
+	resp := model.ResponseHealth{
+		Status:          "ok",
+		Uptime:          int64(time.Since(a.startTime).Seconds()),
+		Engine:          health.Engine,
+		HeadlessMode:    health.HeadlessMode,
+		BrowserPath:     health.Path,
+		Connected:       health.Connected,
+		PageCount:       health.PageCount,
+		LastLaunchError: health.LastLaunchError,
+		Checks:          checks,
+	}
+
+	if !health.LastLaunchTime.IsZero() {
+		resp.LastLaunchTime = health.LastLaunchTime.UnixMilli()
+	}
+
+	for _, check := range checks {
+		if !check.OK {
+			resp.Status = "fail"
+			c.JSON(http.StatusServiceUnavailable, &response.Response{
+				ErrorCode:   http.StatusServiceUnavailable,
+				Description: fmt.Sprintf("%s check failed: %s", check.Name, check.Message),
+				Data:        resp,
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, response.New(resp))
+}
+
+func processesCheck(list []processmanager.ProcessStatus) model.ResponseHealthCheck {
+	check := model.ResponseHealthCheck{Name: "processes", OK: true, Message: fmt.Sprintf("%d running", len(list))}
+
+	for _, p := range list {
+		if !p.Running {
+			check.OK = false
+			check.Message = fmt.Sprintf("%s is not running, restarts %d/%d", p.Name, p.Restarts, p.MaxRestarts)
+			break
+		}
+	}
+
+	return check
+}
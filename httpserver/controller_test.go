@@ -0,0 +1,69 @@
+package httpserver
+
+import (
+	"browsertools/httpserver/model"
+	"browsertools/pkg/processmanager"
+	"browsertools/pkg/response"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyzProcesses(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not found")
+	}
+
+	ctrl := newTestController(t)
+
+	pm := processmanager.NewProcessManager()
+	// 重启间隔足够长, 检查期间进程保持退出状态
+	pm.AddProcess("display", sleep, []string{"60"}, "", 1).SetRestartTimeout(time.Minute)
+	require.NoError(t, pm.StartAll())
+	t.Cleanup(pm.StopAll)
+
+	ctrl.processes = pm
+
+	router := gin.New()
+	router.GET("/readyz", ctrl.Readyz)
+
+	readyz := func() (int, model.ResponseHealthCheck) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var health model.ResponseHealth
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response.Response{Data: &health}))
+
+		for _, check := range health.Checks {
+			if check.Name == "processes" {
+				return w.Code, check
+			}
+		}
+
+		t.Fatalf("processes check not found in %s", w.Body.String())
+		return 0, model.ResponseHealthCheck{}
+	}
+
+	_, check := readyz()
+	assert.True(t, check.OK, check.Message)
+
+	require.NoError(t, syscall.Kill(pm.Status()[0].PID, syscall.SIGKILL))
+
+	assert.Eventually(t, func() bool {
+		_, check := readyz()
+		return !check.OK
+	}, 5*time.Second, 50*time.Millisecond)
+
+	code, check := readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, check.Message, "display is not running")
+}
@@ -0,0 +1,44 @@
+package httpserver
+
+import (
+	"browsertools/pkg/browser"
+	"browsertools/pkg/processmanager"
+	"browsertools/pkg/webhook"
+	"fmt"
+	"time"
+)
+
+// webhookCloseTimeout 关闭服务时等待剩余事件推送的时间, 超时后写入死信
+const webhookCloseTimeout = 5 * time.Second
+
+// publishBrowserEvent 转发浏览器事件, Publish 不会阻塞, 可以在 playwright 事件协程中调用
+func (a *APIController) publishBrowserEvent(event browser.BrowserEvent) {
+	a.webhooks.Publish(webhook.Event{
+		Type:      event.Type,
+		Time:      event.Time,
+		SessionID: event.SessionID,
+		PageID:    event.PageID,
+		URL:       event.URL,
+		Message:   event.Message,
+		Data:      event.Data,
+	})
+}
+
+func (a *APIController) publishProcessRestart(status processmanager.ProcessStatus, exitErr error) {
+	event := webhook.Event{
+		Type:    webhook.EventProcessRestarted,
+		Message: fmt.Sprintf("%s restarted, pid %d", status.Name, status.PID),
+		Data: map[string]interface{}{
+			"name":         status.Name,
+			"pid":          status.PID,
+			"restarts":     status.Restarts,
+			"max_restarts": status.MaxRestarts,
+		},
+	}
+
+	if exitErr != nil {
+		event.Data["exit_error"] = exitErr.Error()
+	}
+
+	a.webhooks.Publish(event)
+}
@@ -0,0 +1,592 @@
+package httpserver
+
+import (
+	"browsertools/httpserver/model"
+	"browsertools/pkg/auth"
+	"browsertools/pkg/browser"
+	"browsertools/pkg/browserpb"
+	"browsertools/pkg/errors"
+	"browsertools/pkg/xgrpc"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// grpcService 与 HTTP 接口共用 APIController, 请求转换为 model 中的请求后使用相同的校验规则
+type grpcService struct {
+	browserpb.UnimplementedBrowserServiceServer
+	ctrl *APIController
+}
+
+// grpcScopes 与对应的 HTTP 接口权限相同, 未列出的方法需要 admin 权限
+var grpcScopes = map[string]string{
+	browserpb.BrowserService_ListSessions_FullMethodName:     auth.ScopeRead,
+	browserpb.BrowserService_ListTabs_FullMethodName:         auth.ScopeRead,
+	browserpb.BrowserService_Screenshot_FullMethodName:       auth.ScopeRead,
+	browserpb.BrowserService_GetConsoleLogs_FullMethodName:   auth.ScopeRead,
+	browserpb.BrowserService_Markdown_FullMethodName:         auth.ScopeRead,
+	browserpb.BrowserService_Extract_FullMethodName:          auth.ScopeRead,
+	browserpb.BrowserService_ListRecordings_FullMethodName:   auth.ScopeRead,
+	browserpb.BrowserService_ListBaselines_FullMethodName:    auth.ScopeRead,
+	browserpb.BrowserService_PoolStatus_FullMethodName:       auth.ScopeRead,
+	browserpb.BrowserService_WatchPageEvents_FullMethodName:  auth.ScopeRead,
+	browserpb.BrowserService_StreamScreencast_FullMethodName: auth.ScopeRead,
+
+	browserpb.BrowserService_OpenTab_FullMethodName:          auth.ScopeInteract,
+	browserpb.BrowserService_PinTab_FullMethodName:           auth.ScopeInteract,
+	browserpb.BrowserService_CloseTab_FullMethodName:         auth.ScopeInteract,
+	browserpb.BrowserService_Run_FullMethodName:              auth.ScopeInteract,
+	browserpb.BrowserService_StartInteraction_FullMethodName: auth.ScopeInteract,
+	browserpb.BrowserService_StopInteraction_FullMethodName:  auth.ScopeInteract,
+	browserpb.BrowserService_StartRecording_FullMethodName:   auth.ScopeInteract,
+	browserpb.BrowserService_StopRecording_FullMethodName:    auth.ScopeInteract,
+	browserpb.BrowserService_VisualCompare_FullMethodName:    auth.ScopeInteract,
+	browserpb.BrowserService_VisualApprove_FullMethodName:    auth.ScopeInteract,
+	browserpb.BrowserService_LeaseBrowser_FullMethodName:     auth.ScopeInteract,
+	browserpb.BrowserService_ReturnBrowser_FullMethodName:    auth.ScopeInteract,
+
+	browserpb.BrowserService_CreateSession_FullMethodName: auth.ScopeAdmin,
+	browserpb.BrowserService_CloseSession_FullMethodName:  auth.ScopeAdmin,
+}
+
+// grpcScope 反射服务只列出接口定义, read 权限即可
+func grpcScope(fullMethod string) string {
+	if scope, ok := grpcScopes[fullMethod]; ok {
+		return scope
+	}
+
+	if strings.HasPrefix(fullMethod, "/grpc.reflection.") {
+		return auth.ScopeRead
+	}
+
+	return auth.ScopeAdmin
+}
+
+// newGRPCServer 错误通过 xgrpc 的拦截器转换为 gRPC 状态码, authenticator 不为 nil 时校验令牌和权限
+func (a *APIController) newGRPCServer(authenticator *auth.Authenticator) *grpc.Server {
+	unary := []grpc.UnaryServerInterceptor{xgrpc.UnaryRecovery()}
+	stream := []grpc.StreamServerInterceptor{xgrpc.StreamRecovery()}
+	if authenticator != nil {
+		unary = append(unary, xgrpc.UnaryAuth(authenticator, grpcScope))
+		stream = append(stream, xgrpc.StreamAuth(authenticator, grpcScope))
+	}
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+
+	browserpb.RegisterBrowserServiceServer(server, &grpcService{ctrl: a})
+	reflection.Register(server)
+
+	return server
+}
+
+func (s *grpcService) CreateSession(ctx context.Context, in *browserpb.CreateSessionRequest) (*browserpb.Session, error) {
+	req := model.RequestSessionCreate{SessionID: in.SessionId, Engine: in.Engine, HeadlessMode: in.HeadlessMode}
+	mustValidate(&req)
+
+	return toPBSession(s.ctrl.createSession(req)), nil
+}
+
+func (s *grpcService) CloseSession(ctx context.Context, in *browserpb.SessionRequest) (*emptypb.Empty, error) {
+	req := model.RequestSession{SessionID: in.SessionId}
+	mustValidate(&req)
+
+	s.ctrl.closeSession(req.SessionID)
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *grpcService) ListSessions(ctx context.Context, _ *emptypb.Empty) (*browserpb.ListSessionsResponse, error) {
+	sessions := s.ctrl.listSessions()
+
+	resp := &browserpb.ListSessionsResponse{Sessions: make([]*browserpb.Session, 0, len(sessions))}
+	for _, session := range sessions {
+		resp.Sessions = append(resp.Sessions, toPBSession(session))
+	}
+
+	return resp, nil
+}
+
+// OpenTab 返回打开后的活动标签页
+func (s *grpcService) OpenTab(ctx context.Context, in *browserpb.OpenTabRequest) (*browserpb.Tab, error) {
+	req := model.RequestBrowserOpenTab{SessionID: in.SessionId, Url: in.Url}
+	mustValidate(&req)
+
+	errors.Check(s.ctrl.getBrowser(req.SessionID).OpenTab(ctx, req.Url), "open browser error")
+
+	for _, tab := range s.ctrl.listTabs(req.SessionID) {
+		if tab.Active {
+			return toPBTab(tab), nil
+		}
+	}
+
+	return &browserpb.Tab{Url: req.Url}, nil
+}
+
+func (s *grpcService) ListTabs(ctx context.Context, in *browserpb.SessionRequest) (*browserpb.ListTabsResponse, error) {
+	tabs := s.ctrl.listTabs(in.SessionId)
+
+	resp := &browserpb.ListTabsResponse{Tabs: make([]*browserpb.Tab, 0, len(tabs))}
+	for _, tab := range tabs {
+		resp.Tabs = append(resp.Tabs, toPBTab(tab))
+	}
+
+	return resp, nil
+}
+
+func (s *grpcService) PinTab(ctx context.Context, in *browserpb.PinTabRequest) (*emptypb.Empty, error) {
+	s.ctrl.getTab(in.SessionId, in.PageId).SetPinned(in.Pinned)
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *grpcService) CloseTab(ctx context.Context, in *browserpb.TabRequest) (*emptypb.Empty, error) {
+	errors.Check(s.ctrl.getBrowser(in.SessionId).CloseTab(in.PageId), "close tab error")
+
+	return &emptypb.Empty{}, nil
+}
+
+// Screenshot 直接返回 PNG 数据, 不经过 base64 编码
+func (s *grpcService) Screenshot(ctx context.Context, in *browserpb.ScreenshotRequest) (*browserpb.Image, error) {
+	data := s.ctrl.visualScreenshot(in.SessionId, in.PageId, in.ViewportOnly, in.MaskSelectors)
+
+	return &browserpb.Image{MimeType: "image/png", Data: data}, nil
+}
+
+func (s *grpcService) GetConsoleLogs(ctx context.Context, in *browserpb.TabRequest) (*browserpb.ConsoleLogs, error) {
+	return &browserpb.ConsoleLogs{Logs: s.ctrl.consoleLogs(in.SessionId, in.PageId)}, nil
+}
+
+func (s *grpcService) Markdown(ctx context.Context, in *browserpb.MarkdownRequest) (*browserpb.MarkdownResponse, error) {
+	req := model.RequestMarkdown{
+		SessionID: in.SessionId,
+		PageID:    in.PageId,
+		FullPage:  in.FullPage,
+		MaxChars:  int(in.MaxChars),
+		MaxTokens: int(in.MaxTokens),
+		Page:      int(in.Page),
+	}
+	mustValidate(&req)
+
+	doc := s.ctrl.markdown(req)
+
+	return &browserpb.MarkdownResponse{
+		Url:             doc.Url,
+		Title:           doc.Title,
+		Markdown:        doc.Markdown,
+		Page:            int32(doc.Page),
+		TotalPages:      int32(doc.TotalPages),
+		TotalChars:      int32(doc.TotalChars),
+		EstimatedTokens: int32(doc.EstimatedTokens),
+	}, nil
+}
+
+// Extract 结果与对应的 /browser/extract/* 接口的 data 相同
+func (s *grpcService) Extract(ctx context.Context, in *browserpb.ExtractRequest) (*browserpb.ExtractResponse, error) {
+	var data interface{}
+
+	switch in.Kind {
+	case browser.ExtractTables:
+		req := model.RequestExtractTables{SessionID: in.SessionId, PageID: in.PageId, Scope: in.Scope, Format: in.Format}
+		mustValidate(&req)
+
+		data = s.ctrl.extractTables(req)
+	case browser.ExtractLinks:
+		links, err := s.ctrl.getTab(in.SessionId, in.PageId).ExtractLinks(in.Scope)
+		errors.Check(err, "extract links error")
+
+		data = links
+	case browser.ExtractForms:
+		forms, err := s.ctrl.getTab(in.SessionId, in.PageId).ExtractForms(in.Scope)
+		errors.Check(err, "extract forms error")
+
+		data = forms
+	case browser.ExtractMetadata:
+		metadata, err := s.ctrl.getTab(in.SessionId, in.PageId).ExtractMetadata()
+		errors.Check(err, "extract metadata error")
+
+		data = metadata
+	case browser.ExtractStructuredData:
+		structured, err := s.ctrl.getTab(in.SessionId, in.PageId).ExtractStructuredData()
+		errors.Check(err, "extract structured data error")
+
+		data = structured
+	default:
+		errors.Throw(errors.ErrArgument, "kind must be one of links forms tables metadata structured_data")
+	}
+
+	return &browserpb.ExtractResponse{Data: toPBValue(data)}, nil
+}
+
+// Run 与 /browser/run 一样, 步骤失败不会返回错误
+func (s *grpcService) Run(ctx context.Context, in *browserpb.RunRequest) (*browserpb.RunResponse, error) {
+	req := model.RequestRun{
+		SessionID:    in.SessionId,
+		PageID:       in.PageId,
+		TimeoutMs:    int(in.TimeoutMs),
+		RetryDelayMs: int(in.RetryDelayMs),
+		Steps:        make([]model.RequestStep, 0, len(in.Steps)),
+	}
+	for _, step := range in.Steps {
+		req.Steps = append(req.Steps, fromPBStep(step))
+	}
+	mustValidate(&req)
+
+	result := s.ctrl.run(ctx, req)
+
+	resp := &browserpb.RunResponse{
+		PageId:     result.PageID,
+		Success:    result.Success,
+		DurationMs: result.Duration.Milliseconds(),
+		Steps:      make([]*browserpb.StepResult, 0, len(result.Steps)),
+	}
+
+	for _, step := range result.Steps {
+		item := &browserpb.StepResult{
+			Index:      int32(step.Index),
+			Name:       step.Name,
+			Action:     step.Action,
+			Status:     step.Status,
+			Attempts:   int32(step.Attempts),
+			DurationMs: step.Duration.Milliseconds(),
+			Error:      step.Error,
+		}
+
+		if step.Value != nil {
+			item.Value = toPBValue(step.Value)
+		}
+
+		if step.Artifact != nil {
+			item.Artifact = &browserpb.Image{MimeType: "image/" + step.Artifact.Type, Data: step.Artifact.Data}
+		}
+
+		resp.Steps = append(resp.Steps, item)
+	}
+
+	return resp, nil
+}
+
+func (s *grpcService) StartInteraction(ctx context.Context, in *browserpb.TabRequest) (*browserpb.Interaction, error) {
+	page, err := s.ctrl.getBrowser(in.SessionId).StartInteractionRecording(in.PageId)
+	errors.Check(err, "start interaction recording error")
+
+	return &browserpb.Interaction{PageId: page.GetPageID()}, nil
+}
+
+func (s *grpcService) StopInteraction(ctx context.Context, in *browserpb.StopInteractionRequest) (*browserpb.Interaction, error) {
+	// gRPC 不支持 include_secrets, 总是去掉密码框的输入
+	req := model.RequestInteractionStop{SessionID: in.SessionId, PageID: in.PageId, Codegen: in.Codegen}
+	mustValidate(&req)
+
+	interaction := s.ctrl.stopInteraction(req)
+
+	resp := &browserpb.Interaction{
+		PageId:  interaction.PageID,
+		Steps:   make([]*browserpb.Step, 0, len(interaction.Steps)),
+		Codegen: interaction.Codegen,
+		Code:    interaction.Code,
+	}
+	for _, step := range interaction.Steps {
+		resp.Steps = append(resp.Steps, toPBStep(step))
+	}
+
+	return resp, nil
+}
+
+func (s *grpcService) StartRecording(ctx context.Context, in *browserpb.StartRecordingRequest) (*browserpb.Recording, error) {
+	req := model.RequestRecordingStart{
+		SessionID:      in.SessionId,
+		PageID:         in.PageId,
+		FPS:            int(in.Fps),
+		Quality:        int(in.Quality),
+		MaxWidth:       int(in.MaxWidth),
+		MaxHeight:      int(in.MaxHeight),
+		MaxDurationSec: int(in.MaxDurationSec),
+		MaxSizeMB:      int(in.MaxSizeMb),
+	}
+	mustValidate(&req)
+
+	return toPBRecording(s.ctrl.startRecording(req)), nil
+}
+
+func (s *grpcService) StopRecording(ctx context.Context, in *browserpb.RecordingRequest) (*browserpb.Recording, error) {
+	req := model.RequestRecording{ID: in.Id}
+	mustValidate(&req)
+
+	recording, err := s.ctrl.recorder.Stop(req.ID)
+	errors.Check(err, "stop recording error")
+
+	return toPBRecording(toResponseRecording(recording)), nil
+}
+
+func (s *grpcService) ListRecordings(ctx context.Context, _ *emptypb.Empty) (*browserpb.ListRecordingsResponse, error) {
+	recordings := s.ctrl.listRecordings()
+
+	resp := &browserpb.ListRecordingsResponse{Recordings: make([]*browserpb.Recording, 0, len(recordings))}
+	for _, recording := range recordings {
+		resp.Recordings = append(resp.Recordings, toPBRecording(recording))
+	}
+
+	return resp, nil
+}
+
+func (s *grpcService) VisualCompare(ctx context.Context, in *browserpb.VisualCompareRequest) (*browserpb.VisualCompareResponse, error) {
+	req := model.RequestVisualCompare{
+		SessionID:       in.SessionId,
+		Name:            in.Name,
+		PageID:          in.PageId,
+		ViewportOnly:    in.ViewportOnly,
+		MaskSelectors:   in.MaskSelectors,
+		Threshold:       in.Threshold,
+		IncludeAA:       in.IncludeAa,
+		CreateIfMissing: in.CreateIfMissing,
+	}
+	mustValidate(&req)
+
+	result, diffImage := s.ctrl.visualCompare(req)
+
+	resp := &browserpb.VisualCompareResponse{
+		Name:            result.Name,
+		BaselineCreated: result.BaselineCreated,
+		MismatchPercent: result.MismatchPercent,
+		DiffPixels:      int32(result.DiffPixels),
+		TotalPixels:     int32(result.TotalPixels),
+		SizeMismatch:    result.SizeMismatch,
+		Regions:         make([]*browserpb.Region, 0, len(result.Regions)),
+		DiffImage:       diffImage,
+	}
+	for _, region := range result.Regions {
+		resp.Regions = append(resp.Regions, &browserpb.Region{
+			X:      int32(region.X),
+			Y:      int32(region.Y),
+			Width:  int32(region.Width),
+			Height: int32(region.Height),
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *grpcService) VisualApprove(ctx context.Context, in *browserpb.VisualApproveRequest) (*emptypb.Empty, error) {
+	req := model.RequestVisualApprove{
+		SessionID:      in.SessionId,
+		Name:           in.Name,
+		PageID:         in.PageId,
+		ViewportOnly:   in.ViewportOnly,
+		MaskSelectors:  in.MaskSelectors,
+		FromLastActual: in.FromLastActual,
+	}
+	mustValidate(&req)
+
+	s.ctrl.visualApprove(req)
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *grpcService) ListBaselines(ctx context.Context, _ *emptypb.Empty) (*browserpb.ListBaselinesResponse, error) {
+	baselines := s.ctrl.listBaselines()
+
+	resp := &browserpb.ListBaselinesResponse{Baselines: make([]*browserpb.Baseline, 0, len(baselines))}
+	for _, baseline := range baselines {
+		resp.Baselines = append(resp.Baselines, &browserpb.Baseline{
+			Name:       baseline.Name,
+			Size:       baseline.Size,
+			UpdateTime: baseline.UpdateTime,
+		})
+	}
+
+	return resp, nil
+}
+
+func (s *grpcService) LeaseBrowser(ctx context.Context, in *browserpb.LeaseBrowserRequest) (*browserpb.Lease, error) {
+	req := model.RequestPoolLease{TimeoutMs: int(in.TimeoutMs)}
+	mustValidate(&req)
+
+	lease := s.ctrl.leaseBrowser(ctx, req)
+
+	return &browserpb.Lease{LeaseId: lease.LeaseID, BrowserId: lease.BrowserID, LeaseTime: lease.LeaseTime}, nil
+}
+
+func (s *grpcService) ReturnBrowser(ctx context.Context, in *browserpb.ReturnBrowserRequest) (*emptypb.Empty, error) {
+	req := model.RequestPoolReturn{LeaseID: in.LeaseId}
+	mustValidate(&req)
+
+	errors.Check(s.ctrl.manager.GetPool().Return(req.LeaseID), "return browser error")
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *grpcService) PoolStatus(ctx context.Context, _ *emptypb.Empty) (*browserpb.PoolStatusResponse, error) {
+	status := s.ctrl.poolStatus()
+
+	return &browserpb.PoolStatusResponse{
+		MinSize:  int32(status.MinSize),
+		MaxSize:  int32(status.MaxSize),
+		Size:     int32(status.Size),
+		Idle:     int32(status.Idle),
+		Leased:   int32(status.Leased),
+		Starting: int32(status.Starting),
+	}, nil
+}
+
+// WatchPageEvents 推送标签页的控制台消息和网络事件, 直到客户端断开、标签页关闭或服务关闭
+func (s *grpcService) WatchPageEvents(in *browserpb.WatchPageEventsRequest, stream browserpb.BrowserService_WatchPageEventsServer) error {
+	req := model.RequestPageEvents{SessionID: in.SessionId, PageID: in.PageId, Types: in.Types}
+	mustValidate(&req)
+
+	page, release := s.ctrl.useTab(req.SessionID, req.PageID)
+	defer release()
+
+	return s.ctrl.watchPageEvents(stream.Context(), page, req.Types, func(activity browser.PageActivity, dropped int) error {
+		return stream.Send(&browserpb.PageEvent{
+			Type:         activity.Type,
+			PageId:       activity.PageID,
+			Time:         activity.Time.UnixMilli(),
+			Level:        activity.Level,
+			Text:         activity.Text,
+			Location:     activity.Location,
+			Method:       activity.Method,
+			Url:          activity.URL,
+			ResourceType: activity.ResourceType,
+			Status:       int32(activity.Status),
+			Error:        activity.Error,
+			Dropped:      int32(dropped),
+		})
+	})
+}
+
+// StreamScreencast 与 /browser/live 相同, 推送活动标签页的 JPEG 画面, 仅支持 Chromium
+func (s *grpcService) StreamScreencast(in *browserpb.StreamScreencastRequest, stream browserpb.BrowserService_StreamScreencastServer) error {
+	req := model.RequestLiveStream{
+		SessionID: in.SessionId,
+		Quality:   int(in.Quality),
+		MaxWidth:  int(in.MaxWidth),
+		MaxHeight: int(in.MaxHeight),
+		FPS:       int(in.Fps),
+	}
+	mustValidate(&req)
+
+	live, release := s.ctrl.startLiveStream(req)
+	defer release()
+	defer live.Close()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.ctrl.shutdown:
+			return nil
+		case frame := <-live.Frames():
+			err := stream.Send(&browserpb.ScreencastFrame{
+				PageId:    frame.PageID,
+				Data:      frame.Data,
+				Timestamp: frame.Timestamp.UnixMilli(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toPBSession(session model.ResponseSession) *browserpb.Session {
+	return &browserpb.Session{
+		SessionId:    session.SessionID,
+		Engine:       session.Engine,
+		HeadlessMode: session.HeadlessMode,
+		CreateTime:   session.CreateTime,
+		LastUsed:     session.LastUsed,
+		PageCount:    int32(session.PageCount),
+	}
+}
+
+func toPBTab(tab model.ResponseTab) *browserpb.Tab {
+	return &browserpb.Tab{
+		PageId:      tab.PageID,
+		Url:         tab.Url,
+		Title:       tab.Title,
+		Active:      tab.Active,
+		Pinned:      tab.Pinned,
+		CreateTime:  tab.CreateTime,
+		LastUsed:    tab.LastUsed,
+		State:       tab.State,
+		OpenerId:    tab.OpenerID,
+		Popup:       tab.Popup,
+		WindowId:    tab.WindowID,
+		Navigations: int32(tab.Navigations),
+	}
+}
+
+func toPBRecording(recording model.ResponseRecording) *browserpb.Recording {
+	return &browserpb.Recording{
+		Id:        recording.ID,
+		PageId:    recording.PageID,
+		Status:    recording.Status,
+		StartTime: recording.StartTime,
+		EndTime:   recording.EndTime,
+		Duration:  recording.Duration,
+		Frames:    int32(recording.Frames),
+		Size:      recording.Size,
+		Error:     recording.Error,
+	}
+}
+
+func fromPBStep(step *browserpb.Step) model.RequestStep {
+	req := model.RequestStep{
+		Name:            step.Name,
+		Action:          step.Action,
+		URL:             step.Url,
+		Selector:        step.Selector,
+		Value:           step.Value,
+		Secret:          step.Secret,
+		Key:             step.Key,
+		State:           step.State,
+		DelayMs:         int(step.DelayMs),
+		Script:          step.Script,
+		Extract:         step.Extract,
+		Attribute:       step.Attribute,
+		FullPage:        step.FullPage,
+		Condition:       step.Condition,
+		Expected:        step.Expected,
+		TimeoutMs:       int(step.TimeoutMs),
+		Retries:         int(step.Retries),
+		ContinueOnError: step.ContinueOnError,
+	}
+
+	if step.Arg != nil {
+		req.Arg = step.Arg.AsInterface()
+	}
+
+	return req
+}
+
+func toPBStep(step model.RequestStep) *browserpb.Step {
+	return &browserpb.Step{
+		Name:     step.Name,
+		Action:   step.Action,
+		Url:      step.URL,
+		Selector: step.Selector,
+		Value:    step.Value,
+		Secret:   step.Secret,
+		Key:      step.Key,
+	}
+}
+
+// toPBValue 通过 JSON 转换, 保证与 HTTP 接口返回的字段名相同
+func toPBValue(v interface{}) *structpb.Value {
+	data, err := json.Marshal(v)
+	errors.Check(err, "marshal value error")
+
+	var value structpb.Value
+	errors.Check(protojson.Unmarshal(data, &value), "convert value error")
+
+	return &value
+}
@@ -0,0 +1,385 @@
+package httpserver
+
+import (
+	"browsertools/httpserver/model"
+	"browsertools/pkg/browser"
+	"browsertools/pkg/errors"
+	"browsertools/pkg/mcp"
+	"browsertools/pkg/xgin"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	mcpServerName    = "browsertools"
+	mcpServerVersion = apiVersion
+)
+
+// mcpTarget 大部分工具都可以指定会话和标签页, 为空时使用默认浏览器和当前活动标签页
+type mcpTarget struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+}
+
+// mcpStepArgs 单个动作的工具, 参数与 /browser/run 的步骤相同
+type mcpStepArgs struct {
+	mcpTarget
+	model.RequestStep
+}
+
+// newMCPServer 把浏览器能力注册为 MCP 工具, 与 HTTP 接口共用同一个 BrowserManager
+func (a *APIController) newMCPServer() *mcp.Server {
+	server := mcp.NewServer(mcpServerName, mcpServerVersion)
+
+	target := map[string]interface{}{
+		"session_id": mcpProp("string", "Named session, empty for the default browser"),
+		"page_id":    mcpProp("string", "Tab ID from browser_list_tabs, empty for the active tab"),
+	}
+	timeoutMs := mcpProp("integer", "Timeout in milliseconds, default 30000")
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_open_tab",
+		Description: "Open a URL in a new tab, or in the blank start tab if it is the only one.",
+		InputSchema: mcpSchema([]string{"url"}, map[string]interface{}{
+			"session_id": target["session_id"],
+			"url":        mcpProp("string", "URL to open"),
+		}),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var req model.RequestBrowserOpenTab
+			mustBindArgs(args, &req)
+
+			b := a.getBrowser(req.SessionID)
+			errors.Check(b.OpenTab(ctx, req.Url), "open browser error")
+
+			page := b.GetActiveTab()
+			if page == nil {
+				return mcpText("opened %s", req.Url), nil
+			}
+
+			return mcpText("opened %s in tab %s", page.GetPage().URL(), page.GetPageID()), nil
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_screenshot",
+		Description: "Take a PNG screenshot of a tab.",
+		InputSchema: mcpSchema(nil, mcpMerge(target, map[string]interface{}{
+			"viewport_only": mcpProp("boolean", "Capture only the visible viewport instead of the full page"),
+		})),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var req struct {
+				mcpTarget
+				ViewportOnly bool `json:"viewport_only"`
+			}
+			mustBindArgs(args, &req)
+
+			data, err := a.getTab(req.SessionID, req.PageID).ScreenshotWithOptions(browser.ScreenshotOptions{FullPage: !req.ViewportOnly})
+			errors.Check(err, "screenshot error")
+
+			return &mcp.ToolResult{Content: []mcp.Content{mcp.ImageContent(data, "image/png")}}, nil
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_console_logs",
+		Description: "Get console messages collected from a tab.",
+		InputSchema: mcpSchema(nil, target),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var req mcpTarget
+			mustBindArgs(args, &req)
+
+			return mcpJSON(a.getTab(req.SessionID, req.PageID).GetLogs()), nil
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_list_tabs",
+		Description: "List open tabs with their IDs, URLs, titles and which one is active.",
+		InputSchema: mcpSchema(nil, map[string]interface{}{"session_id": target["session_id"]}),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var req mcpTarget
+			mustBindArgs(args, &req)
+
+			return mcpJSON(a.listTabs(req.SessionID)), nil
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_close_tab",
+		Description: "Close a tab.",
+		InputSchema: mcpSchema([]string{"page_id"}, target),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var req model.RequestTab
+			mustBindArgs(args, &req)
+
+			errors.Check(a.getBrowser(req.SessionID).CloseTab(req.PageID), "close tab error")
+
+			return mcpText("closed tab %s", req.PageID), nil
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_markdown",
+		Description: "Get the readable content of a tab as Markdown, split into pages for long documents.",
+		InputSchema: mcpSchema(nil, mcpMerge(target, map[string]interface{}{
+			"full_page":  mcpProp("boolean", "Keep navigation, headers and footers instead of only the main content"),
+			"max_tokens": mcpProp("integer", "Max estimated tokens per page"),
+			"page":       mcpProp("integer", "Page number, starting from 1"),
+		})),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var req model.RequestMarkdown
+			mustBindArgs(args, &req)
+
+			doc := a.markdown(req)
+			header := fmt.Sprintf("# %s\nURL: %s\nPage %d of %d\n\n", doc.Title, doc.Url, doc.Page, doc.TotalPages)
+
+			return &mcp.ToolResult{Content: []mcp.Content{mcp.TextContent(header + doc.Markdown)}}, nil
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_extract",
+		Description: "Extract links, forms, tables, metadata or structured data (JSON-LD, microdata) from a tab.",
+		InputSchema: mcpSchema([]string{"kind"}, mcpMerge(target, map[string]interface{}{
+			"kind":  mcpEnum("What to extract", browser.ExtractLinks, browser.ExtractForms, browser.ExtractTables, browser.ExtractMetadata, browser.ExtractStructuredData),
+			"scope": mcpProp("string", "CSS selector limiting links, forms and tables to part of the page"),
+		})),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var req struct {
+				mcpTarget
+				Kind  string `json:"kind" validate:"oneof=links forms tables metadata structured_data"`
+				Scope string `json:"scope"`
+			}
+			mustBindArgs(args, &req)
+
+			return a.runMCPSteps(ctx, req.mcpTarget, browser.Step{Action: browser.StepExtract, Extract: req.Kind, Selector: req.Scope}), nil
+		},
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_navigate",
+		Description: "Navigate a tab to a URL and wait for the page to load.",
+		InputSchema: mcpSchema([]string{"url"}, mcpMerge(target, map[string]interface{}{
+			"url":        mcpProp("string", "URL to navigate to"),
+			"timeout_ms": timeoutMs,
+		})),
+		Handler: a.mcpStepHandler(browser.StepNavigate),
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_click",
+		Description: "Click the first element matching a selector. Playwright selectors such as text=\"Sign in\" are supported.",
+		InputSchema: mcpSchema([]string{"selector"}, mcpMerge(target, map[string]interface{}{
+			"selector":   mcpProp("string", "Element selector"),
+			"timeout_ms": timeoutMs,
+		})),
+		Handler: a.mcpStepHandler(browser.StepClick),
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_fill",
+		Description: "Replace the value of an input, textarea or contenteditable element.",
+		InputSchema: mcpSchema([]string{"selector", "value"}, mcpMerge(target, map[string]interface{}{
+			"selector":   mcpProp("string", "Element selector"),
+			"value":      mcpProp("string", "Value to fill"),
+			"timeout_ms": timeoutMs,
+		})),
+		Handler: a.mcpStepHandler(browser.StepFill),
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_select",
+		Description: "Select an option of a <select> element by value.",
+		InputSchema: mcpSchema([]string{"selector", "value"}, mcpMerge(target, map[string]interface{}{
+			"selector":   mcpProp("string", "Element selector"),
+			"value":      mcpProp("string", "Option value"),
+			"timeout_ms": timeoutMs,
+		})),
+		Handler: a.mcpStepHandler(browser.StepSelect),
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_press",
+		Description: "Press a key such as Enter or Control+A, on an element or on the focused element.",
+		InputSchema: mcpSchema([]string{"key"}, mcpMerge(target, map[string]interface{}{
+			"key":        mcpProp("string", "Key name"),
+			"selector":   mcpProp("string", "Element selector, empty for the focused element"),
+			"timeout_ms": timeoutMs,
+		})),
+		Handler: a.mcpStepHandler(browser.StepPress),
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_wait",
+		Description: "Wait for an element to reach a state, or wait for a fixed delay when no selector is given.",
+		InputSchema: mcpSchema(nil, mcpMerge(target, map[string]interface{}{
+			"selector":   mcpProp("string", "Element selector"),
+			"state":      mcpEnum("Element state, default visible", "visible", "hidden", "attached", "detached"),
+			"delay_ms":   mcpProp("integer", "Delay in milliseconds when no selector is given"),
+			"timeout_ms": timeoutMs,
+		})),
+		Handler: a.mcpStepHandler(browser.StepWait),
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_evaluate",
+		Description: "Evaluate a JavaScript expression or function in a tab and return the JSON result.",
+		InputSchema: mcpSchema([]string{"script"}, mcpMerge(target, map[string]interface{}{
+			"script":     mcpProp("string", "Expression or function source, e.g. () => document.title"),
+			"arg":        map[string]interface{}{"description": "Argument passed to the function"},
+			"timeout_ms": timeoutMs,
+		})),
+		Handler: a.mcpStepHandler(browser.StepEvaluate),
+	})
+
+	server.AddTool(mcp.Tool{
+		Name:        "browser_run",
+		Description: "Run a sequence of steps on a tab in one call. Each step has an action (navigate, wait, click, fill, select, press, evaluate, screenshot, extract, assert) and the same fields as the single-action tools.",
+		InputSchema: mcpSchema([]string{"steps"}, mcpMerge(target, map[string]interface{}{
+			"steps": map[string]interface{}{
+				"type":        "array",
+				"description": "Steps to run in order; a failed step stops the run unless continue_on_error is set",
+				"items": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"action"},
+					"properties": map[string]interface{}{
+						"action":            mcpEnum("Step action", browser.StepNavigate, browser.StepWait, browser.StepClick, browser.StepFill, browser.StepSelect, browser.StepPress, browser.StepEvaluate, browser.StepScreenshot, browser.StepExtract, browser.StepAssert),
+						"name":              mcpProp("string", "Label shown in the result"),
+						"url":               mcpProp("string", "navigate URL"),
+						"selector":          mcpProp("string", "Target element"),
+						"value":             mcpProp("string", "fill or select value"),
+						"key":               mcpProp("string", "press key"),
+						"script":            mcpProp("string", "evaluate or assert evaluate script"),
+						"extract":           mcpProp("string", "extract kind: text, html, attribute, links, forms, tables, metadata or structured_data"),
+						"attribute":         mcpProp("string", "Attribute name for extract attribute"),
+						"condition":         mcpProp("string", "assert condition: visible, hidden, text_contains, text_equals, url_contains, title_contains or evaluate"),
+						"expected":          mcpProp("string", "Expected value for assert"),
+						"timeout_ms":        timeoutMs,
+						"retries":           mcpProp("integer", "Retries after a failure"),
+						"continue_on_error": mcpProp("boolean", "Run the next steps even if this one fails"),
+					},
+				},
+			},
+		})),
+		Handler: func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+			var req model.RequestRun
+			mustBindArgs(args, &req)
+
+			steps := make([]browser.Step, 0, len(req.Steps))
+			for i, step := range req.Steps {
+				steps = append(steps, toStep(i, step))
+			}
+
+			return a.runMCPSteps(ctx, mcpTarget{SessionID: req.SessionID, PageID: req.PageID}, steps...), nil
+		},
+	})
+
+	return server
+}
+
+// mcpStepHandler 单个动作的工具, 与 /browser/run 的一个步骤相同
+func (a *APIController) mcpStepHandler(action string) mcp.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (*mcp.ToolResult, error) {
+		// 动作由工具决定, 需要先设置再校验, 否则 Action 的 oneof 校验总是失败
+		var req mcpStepArgs
+		mustUnmarshalArgs(args, &req)
+		req.Action = action
+		mustValidate(&req)
+
+		return a.runMCPSteps(ctx, req.mcpTarget, toStep(0, req.RequestStep)), nil
+	}
+}
+
+// runMCPSteps 截图作为图片返回, 其余结果以 JSON 文本返回, 任一步骤失败时结果标记为错误
+func (a *APIController) runMCPSteps(ctx context.Context, target mcpTarget, steps ...browser.Step) *mcp.ToolResult {
+	mustCheckSteps(steps, browser.RunOptions{})
+
+	page, release := a.useTab(target.SessionID, target.PageID)
+	defer release()
+
+	result := page.RunSteps(ctx, steps, browser.RunOptions{})
+	resp := toResponseRun(result)
+
+	content := make([]mcp.Content, 0, 1)
+	for i, step := range result.Steps {
+		if step.Artifact != nil {
+			content = append(content, mcp.ImageContent(step.Artifact.Data, "image/png"))
+			resp.Steps[i].Artifact = nil
+		}
+	}
+
+	// 只有一个步骤时直接返回步骤的结果
+	if len(resp.Steps) == 1 {
+		step := resp.Steps[0]
+		switch {
+		case step.Error != "":
+			content = append(content, mcp.TextContent(step.Error))
+		case step.Value != nil:
+			content = append(content, mcp.JSONContent(step.Value))
+		case len(content) == 0:
+			content = append(content, mcp.TextContent(fmt.Sprintf("%s done in %v", step.Action, time.Duration(step.DurationMs)*time.Millisecond)))
+		}
+	} else {
+		content = append(content, mcp.JSONContent(resp))
+	}
+
+	return &mcp.ToolResult{Content: content, IsError: !result.Success}
+}
+
+// mustBindArgs 与 MustBindContext 一样, 参数错误时抛出 ErrArgument
+func mustBindArgs(args json.RawMessage, req interface{}) {
+	mustUnmarshalArgs(args, req)
+	mustValidate(req)
+}
+
+// mustUnmarshalArgs 只解析参数, 不校验
+func mustUnmarshalArgs(args json.RawMessage, req interface{}) {
+	if err := json.Unmarshal(args, req); err != nil {
+		errors.Throw(errors.ErrArgument, err.Error())
+	}
+}
+
+// mustValidate 校验不是通过 gin 绑定的请求, 例如 MCP 工具参数和 gRPC 请求
+func mustValidate(req interface{}) {
+	if err := xgin.Validate(req); err != nil {
+		errors.Throw(errors.ErrArgument, err.Error())
+	}
+}
+
+func mcpText(format string, args ...interface{}) *mcp.ToolResult {
+	return &mcp.ToolResult{Content: []mcp.Content{mcp.TextContent(fmt.Sprintf(format, args...))}}
+}
+
+func mcpJSON(v interface{}) *mcp.ToolResult {
+	return &mcp.ToolResult{Content: []mcp.Content{mcp.JSONContent(v)}}
+}
+
+func mcpSchema(required []string, properties map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func mcpProp(typ string, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
+
+func mcpEnum(description string, values ...string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description, "enum": values}
+}
+
+func mcpMerge(maps ...map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, m := range maps {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
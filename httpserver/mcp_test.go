@@ -0,0 +1,93 @@
+package httpserver
+
+import (
+	"browsertools/pkg/config"
+	"browsertools/pkg/errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestController 不连接也不启动浏览器, 需要浏览器的操作返回 BrowserNotInstalled
+func newTestController(t *testing.T) *APIController {
+	cfg := config.Default()
+	cfg.Browser.CDPEndpoint = ""
+	cfg.Browser.PathCandidates = []string{filepath.Join(t.TempDir(), "missing-chrome")}
+	cfg.Storage.Dir = t.TempDir()
+
+	ctrl := NewController(cfg)
+	t.Cleanup(ctrl.Close)
+
+	return ctrl
+}
+
+type mcpToolResult struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	IsError bool `json:"isError"`
+}
+
+func callMCPTool(t *testing.T, ctrl *APIController, name string, args string) mcpToolResult {
+	t.Helper()
+
+	message := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":%q,"arguments":%s}}`, name, args)
+
+	var resp struct {
+		Result mcpToolResult `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(ctrl.newMCPServer().HandleMessage(context.Background(), []byte(message)), &resp))
+	require.NotEmpty(t, resp.Result.Content)
+
+	return resp.Result
+}
+
+func TestMCPStepTools(t *testing.T) {
+	ctrl := newTestController(t)
+
+	tools := map[string]string{
+		"browser_navigate": `{"url":"https://example.com"}`,
+		"browser_click":    `{"selector":"#submit"}`,
+		"browser_fill":     `{"selector":"#name","value":"alice"}`,
+		"browser_select":   `{"selector":"#country","value":"cn"}`,
+		"browser_press":    `{"key":"Enter"}`,
+		"browser_wait":     `{"selector":"#done","state":"visible"}`,
+		"browser_evaluate": `{"script":"() => document.title"}`,
+	}
+
+	for name, args := range tools {
+		// 参数校验通过后才会获取浏览器
+		result := callMCPTool(t, ctrl, name, args)
+		assert.True(t, result.IsError, name)
+		assert.Contains(t, result.Content[0].Text, errors.BrowserNotInstalled.Error(), name)
+		assert.NotContains(t, result.Content[0].Text, errors.ErrArgument.Error(), name)
+	}
+}
+
+func TestMCPStepToolsInvalidArgs(t *testing.T) {
+	ctrl := newTestController(t)
+
+	calls := []struct {
+		name string
+		args string
+	}{
+		{"browser_navigate", `{}`},
+		{"browser_press", `{"selector":"#name"}`},
+		{"browser_evaluate", `{"script":""}`},
+		{"browser_wait", `{"state":"gone"}`},
+		// 固定等待超过默认的步骤超时时间
+		{"browser_wait", `{"delay_ms":60000}`},
+	}
+
+	for _, call := range calls {
+		result := callMCPTool(t, ctrl, call.name, call.args)
+		assert.True(t, result.IsError, call.args)
+		assert.Contains(t, result.Content[0].Text, errors.ErrArgument.Error(), call.args)
+	}
+}
@@ -1,10 +1,163 @@
 package model
 
 type RequestScreenshot struct {
+	SessionID   string `json:"session_id"`
 	ImageType   string `json:"image_type" validate:"required"`
 	ImageFormat string `json:"image_format" validate:"required"`
 }
 
 type RequestBrowserOpenTab struct {
-	Url string `json:"url" validate:"required"`
+	SessionID string `json:"session_id"`
+	Url       string `json:"url" validate:"required"`
+}
+
+type RequestConsoleLogs struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+}
+
+type RequestSession struct {
+	SessionID string `json:"session_id" validate:"omitempty,max=64"`
+}
+
+type RequestSessionCreate struct {
+	SessionID    string `json:"session_id" validate:"omitempty,max=64"`
+	Engine       string `json:"engine" validate:"omitempty,oneof=chromium firefox webkit"`
+	HeadlessMode string `json:"headless_mode" validate:"omitempty,oneof=headful headless new-headless"`
+}
+
+type RequestTab struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+}
+
+type RequestTabPin struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	Pinned    bool   `json:"pinned"`
+}
+
+type RequestPoolLease struct {
+	TimeoutMs int `json:"timeout_ms" validate:"omitempty,min=0,max=600000"`
+}
+
+type RequestPoolReturn struct {
+	LeaseID string `json:"lease_id" validate:"required"`
+}
+
+type RequestRecordingStart struct {
+	SessionID      string `json:"session_id"`
+	PageID         string `json:"page_id"`
+	FPS            int    `json:"fps" validate:"omitempty,min=1,max=25"`
+	Quality        int    `json:"quality" validate:"omitempty,min=1,max=100"`
+	MaxWidth       int    `json:"max_width" validate:"omitempty,min=1"`
+	MaxHeight      int    `json:"max_height" validate:"omitempty,min=1"`
+	MaxDurationSec int    `json:"max_duration_sec" validate:"omitempty,min=1,max=3600"`
+	MaxSizeMB      int    `json:"max_size_mb" validate:"omitempty,min=1,max=1024"`
+}
+
+type RequestRecording struct {
+	ID string `json:"id" form:"id" validate:"required"`
+}
+
+type RequestLiveStream struct {
+	SessionID string `json:"session_id" form:"session_id"`
+	Quality   int    `json:"quality" form:"quality" validate:"omitempty,min=1,max=100"`
+	MaxWidth  int    `json:"max_width" form:"max_width" validate:"omitempty,min=1"`
+	MaxHeight int    `json:"max_height" form:"max_height" validate:"omitempty,min=1"`
+	FPS       int    `json:"fps" form:"fps" validate:"omitempty,min=1,max=30"`
+}
+
+type RequestPageEvents struct {
+	SessionID string `json:"session_id" form:"session_id"`
+	PageID    string `json:"page_id" form:"page_id"`
+	// Types 只推送这些类型的事件, 为空时推送全部
+	Types []string `json:"types" form:"types" validate:"dive,oneof=console request response requestfailed"`
+}
+
+type RequestVisualCompare struct {
+	SessionID       string   `json:"session_id"`
+	Name            string   `json:"name" validate:"required"`
+	PageID          string   `json:"page_id"`
+	ViewportOnly    bool     `json:"viewport_only"`
+	MaskSelectors   []string `json:"mask_selectors"`
+	Threshold       float64  `json:"threshold" validate:"omitempty,gt=0,lte=1"`
+	IncludeAA       bool     `json:"include_aa"`
+	CreateIfMissing bool     `json:"create_if_missing"`
+}
+
+type RequestVisualApprove struct {
+	SessionID      string   `json:"session_id"`
+	Name           string   `json:"name" validate:"required"`
+	PageID         string   `json:"page_id"`
+	ViewportOnly   bool     `json:"viewport_only"`
+	MaskSelectors  []string `json:"mask_selectors"`
+	FromLastActual bool     `json:"from_last_actual"`
+}
+
+type RequestMarkdown struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	FullPage  bool   `json:"full_page"`
+	MaxChars  int    `json:"max_chars" validate:"omitempty,min=100"`
+	MaxTokens int    `json:"max_tokens" validate:"omitempty,min=50"`
+	Page      int    `json:"page" validate:"omitempty,min=1"`
+}
+
+type RequestExtract struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	Scope     string `json:"scope"`
+}
+
+type RequestExtractTables struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	Scope     string `json:"scope"`
+	Format    string `json:"format" validate:"omitempty,oneof=objects rows csv"`
+}
+
+type RequestRun struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	// TimeoutMs 步骤的默认超时时间
+	TimeoutMs    int           `json:"timeout_ms" validate:"omitempty,min=100,max=600000"`
+	RetryDelayMs int           `json:"retry_delay_ms" validate:"omitempty,min=0,max=60000"`
+	Steps        []RequestStep `json:"steps" validate:"required,min=1,max=100,dive"`
+}
+
+type RequestStep struct {
+	Name            string      `json:"name,omitempty"`
+	Action          string      `json:"action" validate:"oneof=navigate wait click fill select press evaluate screenshot extract assert"`
+	URL             string      `json:"url,omitempty" validate:"required_if=Action navigate"`
+	Selector        string      `json:"selector,omitempty"`
+	Value           string      `json:"value,omitempty"`
+	Secret          bool        `json:"secret,omitempty"`
+	Key             string      `json:"key,omitempty" validate:"required_if=Action press"`
+	State           string      `json:"state,omitempty" validate:"omitempty,oneof=visible hidden attached detached"`
+	DelayMs         int         `json:"delay_ms,omitempty" validate:"omitempty,min=0,max=600000"`
+	Script          string      `json:"script,omitempty" validate:"required_if=Action evaluate"`
+	Arg             interface{} `json:"arg,omitempty"`
+	Extract         string      `json:"extract,omitempty" validate:"omitempty,oneof=text html attribute links forms tables metadata structured_data"`
+	Attribute       string      `json:"attribute,omitempty"`
+	FullPage        bool        `json:"full_page,omitempty"`
+	Condition       string      `json:"condition,omitempty" validate:"required_if=Action assert,omitempty,oneof=visible hidden text_contains text_equals url_contains title_contains evaluate"`
+	Expected        string      `json:"expected,omitempty"`
+	TimeoutMs       int         `json:"timeout_ms,omitempty" validate:"omitempty,min=100,max=600000"`
+	Retries         int         `json:"retries,omitempty" validate:"omitempty,min=0,max=10"`
+	ContinueOnError bool        `json:"continue_on_error,omitempty"`
+}
+
+type RequestInteractionStart struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+}
+
+type RequestInteractionStop struct {
+	SessionID string `json:"session_id"`
+	PageID    string `json:"page_id"`
+	// Codegen 同时生成 Playwright 测试代码: go 或 typescript, 为空时不生成
+	Codegen string `json:"codegen" validate:"omitempty,oneof=go typescript"`
+	// IncludeSecrets 在步骤和代码中保留密码框的原值, 默认去掉, 生成的代码从 BROWSERTOOLS_SECRET_N 环境变量读取
+	IncludeSecrets bool `json:"include_secrets"`
 }
@@ -9,3 +9,182 @@ type ResponseScreenshot struct {
 	ImageType string `json:"image_type"`
 	Data      string `json:"data"`
 }
+
+type ResponseRecording struct {
+	ID        string  `json:"id"`
+	PageID    string  `json:"page_id"`
+	Status    string  `json:"status"`
+	StartTime int64   `json:"start_time"`
+	EndTime   int64   `json:"end_time,omitempty"`
+	Duration  float64 `json:"duration"`
+	Frames    int     `json:"frames"`
+	Size      int64   `json:"size"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type ResponsePageEvent struct {
+	// Type console, request, response 或 requestfailed
+	Type   string `json:"type"`
+	PageID string `json:"page_id"`
+	Time   int64  `json:"time"`
+	// Level 控制台消息的类型, 例如 log, warning, error
+	Level        string `json:"level,omitempty"`
+	Text         string `json:"text,omitempty"`
+	Location     string `json:"location,omitempty"`
+	Method       string `json:"method,omitempty"`
+	URL          string `json:"url,omitempty"`
+	ResourceType string `json:"resource_type,omitempty"`
+	Status       int    `json:"status,omitempty"`
+	Error        string `json:"error,omitempty"`
+	// Dropped 接收太慢时在这个事件之前被丢弃的事件数
+	Dropped int `json:"dropped,omitempty"`
+}
+
+type ResponseRegion struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type ResponseVisualCompare struct {
+	Name            string           `json:"name"`
+	BaselineCreated bool             `json:"baseline_created"`
+	MismatchPercent float64          `json:"mismatch_percent"`
+	DiffPixels      int              `json:"diff_pixels"`
+	TotalPixels     int              `json:"total_pixels"`
+	SizeMismatch    bool             `json:"size_mismatch"`
+	Regions         []ResponseRegion `json:"regions"`
+	DiffImage       string           `json:"diff_image,omitempty"`
+}
+
+type ResponseBaseline struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	UpdateTime int64  `json:"update_time"`
+}
+
+type ResponseMarkdown struct {
+	Url             string `json:"url"`
+	Title           string `json:"title"`
+	Markdown        string `json:"markdown"`
+	Page            int    `json:"page"`
+	TotalPages      int    `json:"total_pages"`
+	TotalChars      int    `json:"total_chars"`
+	EstimatedTokens int    `json:"estimated_tokens"`
+}
+
+type ResponseTable struct {
+	Index   int         `json:"index"`
+	ID      string      `json:"id"`
+	Caption string      `json:"caption"`
+	Headers []string    `json:"headers"`
+	Rows    interface{} `json:"rows,omitempty"`
+	CSV     string      `json:"csv,omitempty"`
+}
+
+type ResponseTab struct {
+	PageID     string `json:"page_id"`
+	Url        string `json:"url"`
+	Title      string `json:"title"`
+	Active     bool   `json:"active"`
+	Pinned     bool   `json:"pinned"`
+	CreateTime int64  `json:"create_time"`
+	LastUsed   int64  `json:"last_used"`
+	// State ok, hung 或 crashed
+	State string `json:"state"`
+	// OpenerID 打开此标签页的标签页, Popup 表示由 window.open 或 target=_blank 打开
+	OpenerID    string `json:"opener_id,omitempty"`
+	Popup       bool   `json:"popup"`
+	WindowID    int64  `json:"window_id,omitempty"`
+	Navigations int    `json:"navigations"`
+}
+
+type ResponseRecoveryEvent struct {
+	Type         string `json:"type"`
+	Success      bool   `json:"success"`
+	SessionID    string `json:"session_id,omitempty"`
+	PageID       string `json:"page_id,omitempty"`
+	Attempts     int    `json:"attempts"`
+	RestoredTabs int    `json:"restored_tabs"`
+	DurationMs   int64  `json:"duration_ms"`
+	Error        string `json:"error,omitempty"`
+	Time         int64  `json:"time"`
+}
+
+type ResponseLease struct {
+	LeaseID   string `json:"lease_id"`
+	BrowserID string `json:"browser_id"`
+	LeaseTime int64  `json:"lease_time"`
+}
+
+type ResponsePoolStatus struct {
+	MinSize  int `json:"min_size"`
+	MaxSize  int `json:"max_size"`
+	Size     int `json:"size"`
+	Idle     int `json:"idle"`
+	Leased   int `json:"leased"`
+	Starting int `json:"starting"`
+}
+
+type ResponseSession struct {
+	SessionID    string `json:"session_id"`
+	Engine       string `json:"engine,omitempty"`
+	HeadlessMode string `json:"headless_mode,omitempty"`
+	CreateTime   int64  `json:"create_time,omitempty"`
+	LastUsed     int64  `json:"last_used,omitempty"`
+	PageCount    int    `json:"page_count"`
+}
+
+type ResponseHealthCheck struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+type ResponseHealth struct {
+	// Status ok 或 fail
+	Status          string                `json:"status"`
+	Uptime          int64                 `json:"uptime"`
+	Engine          string                `json:"engine,omitempty"`
+	HeadlessMode    string                `json:"headless_mode,omitempty"`
+	BrowserPath     string                `json:"browser_path,omitempty"`
+	Connected       bool                  `json:"connected"`
+	PageCount       int                   `json:"page_count"`
+	LastLaunchTime  int64                 `json:"last_launch_time,omitempty"`
+	LastLaunchError string                `json:"last_launch_error,omitempty"`
+	Checks          []ResponseHealthCheck `json:"checks"`
+}
+
+type ResponseRun struct {
+	PageID     string               `json:"page_id"`
+	Success    bool                 `json:"success"`
+	DurationMs int64                `json:"duration_ms"`
+	Steps      []ResponseStepResult `json:"steps"`
+}
+
+type ResponseStepResult struct {
+	Index      int               `json:"index"`
+	Name       string            `json:"name,omitempty"`
+	Action     string            `json:"action"`
+	Status     string            `json:"status"`
+	Attempts   int               `json:"attempts"`
+	DurationMs int64             `json:"duration_ms"`
+	Error      string            `json:"error,omitempty"`
+	Value      interface{}       `json:"value,omitempty"`
+	Artifact   *ResponseArtifact `json:"artifact,omitempty"`
+}
+
+type ResponseArtifact struct {
+	Type string `json:"type"`
+	// Data base64 编码的文件内容
+	Data string `json:"data"`
+}
+
+type ResponseInteraction struct {
+	PageID string `json:"page_id"`
+	// Steps 可以直接作为 /browser/run 的 steps 回放
+	Steps   []RequestStep `json:"steps"`
+	Codegen string        `json:"codegen,omitempty"`
+	Code    string        `json:"code,omitempty"`
+}
@@ -0,0 +1,300 @@
+package httpserver
+
+import (
+	"browsertools/httpserver/model"
+	"browsertools/pkg/browser"
+	"browsertools/pkg/errors"
+	"browsertools/pkg/openapi"
+	"browsertools/pkg/response"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	openAPITitle = "browsertools"
+	apiVersion   = "1.0.0"
+)
+
+// apiDoc 一个接口的文档, 请求和响应类型用于生成 schema
+type apiDoc struct {
+	Summary     string
+	Description string
+	// Request 请求体或查询参数的类型, Query 为 true 时作为查询参数
+	Request interface{}
+	Query   bool
+	// Response data 字段的类型, List 为 true 时 data 为 ResponseList, list 的元素类型为 Response
+	Response interface{}
+	List     bool
+	// ContentType 不是统一响应格式的接口, 例如文件下载
+	ContentType string
+}
+
+// apiDocs 以 "METHOD path" 为键, 路由在 New 中注册, 没有文档的路由只生成基本信息
+var apiDocs = map[string]apiDoc{
+	"GET /health":  {Summary: "Health check", Response: ""},
+	"GET /livez":   {Summary: "Liveness check", Response: model.ResponseHealth{}},
+	"GET /readyz":  {Summary: "Readiness check, returns HTTP 503 with code 503 when a check fails", Response: model.ResponseHealth{}},
+	"GET /metrics": {Summary: "Prometheus metrics", ContentType: "text/plain"},
+	"GET /openapi.json": {
+		Summary:     "This OpenAPI document",
+		ContentType: "application/json",
+	},
+	"POST /mcp": {
+		Summary:     "MCP Streamable HTTP endpoint",
+		Description: "JSON-RPC 2.0 messages of the Model Context Protocol. The Mcp-Session-Id header returned by initialize must be sent with later requests.",
+		ContentType: "application/json",
+	},
+	"GET /mcp":    {Summary: "Not supported, the server does not push messages", ContentType: "text/plain"},
+	"DELETE /mcp": {Summary: "End an MCP session", ContentType: "text/plain"},
+
+	"POST /browser/screenshot":     {Summary: "Take a screenshot of the active tab", Request: model.RequestScreenshot{}, Response: model.ResponseScreenshot{}},
+	"POST /browser/openTab":        {Summary: "Open a URL in a new tab", Request: model.RequestBrowserOpenTab{}},
+	"POST /browser/getConsoleLogs": {Summary: "Get console logs of a tab, the request body is optional", Request: model.RequestConsoleLogs{}, Response: "", List: true},
+
+	"POST /browser/tab/list":  {Summary: "List open tabs", Request: model.RequestTab{}, Response: model.ResponseTab{}, List: true},
+	"POST /browser/tab/pin":   {Summary: "Pin a tab so that it is never closed by the tab policy", Request: model.RequestTabPin{}},
+	"POST /browser/tab/close": {Summary: "Close a tab", Request: model.RequestTab{}},
+
+	"POST /browser/session/create": {Summary: "Create a named browser session", Request: model.RequestSessionCreate{}, Response: model.ResponseSession{}},
+	"POST /browser/session/close":  {Summary: "Close a named browser session", Request: model.RequestSession{}},
+	"POST /browser/session/list":   {Summary: "List browser sessions", Response: model.ResponseSession{}, List: true},
+
+	"POST /browser/recovery/events": {Summary: "List recent crash recovery events, newest first", Response: model.ResponseRecoveryEvent{}, List: true},
+
+	"POST /browser/pool/lease":  {Summary: "Lease a browser from the pool, lease_id can be used as session_id", Request: model.RequestPoolLease{}, Response: model.ResponseLease{}},
+	"POST /browser/pool/return": {Summary: "Return a leased browser to the pool", Request: model.RequestPoolReturn{}},
+	"POST /browser/pool/status": {Summary: "Browser pool status", Response: model.ResponsePoolStatus{}},
+
+	"POST /browser/recording/start":  {Summary: "Start recording a tab as video", Request: model.RequestRecordingStart{}, Response: model.ResponseRecording{}},
+	"POST /browser/recording/stop":   {Summary: "Stop a recording", Request: model.RequestRecording{}, Response: model.ResponseRecording{}},
+	"POST /browser/recording/delete": {Summary: "Delete a recording and its file, stopping it first when running", Request: model.RequestRecording{}},
+	"POST /browser/recording/list":   {Summary: "List recordings", Response: model.ResponseRecording{}, List: true},
+	"GET /browser/recording/download": {
+		Summary: "Download a finished recording", Request: model.RequestRecording{}, Query: true, ContentType: "video/x-msvideo",
+	},
+
+	"GET /browser/live": {
+		Summary: "Live MJPEG stream of the active tab", Request: model.RequestLiveStream{}, Query: true, ContentType: "multipart/x-mixed-replace",
+	},
+	"GET /browser/events": {
+		Summary:     "Stream console messages and network requests of a tab",
+		Description: "Server-Sent Events until the tab is closed; the event name is the type and the data is a JSON ResponsePageEvent.",
+		Request:     model.RequestPageEvents{}, Query: true, Response: model.ResponsePageEvent{}, ContentType: "text/event-stream",
+	},
+
+	"POST /browser/visual/compare":   {Summary: "Compare a screenshot with its baseline", Request: model.RequestVisualCompare{}, Response: model.ResponseVisualCompare{}},
+	"POST /browser/visual/approve":   {Summary: "Save a screenshot as the new baseline", Request: model.RequestVisualApprove{}},
+	"POST /browser/visual/baselines": {Summary: "List visual baselines", Response: model.ResponseBaseline{}, List: true},
+
+	"POST /browser/markdown": {Summary: "Convert the readable content of a tab to Markdown", Request: model.RequestMarkdown{}, Response: model.ResponseMarkdown{}},
+
+	"POST /browser/run": {
+		Summary:     "Run a sequence of steps on a tab",
+		Description: "Failed steps do not fail the request, check success and the status of each step.",
+		Request:     model.RequestRun{}, Response: model.ResponseRun{},
+	},
+
+	"POST /browser/interaction/start": {Summary: "Start recording user interactions, headful browsers only", Request: model.RequestInteractionStart{}, Response: model.ResponseInteraction{}},
+	"POST /browser/interaction/stop":  {Summary: "Stop recording user interactions and return replayable steps", Request: model.RequestInteractionStop{}, Response: model.ResponseInteraction{}},
+
+	"POST /browser/extract/links":          {Summary: "Extract links", Request: model.RequestExtract{}, Response: browser.Link{}, List: true},
+	"POST /browser/extract/forms":          {Summary: "Extract forms and their fields", Request: model.RequestExtract{}, Response: browser.Form{}, List: true},
+	"POST /browser/extract/tables":         {Summary: "Extract tables", Request: model.RequestExtractTables{}, Response: model.ResponseTable{}, List: true},
+	"POST /browser/extract/metadata":       {Summary: "Extract page metadata", Request: model.RequestExtract{}, Response: browser.Metadata{}},
+	"POST /browser/extract/structuredData": {Summary: "Extract JSON-LD and microdata", Request: model.RequestExtract{}, Response: browser.StructuredData{}},
+}
+
+// newOpenAPIDocument 根据已注册的路由生成 OpenAPI 文档
+func newOpenAPIDocument(routes gin.RoutesInfo) *openapi.Document {
+	reflector := openapi.NewReflector()
+	reflector.Schemas["Response"] = responseSchema()
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       openAPITitle,
+			Version:     apiVersion,
+			Description: openAPIDescription(),
+		},
+		Paths: make(map[string]openapi.PathItem),
+	}
+
+	for _, route := range routes {
+		doc.Paths[route.Path] = addOperation(doc.Paths[route.Path], reflector, route.Method, route.Path)
+	}
+
+	doc.Components.Schemas = reflector.Schemas
+
+	return doc
+}
+
+func addOperation(item openapi.PathItem, reflector *openapi.Reflector, method string, path string) openapi.PathItem {
+	if item == nil {
+		item = make(openapi.PathItem)
+	}
+
+	doc := apiDocs[method+" "+path]
+	op := &openapi.Operation{
+		OperationID: operationID(method, path),
+		Summary:     doc.Summary,
+		Description: doc.Description,
+		Tags:        []string{operationTag(path)},
+		Responses:   make(map[string]openapi.Response),
+	}
+
+	if doc.Request != nil {
+		schema := reflector.Schema(doc.Request)
+		if doc.Query {
+			op.Parameters = queryParameters(reflector, schema)
+		} else {
+			op.RequestBody = &openapi.RequestBody{
+				Content: map[string]openapi.MediaType{gin.MIMEJSON: {Schema: schema}},
+			}
+		}
+	}
+
+	if doc.ContentType != "" {
+		// 流式接口的 Response 为每条消息的类型
+		schema := &openapi.Schema{}
+		if doc.Response != nil {
+			schema = reflector.Schema(doc.Response)
+		}
+
+		op.Responses["200"] = openapi.Response{
+			Description: "OK",
+			Content:     map[string]openapi.MediaType{doc.ContentType: {Schema: schema}},
+		}
+	} else {
+		op.Responses["200"] = openapi.Response{
+			Description: "Response envelope, code is 200 on success or an error code on failure",
+			Content:     map[string]openapi.MediaType{gin.MIMEJSON: {Schema: envelopeSchema(reflector, doc)}},
+		}
+	}
+
+	item[strings.ToLower(method)] = op
+
+	return item
+}
+
+// envelopeSchema response.Response 包装的 data 类型
+func envelopeSchema(reflector *openapi.Reflector, doc apiDoc) *openapi.Schema {
+	if doc.Response == nil {
+		return openapi.Ref("Response")
+	}
+
+	data := reflector.Schema(doc.Response)
+	if doc.List {
+		data = &openapi.Schema{
+			Type:     "object",
+			Required: []string{"total", "list"},
+			Properties: map[string]*openapi.Schema{
+				"total": {Type: "integer", Format: "int64"},
+				"list":  {Type: "array", Items: data},
+			},
+		}
+	}
+
+	return &openapi.Schema{AllOf: []*openapi.Schema{
+		openapi.Ref("Response"),
+		{Type: "object", Properties: map[string]*openapi.Schema{"data": data}},
+	}}
+}
+
+func responseSchema() *openapi.Schema {
+	codes := make([]interface{}, 0, len(errors.Codes())+1)
+	codes = append(codes, response.SuccessCode)
+	for _, code := range errors.Codes() {
+		codes = append(codes, code.Code())
+	}
+
+	return &openapi.Schema{
+		Type:     "object",
+		Required: []string{"code", "msg"},
+		Properties: map[string]*openapi.Schema{
+			"code": {Type: "integer", Description: "200 on success, see the error code table in the description", Enum: codes},
+			"msg":  {Type: "string"},
+			"data": {Description: "Result, absent when the request failed or has no result"},
+		},
+	}
+}
+
+func openAPIDescription() string {
+	codes := errors.Codes()
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].Code() < codes[j].Code()
+	})
+
+	var b strings.Builder
+	b.WriteString("Requests fail with HTTP 200 and a response envelope whose code is not 200.\n\n")
+	b.WriteString("When auth is enabled, /browser, /mcp and /metrics require `Authorization: Bearer <token or JWT>` ")
+	b.WriteString("or HMAC signed requests (X-Browsertools-Key, X-Browsertools-Timestamp, X-Browsertools-Signature). ")
+	b.WriteString("Missing or invalid credentials fail with code 400, insufficient scope with HTTP 403 and code 401. ")
+	b.WriteString("Scopes: read for screenshots, logs, lists and page content; interact for opening tabs, running steps and recording; ")
+	b.WriteString("admin for sessions and metrics. A higher scope includes the lower ones.\n\n")
+	b.WriteString("| code | msg |\n| --- | --- |\n")
+	for _, code := range codes {
+		fmt.Fprintf(&b, "| %d | %s |\n", code.Code(), code.Error())
+	}
+
+	return b.String()
+}
+
+func queryParameters(reflector *openapi.Reflector, schema *openapi.Schema) []openapi.Parameter {
+	if schema.Ref != "" {
+		schema = reflector.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := make([]openapi.Parameter, 0, len(names))
+	for _, name := range names {
+		params = append(params, openapi.Parameter{
+			Name:     name,
+			In:       "query",
+			Required: slices.Contains(schema.Required, name),
+			Schema:   schema.Properties[name],
+		})
+	}
+
+	return params
+}
+
+// operationID POST 接口为路径的驼峰形式, 例如 /browser/tab/list 为 browserTabList, 其他方法加上方法名, 例如 getHealth
+func operationID(method string, path string) string {
+	var b strings.Builder
+	if method != http.MethodPost {
+		b.WriteString(strings.ToLower(method))
+	}
+
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '.' }) {
+		if b.Len() == 0 {
+			b.WriteString(part)
+		} else {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+
+	return b.String()
+}
+
+// operationTag /browser/tab/list 为 tab, /browser/screenshot 为 browser, 其余为 system
+func operationTag(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) > 2:
+		return parts[1]
+	case parts[0] == "browser":
+		return "browser"
+	}
+
+	return "system"
+}
@@ -1,37 +1,268 @@
 package httpserver
 
 import (
+	"browsertools/log"
+	"browsertools/pkg/auth"
+	"browsertools/pkg/config"
+	"browsertools/pkg/errors"
+	"browsertools/pkg/mcp"
+	"browsertools/pkg/processmanager"
 	"browsertools/pkg/response"
+	"browsertools/pkg/xgin"
+	"browsertools/pkg/xgin/timeout"
+	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 type Server struct {
-	addr   string
-	router *gin.Engine
+	addr            string
+	shutdownTimeout time.Duration
+	router          *gin.Engine
+	ctrl            *APIController
+	mcp             *mcp.Server
+	// grpc 可选, 在 grpcAddr 提供与 HTTP 接口相同的浏览器操作
+	grpc     *grpc.Server
+	grpcAddr string
 }
 
-func New(addr string) *Server {
-	router := gin.New()
-	ctrl := NewController()
+func New(cfg *config.Config) *Server {
+	// stdio 模式下标准输出只能有 MCP 消息
+	if cfg.MCP.Stdio {
+		gin.DefaultWriter = os.Stderr
+	}
+
+	router := xgin.New()
+	ctrl := NewController(cfg)
+	mcpServer := ctrl.newMCPServer()
+
+	// 未开启认证时为 nil, 各分组的认证中间件直接放行
+	var authenticator *auth.Authenticator
+	if cfg.Auth.Enabled {
+		var err error
+		authenticator, err = auth.New(cfg.AuthOptions())
+		if err != nil {
+			panic(fmt.Sprintf("init auth error:%v", err))
+		}
+	}
 
+	// 健康检查和接口文档不需要认证
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, response.New("ok"))
 	})
+	router.GET("/livez", ctrl.Livez)
+	router.GET("/readyz", ctrl.Readyz)
+	router.GET("/metrics", xgin.Auth(authenticator, auth.ScopeAdmin), gin.WrapH(promhttp.Handler()))
+
+	if cfg.MCP.Enabled {
+		// 工具调用可能包含较长的步骤, 超时由工具参数控制
+		// MCP 工具包含操作页面的工具, 需要 interact 权限
+		mcpHandler := gin.WrapH(mcp.NewHandler(mcpServer))
+		mcpGroup := router.Group("/mcp", xgin.Auth(authenticator, auth.ScopeInteract))
+		mcpGroup.POST("", timeout.Skip(), mcpHandler)
+		mcpGroup.GET("", mcpHandler)
+		mcpGroup.DELETE("", mcpHandler)
+	}
 
 	browser := router.Group("/browser")
+
+	// 只读取页面和服务状态
+	read := browser.Group("", xgin.Auth(authenticator, auth.ScopeRead))
+	{
+		read.POST("/screenshot", ctrl.Screenshot)
+		read.POST("/getConsoleLogs", ctrl.GetConsoleLogs)
+		read.POST("/tab/list", ctrl.ListTabs)
+		read.POST("/session/list", ctrl.ListSessions)
+		read.POST("/recovery/events", ctrl.ListRecoveryEvents)
+		read.POST("/pool/status", ctrl.PoolStatus)
+		read.POST("/recording/list", ctrl.ListRecordings)
+
+		// 文件下载和实时画面是流式响应, 不使用全局的请求超时
+		read.GET("/recording/download", timeout.Skip(), ctrl.DownloadRecording)
+		read.GET("/live", timeout.Skip(), ctrl.LiveStream)
+		read.GET("/events", timeout.Skip(), ctrl.PageEvents)
+
+		read.POST("/visual/baselines", ctrl.ListBaselines)
+		read.POST("/markdown", ctrl.Markdown)
+
+		read.POST("/extract/links", ctrl.ExtractLinks)
+		read.POST("/extract/forms", ctrl.ExtractForms)
+		read.POST("/extract/tables", ctrl.ExtractTables)
+		read.POST("/extract/metadata", ctrl.ExtractMetadata)
+		read.POST("/extract/structuredData", ctrl.ExtractStructuredData)
+	}
+
+	// 打开和关闭页面, 执行步骤, 录制, 更新视觉基准图
+	interact := browser.Group("", xgin.Auth(authenticator, auth.ScopeInteract))
+	{
+		interact.POST("/openTab", ctrl.OpenTab)
+		interact.POST("/tab/pin", ctrl.PinTab)
+		interact.POST("/tab/close", ctrl.CloseTab)
+
+		interact.POST("/pool/lease", ctrl.LeaseBrowser)
+		interact.POST("/pool/return", ctrl.ReturnBrowser)
+
+		interact.POST("/recording/start", ctrl.StartRecording)
+		interact.POST("/recording/stop", ctrl.StopRecording)
+		interact.POST("/recording/delete", ctrl.DeleteRecording)
+
+		interact.POST("/visual/compare", ctrl.VisualCompare)
+		interact.POST("/visual/approve", ctrl.VisualApprove)
+
+		// 步骤有各自的超时时间, 整个脚本可能超过默认的请求超时
+		interact.POST("/run", timeout.Skip(), ctrl.Run)
+
+		interact.POST("/interaction/start", ctrl.StartInteraction)
+		interact.POST("/interaction/stop", ctrl.StopInteraction)
+	}
+
+	// 创建和关闭会话会启动和关闭浏览器进程
+	admin := browser.Group("", xgin.Auth(authenticator, auth.ScopeAdmin))
 	{
-		browser.POST("/screenshot", ctrl.Screenshot)
-		browser.POST("/openTab", ctrl.OpenTab)
-		browser.POST("/getConsoleLogs", ctrl.GetConsoleLogs)
+		admin.POST("/session/create", ctrl.CreateSession)
+		admin.POST("/session/close", ctrl.CloseSession)
 	}
 
-	return &Server{addr: addr, router: router}
+	// 文档根据注册的路由生成, 需要放在所有路由之后
+	var spec []byte
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, gin.MIMEJSON, spec)
+	})
+	spec, _ = json.Marshal(newOpenAPIDocument(router.Routes()))
+
+	server := &Server{addr: cfg.Server.Addr, shutdownTimeout: cfg.Server.ShutdownTimeout, router: router, ctrl: ctrl, mcp: mcpServer}
+	if cfg.GRPC.Enabled {
+		server.grpc = ctrl.newGRPCServer(authenticator)
+		server.grpcAddr = cfg.GRPC.Addr
+	}
+
+	return server
+}
+
+// WithProcessManager 设置由本服务管理的进程, 就绪检查会包含这些进程的状态
+func (s *Server) WithProcessManager(pm *processmanager.ProcessManager) *Server {
+	s.ctrl.processes = pm
+	if s.ctrl.webhooks != nil {
+		pm.OnRestart(s.ctrl.publishProcessRestart)
+	}
+	return s
 }
 
+// Start 启动 HTTP 服务和可选的 gRPC 服务, 收到 SIGINT 或 SIGTERM 后停止接收新请求, 等待进行中的请求完成,
+// 然后依次关闭录制, 浏览器和 Playwright 驱动
 func (s *Server) Start() {
-	if err := s.router.Run(s.addr); err != nil {
+	srv := &http.Server{Addr: s.addr, Handler: s.router}
+	srv.RegisterOnShutdown(s.ctrl.beginShutdown)
+
+	serveErr := make(chan error, 2)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	if s.grpc != nil {
+		lis, err := net.Listen("tcp", s.grpcAddr)
+		if err != nil {
+			s.ctrl.Close()
+			panic(fmt.Sprintf("start gRPC server [%s] error:%v", s.grpcAddr, err))
+		}
+
+		log.Infof("gRPC server listening on %s", s.grpcAddr)
+		go func() {
+			if err := s.grpc.Serve(lis); err != nil {
+				serveErr <- errors.WithMessage(err, "gRPC")
+			}
+		}()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	select {
+	case err := <-serveErr:
+		if s.grpc != nil {
+			s.grpc.Stop()
+		}
+		s.ctrl.Close()
 		panic(fmt.Sprintf("start Http server [%s] error:%v", s.addr, err))
+	case sig := <-quit:
+		log.Infof("received signal %v, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	// 先结束实时画面和事件订阅等长连接, 两个服务同时等待进行中的请求完成
+	s.ctrl.beginShutdown()
+	grpcStopped := s.stopGRPC(ctx)
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Errorf("shutdown Http server error: %v", err)
+	}
+
+	<-grpcStopped
+	s.ctrl.Close()
+	log.Infof("server stopped")
+}
+
+// stopGRPC 等待进行中的 RPC 完成, 超时后强制关闭连接
+func (s *Server) stopGRPC(ctx context.Context) <-chan struct{} {
+	stopped := make(chan struct{})
+	if s.grpc == nil {
+		close(stopped)
+		return stopped
 	}
+
+	go func() {
+		defer close(stopped)
+
+		done := make(chan struct{})
+		go func() {
+			s.grpc.GracefulStop()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			log.Errorf("shutdown gRPC server error: %v", ctx.Err())
+			s.grpc.Stop()
+		}
+	}()
+
+	return stopped
+}
+
+// Handler 返回 HTTP 接口的处理器, 用于在其他监听地址上提供服务
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Close 关闭录制, 浏览器和 Playwright 驱动, 与 Handler 一起使用时由调用方在停止服务后调用
+func (s *Server) Close() {
+	s.ctrl.Close()
+}
+
+// ServeStdio 通过标准输入输出提供 MCP 服务, 标准输入关闭或收到 SIGINT, SIGTERM 后关闭浏览器和驱动
+func (s *Server) ServeStdio() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Infof("serving MCP over stdio")
+
+	if err := s.mcp.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil && err != context.Canceled {
+		log.Errorf("serve MCP over stdio error: %v", err)
+	}
+
+	s.ctrl.Close()
+	log.Infof("server stopped")
 }
@@ -49,6 +49,9 @@ func Println(v ...interface{}) {
 type Message struct {
 	MsgType  string `json:"msgtype"`
 	Markdown struct {
+		// Title 和 Text 钉钉机器人使用, 企业微信使用 Content
+		Title   string `json:"title,omitempty"`
+		Text    string `json:"text,omitempty"`
 		Content string `json:"content"`
 	} `json:"markdown"`
 }
@@ -1,13 +1,10 @@
 package main
 
-import "browsertools/httpserver"
-
-//TIP <p>To run your code, right-click the code and select <b>Run</b>.</p> <p>Alternatively, click
-// the <icon src="AllIcons.Actions.Execute"/> icon in the gutter and select the <b>Run</b> menu item from here.</p>
+import (
+	"browsertools/cli"
+	"os"
+)
 
 func main() {
-
-	server := httpserver.New(":8888")
-	server.Start()
-
+	os.Exit(cli.Run(os.Args[0], os.Args[1:]))
 }
@@ -0,0 +1,325 @@
+// Package auth 接口认证, 支持静态令牌, HMAC 签名请求和 JWT, 每种凭证都带有权限范围
+package auth
+
+import (
+	"browsertools/pkg/errors"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 权限范围, 高级别的权限包含低级别的权限
+const (
+	// ScopeRead 截图, 日志, 页面内容等只读操作
+	ScopeRead = "read"
+	// ScopeInteract 打开页面, 执行步骤, 录制等操作页面的请求
+	ScopeInteract = "interact"
+	// ScopeAdmin 创建和关闭会话等管理操作
+	ScopeAdmin = "admin"
+)
+
+var scopeLevels = map[string]int{ScopeRead: 1, ScopeInteract: 2, ScopeAdmin: 3}
+
+// Scopes 所有权限范围, 从低到高
+func Scopes() []string {
+	return []string{ScopeRead, ScopeInteract, ScopeAdmin}
+}
+
+// 认证方式
+const (
+	MethodToken = "token"
+	MethodHMAC  = "hmac"
+	MethodJWT   = "jwt"
+)
+
+// HMAC 签名请求头, 签名内容见 Sign
+const (
+	HeaderKeyID     = "X-Browsertools-Key"
+	HeaderTimestamp = "X-Browsertools-Timestamp"
+	HeaderSignature = "X-Browsertools-Signature"
+)
+
+// maxSignedBodySize 签名请求的请求体需要完整读入内存校验
+const maxSignedBodySize = 10 << 20
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidSignature   = errors.New("invalid signature")
+)
+
+type Options struct {
+	Tokens   []Token
+	HMACKeys []HMACKey
+	// MaxClockSkew 签名时间戳与服务器时间的最大误差, 同时作为 JWT 有效期的容差
+	MaxClockSkew time.Duration
+	JWT          JWTOptions
+}
+
+// Token 静态令牌, 通过 Authorization: Bearer 请求头发送
+type Token struct {
+	Name   string
+	Token  string
+	Scopes []string
+}
+
+// HMACKey 签名密钥, 客户端不发送密钥本身
+type HMACKey struct {
+	ID     string
+	Secret string
+	Scopes []string
+}
+
+// JWTOptions KeyFile 为空时不校验 JWT
+// 文件内容为 PEM 格式的 RSA, ECDSA, Ed25519 公钥或证书时使用对应的签名算法, 否则作为 HS256/384/512 的密钥
+type JWTOptions struct {
+	KeyFile  string
+	Issuer   string
+	Audience string
+}
+
+func DefaultOptions() Options {
+	return Options{MaxClockSkew: 5 * time.Minute}
+}
+
+// Principal 认证通过的调用方
+type Principal struct {
+	// Subject 令牌名称, HMAC 密钥 ID 或 JWT 的 sub
+	Subject string
+	Method  string
+	Scopes  []string
+}
+
+// Allows 是否拥有 scope 权限
+func (p *Principal) Allows(scope string) bool {
+	required, ok := scopeLevels[scope]
+	if !ok {
+		return false
+	}
+
+	for _, granted := range p.Scopes {
+		if scopeLevels[granted] >= required {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Principal) String() string {
+	return p.Method + " " + p.Subject
+}
+
+type principalKey struct{}
+
+// NewContext 在 ctx 中保存调用方
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext 认证中间件保存的调用方, 未开启认证时不存在
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
+
+type staticToken struct {
+	name   string
+	digest [sha256.Size]byte
+	scopes []string
+}
+
+// Authenticator 校验请求中的凭证, 可以在多个协程中使用
+type Authenticator struct {
+	tokens   []staticToken
+	hmacKeys map[string]HMACKey
+	jwt      *jwtVerifier
+	skew     time.Duration
+	now      func() time.Time
+
+	// seen 有效期内已经使用过的签名, 防止重放
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+// New 创建认证器, JWT 密钥文件无法读取或解析时返回错误
+func New(opt Options) (*Authenticator, error) {
+	a := &Authenticator{
+		hmacKeys: make(map[string]HMACKey, len(opt.HMACKeys)),
+		skew:     opt.MaxClockSkew,
+		now:      time.Now,
+		seen:     make(map[string]time.Time),
+	}
+
+	if a.skew <= 0 {
+		a.skew = DefaultOptions().MaxClockSkew
+	}
+
+	for _, token := range opt.Tokens {
+		// 比较摘要, 避免比较耗时泄露令牌长度
+		a.tokens = append(a.tokens, staticToken{name: token.Name, digest: sha256.Sum256([]byte(token.Token)), scopes: token.Scopes})
+	}
+
+	for _, key := range opt.HMACKeys {
+		a.hmacKeys[key.ID] = key
+	}
+
+	if opt.JWT.KeyFile != "" {
+		key, err := loadJWTKey(opt.JWT.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		a.jwt = &jwtVerifier{key: key, issuer: opt.JWT.Issuer, audience: opt.JWT.Audience}
+	}
+
+	return a, nil
+}
+
+// Authenticate 校验 HTTP 请求, 带有签名请求头时按 HMAC 签名校验, 否则校验 Authorization: Bearer 令牌
+// 签名请求的请求体会被读入内存, 校验之后可以再次读取
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if r.Header.Get(HeaderSignature) != "" {
+		return a.verifyRequest(r)
+	}
+
+	token, ok := bearerToken(r.Header.Get("Authorization"))
+	if !ok {
+		return nil, ErrMissingCredentials
+	}
+
+	return a.AuthenticateToken(token)
+}
+
+// AuthenticateToken 校验静态令牌或 JWT, 用于 gRPC 等没有 HTTP 请求的场景
+func (a *Authenticator) AuthenticateToken(token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	digest := sha256.Sum256([]byte(token))
+
+	var matched *staticToken
+	for i := range a.tokens {
+		// 遍历所有令牌, 耗时与匹配的位置无关
+		if subtle.ConstantTimeCompare(digest[:], a.tokens[i].digest[:]) == 1 {
+			matched = &a.tokens[i]
+		}
+	}
+
+	if matched != nil {
+		return &Principal{Subject: matched.name, Method: MethodToken, Scopes: matched.scopes}, nil
+	}
+
+	if a.jwt != nil && strings.Count(token, ".") == 2 {
+		return a.jwt.verify(token, a.now(), a.skew)
+	}
+
+	return nil, ErrInvalidToken
+}
+
+func (a *Authenticator) verifyRequest(r *http.Request) (*Principal, error) {
+	keyID := r.Header.Get(HeaderKeyID)
+	key, ok := a.hmacKeys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidSignature, keyID)
+	}
+
+	timestamp := r.Header.Get(HeaderTimestamp)
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid timestamp %q", ErrInvalidSignature, timestamp)
+	}
+
+	now := a.now()
+	if age := now.Sub(time.UnixMilli(ms)); age > a.skew || age < -a.skew {
+		return nil, fmt.Errorf("%w: timestamp outside the allowed window of %v", ErrInvalidSignature, a.skew)
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(r.Body, maxSignedBodySize+1))
+		_ = r.Body.Close()
+		if err != nil {
+			return nil, errors.WithMessage(err, "read request body error")
+		}
+
+		if len(body) > maxSignedBodySize {
+			return nil, fmt.Errorf("%w: request body larger than %d bytes", ErrInvalidSignature, maxSignedBodySize)
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	signature := r.Header.Get(HeaderSignature)
+	if !hmac.Equal([]byte(Sign(key.Secret, timestamp, r.Method, r.URL.RequestURI(), body)), []byte(signature)) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+
+	if !a.markSeen(signature, now) {
+		return nil, fmt.Errorf("%w: signature already used", ErrInvalidSignature)
+	}
+
+	return &Principal{Subject: key.ID, Method: MethodHMAC, Scopes: key.Scopes}, nil
+}
+
+// markSeen 记录签名, 签名在时间窗口内已经出现过时返回 false
+func (a *Authenticator) markSeen(signature string, now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// 时间戳超出窗口的请求已经被拒绝, 只需要保留一个窗口内的签名
+	if now.Sub(a.lastPrune) > a.skew {
+		for s, expire := range a.seen {
+			if now.After(expire) {
+				delete(a.seen, s)
+			}
+		}
+		a.lastPrune = now
+	}
+
+	if _, ok := a.seen[signature]; ok {
+		return false
+	}
+
+	a.seen[signature] = now.Add(2 * a.skew)
+
+	return true
+}
+
+// Sign 计算请求签名, 签名内容为 "时间戳.方法.路径和查询参数.请求体", 时间戳为 Unix 毫秒
+// 与事件推送的签名格式一致, 结果为 sha256= 加上十六进制的 HMAC-SHA256
+func Sign(secret string, timestamp string, method string, uri string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(method))
+	mac.Write([]byte("."))
+	mac.Write([]byte(uri))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+
+	return token, token != ""
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"browsertools/pkg/errors"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// minJWTSecretSize HS256 要求密钥至少与摘要等长
+const minJWTSecretSize = 32
+
+var ErrInvalidJWT = errors.New("invalid jwt")
+
+type jwtVerifier struct {
+	// key []byte, *rsa.PublicKey, *ecdsa.PublicKey 或 ed25519.PublicKey
+	key      interface{}
+	issuer   string
+	audience string
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+type jwtClaims struct {
+	Subject  string      `json:"sub"`
+	Issuer   string      `json:"iss"`
+	Audience audience    `json:"aud"`
+	Expires  *float64    `json:"exp"`
+	NotBef   *float64    `json:"nbf"`
+	Scope    string      `json:"scope"`
+	Scopes   interface{} `json:"scopes"`
+}
+
+// audience aud 可以是字符串或者字符串数组
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*a = audience{one}
+		return nil
+	}
+
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+
+	return nil
+}
+
+func loadJWTKey(file string) (interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "read jwt key file error")
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		secret := bytes.TrimSpace(data)
+		if len(secret) < minJWTSecretSize {
+			return nil, fmt.Errorf("jwt key file %s: HMAC secret must be at least %d bytes", file, minJWTSecretSize)
+		}
+
+		return secret, nil
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("jwt key file %s: unsupported PEM block %q, use a public key or certificate", file, block.Type)
+	}
+
+	if err != nil {
+		return nil, errors.WithMessagef(err, "parse jwt key file %s error", file)
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("jwt key file %s: unsupported key type %T", file, key)
+}
+
+// verify 校验签名和 exp, nbf, iss, aud, 权限范围取自 scope (空格分隔) 或 scopes (数组或空格分隔) 声明
+func (v *jwtVerifier) verify(token string, now time.Time, skew time.Duration) (*Principal, error) {
+	parts := strings.Split(token, ".")
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidJWT, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidJWT, err)
+	}
+
+	if err := verifySignature(header.Alg, v.key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJWT, err)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidJWT, err)
+	}
+
+	if claims.Expires != nil && now.Add(-skew).After(numericDate(*claims.Expires)) {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidJWT)
+	}
+
+	if claims.NotBef != nil && now.Add(skew).Before(numericDate(*claims.NotBef)) {
+		return nil, fmt.Errorf("%w: token not valid yet", ErrInvalidJWT)
+	}
+
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidJWT, claims.Issuer)
+	}
+
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return nil, fmt.Errorf("%w: audience does not include %q", ErrInvalidJWT, v.audience)
+	}
+
+	return &Principal{Subject: claims.Subject, Method: MethodJWT, Scopes: claims.scopes()}, nil
+}
+
+// scopes 忽略不认识的权限范围, 例如 openid
+func (c *jwtClaims) scopes() []string {
+	values := strings.Fields(c.Scope)
+
+	switch scopes := c.Scopes.(type) {
+	case string:
+		values = append(values, strings.Fields(scopes)...)
+	case []interface{}:
+		for _, s := range scopes {
+			if s, ok := s.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	result := make([]string, 0, len(values))
+	for _, s := range values {
+		if _, ok := scopeLevels[s]; ok {
+			result = append(result, s)
+		}
+	}
+
+	return result
+}
+
+func verifySignature(alg string, key interface{}, input []byte, signature []byte) error {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("algorithm %s does not match the configured key", alg)
+		}
+
+		if !ed25519.Verify(pub, input, signature) {
+			return fmt.Errorf("signature mismatch")
+		}
+
+		return nil
+	}
+
+	if len(alg) != 5 {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	var hash crypto.Hash
+	switch alg[2:] {
+	case "256":
+		hash = crypto.SHA256
+	case "384":
+		hash = crypto.SHA384
+	case "512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	h := hash.New()
+	h.Write(input)
+	digest := h.Sum(nil)
+
+	// 算法必须与密钥类型一致, 防止用公钥作为 HMAC 密钥伪造签名
+	valid := false
+	switch alg[:2] {
+	case "HS":
+		secret, ok := key.([]byte)
+		if !ok {
+			return fmt.Errorf("algorithm %s does not match the configured key", alg)
+		}
+
+		mac := hmac.New(hash.New, secret)
+		mac.Write(input)
+		valid = hmac.Equal(mac.Sum(nil), signature)
+	case "RS", "PS":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("algorithm %s does not match the configured key", alg)
+		}
+
+		if alg[0] == 'R' {
+			valid = rsa.VerifyPKCS1v15(pub, hash, digest, signature) == nil
+		} else {
+			valid = rsa.VerifyPSS(pub, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case "ES":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != ecdsaCurve(hash) {
+			return fmt.Errorf("algorithm %s does not match the configured key", alg)
+		}
+
+		// 签名为定长的 r 和 s 拼接
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("signature mismatch")
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		valid = ecdsa.Verify(pub, digest, r, s)
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	if !valid {
+		return fmt.Errorf("signature mismatch")
+	}
+
+	return nil
+}
+
+func ecdsaCurve(hash crypto.Hash) elliptic.Curve {
+	switch hash {
+	case crypto.SHA384:
+		return elliptic.P384()
+	case crypto.SHA512:
+		return elliptic.P521()
+	}
+
+	return elliptic.P256()
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func numericDate(v float64) time.Time {
+	return time.UnixMilli(int64(v * 1000))
+}
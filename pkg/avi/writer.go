@@ -0,0 +1,223 @@
+package avi
+
+import (
+	"browsertools/pkg/errors"
+	"bytes"
+	"encoding/binary"
+	"image/jpeg"
+	"io"
+)
+
+const (
+	// 头部固定长度: RIFF(12) + hdrl(8+192) + movi列表头(12)
+	headerSize = 224
+	// movi 中 '00dc' 相对于 movi 标识的偏移起点
+	moviOffset = 220
+
+	flagHasIndex = 0x10
+	flagKeyFrame = 0x10
+)
+
+type indexEntry struct {
+	offset uint32
+	size   uint32
+}
+
+// Writer 将JPEG帧写成 MJPEG 编码的 AVI 文件
+type Writer struct {
+	w      io.WriteSeeker
+	fps    int
+	width  int
+	height int
+	index  []indexEntry
+	pos    int64
+	closed bool
+}
+
+// NewWriter 创建AVI写入器, fps 为固定播放帧率
+func NewWriter(w io.WriteSeeker, fps int) (*Writer, error) {
+	if fps <= 0 {
+		fps = 1
+	}
+
+	writer := &Writer{w: w, fps: fps, pos: headerSize}
+
+	// 先写入占位头部, 关闭时回填
+	if _, err := w.Write(make([]byte, headerSize)); err != nil {
+		return nil, errors.WithMessage(err, "write avi header error")
+	}
+
+	return writer, nil
+}
+
+// WriteFrame 写入一帧JPEG数据, 第一帧决定视频尺寸
+func (a *Writer) WriteFrame(frame []byte) error {
+	if a.closed {
+		return errors.New("avi writer is closed")
+	}
+
+	if a.width == 0 {
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(frame))
+		if err != nil {
+			return errors.WithMessage(err, "decode jpeg frame error")
+		}
+
+		a.width = cfg.Width
+		a.height = cfg.Height
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("00dc")
+	writeUint32(buf, uint32(len(frame)))
+	buf.Write(frame)
+
+	if len(frame)%2 == 1 {
+		buf.WriteByte(0)
+	}
+
+	if _, err := a.w.Write(buf.Bytes()); err != nil {
+		return errors.WithMessage(err, "write avi frame error")
+	}
+
+	a.index = append(a.index, indexEntry{offset: uint32(a.pos - moviOffset), size: uint32(len(frame))})
+	a.pos += int64(buf.Len())
+
+	return nil
+}
+
+// Frames 返回已写入的帧数
+func (a *Writer) Frames() int {
+	return len(a.index)
+}
+
+// Size 返回当前文件大小(不含索引)
+func (a *Writer) Size() int64 {
+	return a.pos
+}
+
+// Close 写入索引并回填头部, 不会关闭底层的写入器
+func (a *Writer) Close() error {
+	if a.closed {
+		return nil
+	}
+
+	a.closed = true
+
+	moviSize := a.pos - moviOffset
+
+	idx := &bytes.Buffer{}
+	idx.WriteString("idx1")
+	writeUint32(idx, uint32(len(a.index)*16))
+
+	for _, entry := range a.index {
+		idx.WriteString("00dc")
+		writeUint32(idx, flagKeyFrame)
+		writeUint32(idx, entry.offset)
+		writeUint32(idx, entry.size)
+	}
+
+	if _, err := a.w.Write(idx.Bytes()); err != nil {
+		return errors.WithMessage(err, "write avi index error")
+	}
+
+	riffSize := a.pos + int64(idx.Len()) - 8
+
+	if _, err := a.w.Seek(0, io.SeekStart); err != nil {
+		return errors.WithMessage(err, "seek avi header error")
+	}
+
+	if _, err := a.w.Write(a.header(riffSize, moviSize)); err != nil {
+		return errors.WithMessage(err, "write avi header error")
+	}
+
+	_, err := a.w.Seek(0, io.SeekEnd)
+
+	return err
+}
+
+func (a *Writer) header(riffSize int64, moviSize int64) []byte {
+	frames := uint32(len(a.index))
+	maxSize := uint32(0)
+
+	for _, entry := range a.index {
+		if entry.size > maxSize {
+			maxSize = entry.size
+		}
+	}
+
+	buf := &bytes.Buffer{}
+
+	buf.WriteString("RIFF")
+	writeUint32(buf, uint32(riffSize))
+	buf.WriteString("AVI ")
+
+	buf.WriteString("LIST")
+	writeUint32(buf, 192)
+	buf.WriteString("hdrl")
+
+	// MainAVIHeader
+	buf.WriteString("avih")
+	writeUint32(buf, 56)
+	writeUint32(buf, uint32(1000000/a.fps))
+	writeUint32(buf, maxSize*uint32(a.fps))
+	writeUint32(buf, 0)
+	writeUint32(buf, flagHasIndex)
+	writeUint32(buf, frames)
+	writeUint32(buf, 0)
+	writeUint32(buf, 1)
+	writeUint32(buf, maxSize)
+	writeUint32(buf, uint32(a.width))
+	writeUint32(buf, uint32(a.height))
+	buf.Write(make([]byte, 16))
+
+	buf.WriteString("LIST")
+	writeUint32(buf, 116)
+	buf.WriteString("strl")
+
+	// AVIStreamHeader
+	buf.WriteString("strh")
+	writeUint32(buf, 56)
+	buf.WriteString("vids")
+	buf.WriteString("MJPG")
+	writeUint32(buf, 0)
+	writeUint16(buf, 0)
+	writeUint16(buf, 0)
+	writeUint32(buf, 0)
+	writeUint32(buf, 1)
+	writeUint32(buf, uint32(a.fps))
+	writeUint32(buf, 0)
+	writeUint32(buf, frames)
+	writeUint32(buf, maxSize)
+	writeUint32(buf, 0xFFFFFFFF)
+	writeUint32(buf, 0)
+	writeUint16(buf, 0)
+	writeUint16(buf, 0)
+	writeUint16(buf, uint16(a.width))
+	writeUint16(buf, uint16(a.height))
+
+	// BITMAPINFOHEADER
+	buf.WriteString("strf")
+	writeUint32(buf, 40)
+	writeUint32(buf, 40)
+	writeUint32(buf, uint32(a.width))
+	writeUint32(buf, uint32(a.height))
+	writeUint16(buf, 1)
+	writeUint16(buf, 24)
+	buf.WriteString("MJPG")
+	writeUint32(buf, uint32(a.width*a.height*3))
+	buf.Write(make([]byte, 16))
+
+	buf.WriteString("LIST")
+	writeUint32(buf, uint32(moviSize))
+	buf.WriteString("movi")
+
+	return buf.Bytes()
+}
+
+func writeUint32(buf *bytes.Buffer, v uint32) {
+	_ = binary.Write(buf, binary.LittleEndian, v)
+}
+
+func writeUint16(buf *bytes.Buffer, v uint16) {
+	_ = binary.Write(buf, binary.LittleEndian, v)
+}
@@ -0,0 +1,78 @@
+package avi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFrame(t *testing.T, width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, img, nil); err != nil {
+		t.Fatalf("encode jpeg error: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.avi")
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+
+	writer, err := NewWriter(file, 5)
+	assert.NoError(t, err)
+
+	frame := newFrame(t, 64, 48)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, writer.WriteFrame(frame))
+	}
+
+	assert.Equal(t, 3, writer.Frames())
+	assert.NoError(t, writer.Close())
+	assert.NoError(t, file.Close())
+	assert.Error(t, writer.WriteFrame(frame))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "RIFF", string(data[0:4]))
+	assert.Equal(t, uint32(len(data)-8), binary.LittleEndian.Uint32(data[4:8]))
+	assert.Equal(t, "AVI ", string(data[8:12]))
+	assert.Equal(t, "movi", string(data[moviOffset:moviOffset+4]))
+
+	// avih 中的总帧数与宽高
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(data[48:52]))
+	assert.Equal(t, uint32(64), binary.LittleEndian.Uint32(data[64:68]))
+	assert.Equal(t, uint32(48), binary.LittleEndian.Uint32(data[68:72]))
+
+	// 第一帧紧跟在 movi 之后
+	assert.Equal(t, "00dc", string(data[headerSize:headerSize+4]))
+	assert.Equal(t, uint32(len(frame)), binary.LittleEndian.Uint32(data[headerSize+4:headerSize+8]))
+
+	idx := bytes.LastIndex(data, []byte("idx1"))
+	assert.Equal(t, uint32(3*16), binary.LittleEndian.Uint32(data[idx+4:idx+8]))
+	assert.Equal(t, uint32(4), binary.LittleEndian.Uint32(data[idx+16:idx+20]))
+}
+
+func TestWriterInvalidFrame(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "invalid.avi"))
+	assert.NoError(t, err)
+	defer file.Close()
+
+	writer, err := NewWriter(file, 0)
+	assert.NoError(t, err)
+	assert.Error(t, writer.WriteFrame([]byte("not a jpeg")))
+}
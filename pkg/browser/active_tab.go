@@ -0,0 +1,231 @@
+package browser
+
+import (
+	"browsertools/log"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	activeTabBinding = "__browsertoolsOnActive"
+
+	// activeTabScript 通过 AddInitScript 注入, 每次导航后都会重新执行
+	// 页面可见且获得焦点时上报, 包括通过 VNC 手动切换标签页
+	activeTabScript = `
+(() => {
+  if (window.top !== window || window.__browsertoolsActiveTab) {
+    return;
+  }
+  window.__browsertoolsActiveTab = true;
+
+  const report = (reason) => {
+    if (document.visibilityState !== 'visible' || typeof window.` + activeTabBinding + ` !== 'function') {
+      return;
+    }
+    window.` + activeTabBinding + `(reason).catch(() => {});
+  };
+
+  document.addEventListener('visibilitychange', () => report('visibilitychange'));
+  window.addEventListener('focus', () => report('focus'));
+
+  if (document.hasFocus()) {
+    report('load');
+  }
+})();
+`
+
+	// activeStateScript 返回页面是否可见以及是否有焦点
+	activeStateScript = `() => [document.visibilityState === 'visible', document.hasFocus()]`
+
+	activeSyncTimeout = 3 * time.Second
+	// activeSyncDelay 合并短时间内的多个 Target 事件
+	activeSyncDelay = 200 * time.Millisecond
+)
+
+// setupActiveTracking 注册活动页面回调和初始化脚本, 脚本在之后的每次导航中都会执行
+// 对已经打开的页面再执行一次, 覆盖通过 CDP 连接时已有的标签页
+func (h *PageHandler) setupActiveTracking() {
+	err := h.page.ExposeFunction(activeTabBinding, func(args ...interface{}) interface{} {
+		reason, _ := args[0].(string)
+		log.Debugf("Page %s became active: %s", h.pageID, reason)
+
+		if h.pageListener != nil {
+			h.pageListener.OnActivePage(h.pageID)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Errorf("Failed to expose active tab function for page %s: %v", h.pageID, err)
+		return
+	}
+
+	err = h.page.AddInitScript(playwright.Script{Content: playwright.String(activeTabScript)})
+	if err != nil {
+		log.Errorf("Failed to add active tab init script for page %s: %v", h.pageID, err)
+		return
+	}
+
+	if _, err := h.page.Evaluate(activeTabScript); err != nil {
+		log.Debugf("Failed to run active tab script for page %s: %v", h.pageID, err)
+	}
+}
+
+// activeState 返回页面是否可见以及是否有焦点, 超时或卡死的页面返回 false
+func (h *PageHandler) activeState(timeout time.Duration) (bool, bool) {
+	if h.IsClosed() || h.checkResponsive() != nil {
+		return false, false
+	}
+
+	done := make(chan []interface{}, 1)
+	go func() {
+		result, err := h.page.Evaluate(activeStateScript)
+		state, _ := result.([]interface{})
+		if err != nil || len(state) != 2 {
+			state = nil
+		}
+		done <- state
+	}()
+
+	select {
+	case state := <-done:
+		if state == nil {
+			return false, false
+		}
+
+		visible, _ := state[0].(bool)
+		focused, _ := state[1].(bool)
+		return visible, focused
+	case <-time.After(timeout):
+		return false, false
+	}
+}
+
+// trackTargets 监听 CDP Target 事件, 标签页创建, 切换或导航时重新确认活动页面, 仅 Chromium 支持
+func (h *BrowserHandler) trackTargets() {
+	if h.Engine() != EngineChromium {
+		return
+	}
+
+	session, err := h.browser.NewBrowserCDPSession()
+	if err != nil {
+		log.Errorf("new browser cdp session error: %v", err)
+		return
+	}
+
+	onTarget := func(params map[string]interface{}) {
+		info, _ := params["targetInfo"].(map[string]interface{})
+		if info != nil && info["type"] != "page" {
+			return
+		}
+
+		// 事件回调中不能同步调用 playwright
+		go h.scheduleActiveSync()
+	}
+
+	session.On("Target.targetCreated", onTarget)
+	session.On("Target.targetInfoChanged", onTarget)
+	session.On("Target.targetDestroyed", onTarget)
+
+	if _, err := session.Send("Target.setDiscoverTargets", map[string]interface{}{"discover": true}); err != nil {
+		log.Errorf("discover targets error: %v", err)
+		_ = session.Detach()
+		return
+	}
+
+	h.stateMux.Lock()
+	h.targetSession = session
+	h.stateMux.Unlock()
+}
+
+func (h *BrowserHandler) scheduleActiveSync() {
+	if !h.activeSyncing.CompareAndSwap(false, true) {
+		return
+	}
+
+	time.Sleep(activeSyncDelay)
+	h.activeSyncing.Store(false)
+
+	h.syncActivePage()
+}
+
+// syncActivePage 根据页面的可见性和焦点确认活动页面
+func (h *BrowserHandler) syncActivePage() {
+	if h.IsClosed() {
+		return
+	}
+
+	active := h.pageList.GetActivePage()
+	pages := h.pageList.GetPages()
+
+	if page := pickActivePage(pages, activeStates(pages, activeSyncTimeout), active); page != nil && page != active {
+		h.OnActivePage(page.GetPageID())
+	}
+}
+
+// pageActiveState 页面是否可见以及是否有焦点
+type pageActiveState struct {
+	visible bool
+	focused bool
+}
+
+// activeStates 并发检查所有页面, 卡死的页面不会拖慢其他页面, 总耗时不超过一次 timeout
+func activeStates(pages []*PageHandler, timeout time.Duration) []pageActiveState {
+	states := make([]pageActiveState, len(pages))
+
+	var wg sync.WaitGroup
+	for i, page := range pages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			states[i].visible, states[i].focused = page.activeState(timeout)
+		}()
+	}
+	wg.Wait()
+
+	return states
+}
+
+// pickActivePage 有焦点的页面优先; 没有时当前活动页面仍可见就保持不变, 否则使用第一个可见的页面
+// 没有可见的页面时返回 nil
+func pickActivePage(pages []*PageHandler, states []pageActiveState, active *PageHandler) *PageHandler {
+	var visiblePage *PageHandler
+	activeVisible := false
+
+	for i, page := range pages {
+		if !states[i].visible {
+			continue
+		}
+
+		if states[i].focused {
+			return page
+		}
+
+		if page == active {
+			activeVisible = true
+		}
+
+		if visiblePage == nil {
+			visiblePage = page
+		}
+	}
+
+	if activeVisible {
+		return active
+	}
+
+	return visiblePage
+}
+
+func (h *BrowserHandler) detachTargets() {
+	h.stateMux.Lock()
+	session := h.targetSession
+	h.targetSession = nil
+	h.stateMux.Unlock()
+
+	if session != nil {
+		_ = session.Detach()
+	}
+}
@@ -0,0 +1,74 @@
+package browser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickActivePage(t *testing.T) {
+	pages := []*PageHandler{{pageID: "page-1"}, {pageID: "page-2"}, {pageID: "page-3"}}
+
+	tests := []struct {
+		name   string
+		states []pageActiveState
+		active int
+		want   int
+	}{
+		{"focused page wins", []pageActiveState{{visible: true}, {}, {visible: true, focused: true}}, 0, 2},
+		{"visible active page is kept", []pageActiveState{{visible: true}, {visible: true}, {}}, 1, 1},
+		{"first visible page when active is hidden", []pageActiveState{{}, {visible: true}, {visible: true}}, 0, 1},
+		{"focus without visibility is ignored", []pageActiveState{{focused: true}, {}, {visible: true}}, 0, 2},
+		{"no visible page", []pageActiveState{{}, {}, {}}, 0, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pickActivePage(pages, tt.states, pages[tt.active])
+			if tt.want < 0 {
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			assert.Equal(t, pages[tt.want].pageID, got.pageID)
+		})
+	}
+}
+
+func TestActiveStates_Concurrent(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+
+	hung := func(string, ...interface{}) (interface{}, error) {
+		<-hang
+		return nil, nil
+	}
+	state := func(visible bool, focused bool) func(string, ...interface{}) (interface{}, error) {
+		return func(string, ...interface{}) (interface{}, error) {
+			return []interface{}{visible, focused}, nil
+		}
+	}
+
+	h := newFakeBrowserHandler(&fakeBrowser{}, &fakeContext{},
+		&fakePage{evaluate: hung},
+		&fakePage{evaluate: hung},
+		&fakePage{evaluate: state(true, false)},
+		&fakePage{evaluate: state(true, true)},
+	)
+	pages := make([]*PageHandler, 0, 4)
+	for _, id := range []string{"page-1", "page-2", "page-3", "page-4"} {
+		pages = append(pages, h.pageList.GetPageByID(id))
+	}
+
+	// 卡死的页面同时等待, 不会逐个累加超时时间
+	timeout := 200 * time.Millisecond
+	start := time.Now()
+	states := activeStates(pages, timeout)
+	assert.Less(t, time.Since(start), 2*timeout)
+
+	assert.Equal(t, []pageActiveState{{}, {}, {visible: true}, {visible: true, focused: true}}, states)
+	assert.Equal(t, "page-4", pickActivePage(pages, states, pages[0]).GetPageID())
+}
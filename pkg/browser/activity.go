@@ -0,0 +1,148 @@
+package browser
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	ActivityConsole       = "console"
+	ActivityRequest       = "request"
+	ActivityResponse      = "response"
+	ActivityRequestFailed = "requestfailed"
+)
+
+// PageActivity 页面的控制台消息和网络请求, 用于实时订阅
+type PageActivity struct {
+	Type   string
+	PageID string
+	Time   time.Time
+	// Level 控制台消息的类型, 例如 log, warning, error
+	Level    string
+	Text     string
+	Location string
+	// Method, URL, ResourceType 网络请求的信息, Status 只在 response 中有效, Error 只在 requestfailed 中有效
+	Method       string
+	URL          string
+	ResourceType string
+	Status       int
+	Error        string
+}
+
+// activityListeners 页面活动的订阅者, 回调在 playwright 事件协程中执行, 不能阻塞
+type activityListeners struct {
+	listeners map[int]func(PageActivity)
+	seq       int
+	mux       sync.Mutex
+}
+
+func (l *activityListeners) add(fn func(PageActivity)) func() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	if l.listeners == nil {
+		l.listeners = make(map[int]func(PageActivity))
+	}
+
+	l.seq++
+	seq := l.seq
+	l.listeners[seq] = fn
+
+	return func() {
+		l.mux.Lock()
+		defer l.mux.Unlock()
+
+		delete(l.listeners, seq)
+	}
+}
+
+func (l *activityListeners) empty() bool {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	return len(l.listeners) == 0
+}
+
+func (l *activityListeners) notify(activity PageActivity) {
+	l.mux.Lock()
+	listeners := make([]func(PageActivity), 0, len(l.listeners))
+	for _, fn := range l.listeners {
+		listeners = append(listeners, fn)
+	}
+	l.mux.Unlock()
+
+	for _, fn := range listeners {
+		fn(activity)
+	}
+}
+
+// OnActivity 订阅页面的控制台消息和网络请求, 返回取消订阅的函数
+// 网络事件在第一次订阅时才开始监听
+func (h *PageHandler) OnActivity(fn func(PageActivity)) func() {
+	unsubscribe := h.activity.add(fn)
+
+	if h.networkReady.CompareAndSwap(false, true) {
+		h.page.On("request", h.onRequest)
+		h.page.On("response", h.onResponse)
+		h.page.On("requestfailed", h.onRequestFailed)
+	}
+
+	return unsubscribe
+}
+
+func (h *PageHandler) emitActivity(activity PageActivity) {
+	if h.activity.empty() {
+		return
+	}
+
+	activity.PageID = h.pageID
+	activity.Time = time.Now()
+	h.activity.notify(activity)
+}
+
+func (h *PageHandler) onConsoleActivity(msg playwright.ConsoleMessage) {
+	activity := PageActivity{Type: ActivityConsole, Level: msg.Type(), Text: msg.Text()}
+	if location := msg.Location(); location != nil && location.URL != "" {
+		activity.Location = fmt.Sprintf("%s:%d:%d", location.URL, location.LineNumber, location.ColumnNumber)
+	}
+
+	h.emitActivity(activity)
+}
+
+func (h *PageHandler) onRequest(request playwright.Request) {
+	h.emitActivity(PageActivity{
+		Type:         ActivityRequest,
+		Method:       request.Method(),
+		URL:          request.URL(),
+		ResourceType: request.ResourceType(),
+	})
+}
+
+func (h *PageHandler) onResponse(response playwright.Response) {
+	request := response.Request()
+
+	h.emitActivity(PageActivity{
+		Type:         ActivityResponse,
+		Method:       request.Method(),
+		URL:          response.URL(),
+		ResourceType: request.ResourceType(),
+		Status:       response.Status(),
+	})
+}
+
+func (h *PageHandler) onRequestFailed(request playwright.Request) {
+	activity := PageActivity{
+		Type:         ActivityRequestFailed,
+		Method:       request.Method(),
+		URL:          request.URL(),
+		ResourceType: request.ResourceType(),
+	}
+	if err := request.Failure(); err != nil {
+		activity.Error = err.Error()
+	}
+
+	h.emitActivity(activity)
+}
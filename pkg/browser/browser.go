@@ -4,39 +4,134 @@ import (
 	"browsertools/log"
 	"browsertools/pkg/errors"
 	"context"
+	"sync"
 	"sync/atomic"
 
 	"github.com/playwright-community/playwright-go"
 )
 
-const maxLogs = 1000
-
 type BrowserHandler struct {
 	browser        playwright.Browser
 	browserContext playwright.BrowserContext
 	pageList       *PageList
 	isClosed       atomic.Bool
+	// ownsBrowser 为 false 时只拥有 BrowserContext, 关闭时不关闭浏览器
+	ownsBrowser bool
+	// attached 通过 CDP 连接的外部浏览器, 关闭时只断开连接, 不关闭页面, 上下文和浏览器
+	attached     bool
+	headlessMode string
+	// maxLogs 每个页面保留的控制台日志条数
+	maxLogs int
+	// disconnects 同一个浏览器上所有会话共用的断开连接监听
+	disconnects *disconnectListeners
+	// tabPolicy 标签页生命周期策略, 为空时不限制
+	tabPolicy     *TabPolicy
+	policyStarted bool
+	policyMux     *sync.Mutex
+	// evictMux 串行执行标签页回收
+	evictMux *sync.Mutex
+	// closing 主动关闭时为 true, 此时断开连接不触发崩溃恢复
+	closing atomic.Bool
+	// supervisor 相关的回调和标签页快照, 由 stateMux 保护
+	onCrash      func(RecoveryEvent)
+	onDisconnect func()
+	tabs         []TabSnapshot
+	stateMux     *sync.Mutex
+	// targetSession 监听 CDP Target 事件的浏览器会话, 由 stateMux 保护
+	targetSession playwright.CDPSession
+	activeSyncing atomic.Bool
+	// onEvent 对外通知的事件回调, 由 stateMux 保护
+	onEvent func(BrowserEvent)
 }
 
 func NewBrowserHandler(browser playwright.Browser) (*BrowserHandler, error) {
-	ctx, err := getBrowserContext(browser)
+	return NewBrowserHandlerWithMode(browser, ModeHeadful)
+}
+
+// NewBrowserHandlerWithMode 按无头模式创建 BrowserHandler, 无头模式下上下文使用固定视口
+func NewBrowserHandlerWithMode(browser playwright.Browser, mode string) (*BrowserHandler, error) {
+	return newOwnedBrowserHandler(browser, mode, defaultMaxLogs)
+}
+
+// newOwnedBrowserHandler 为本服务启动的浏览器创建 BrowserHandler, 每个页面保留 maxLogs 条控制台日志
+func newOwnedBrowserHandler(browser playwright.Browser, mode string, maxLogs int) (*BrowserHandler, error) {
+	ctx, err := getBrowserContext(browser, mode)
+	if err != nil {
+		return nil, errors.WithMessage(err, "new context error")
+	}
+
+	return newBrowserHandler(browser, ctx, true, mode, maxLogs, nil), nil
+}
+
+// attachBrowserHandler 为通过 CDP 连接的浏览器创建 BrowserHandler, 浏览器和已有的上下文不归本服务所有
+func attachBrowserHandler(browser playwright.Browser, mode string, maxLogs int) (*BrowserHandler, error) {
+	ctx, err := getBrowserContext(browser, mode)
 	if err != nil {
 		return nil, errors.WithMessage(err, "new context error")
 	}
 
+	handler := newBrowserHandler(browser, ctx, false, mode, maxLogs, nil)
+	handler.attached = true
+
+	return handler, nil
+}
+
+// newBrowserHandler disconnects 为空时向浏览器注册断开连接监听, 会话传入所在浏览器的监听
+func newBrowserHandler(browser playwright.Browser, ctx playwright.BrowserContext, ownsBrowser bool, mode string, maxLogs int, disconnects *disconnectListeners) *BrowserHandler {
+	if disconnects == nil {
+		disconnects = newDisconnectListeners(browser)
+	}
+
 	handler := &BrowserHandler{
 		browser:        browser,
 		browserContext: ctx,
 		pageList:       NewPageList(),
+		ownsBrowser:    ownsBrowser,
+		headlessMode:   mode,
+		maxLogs:        maxLogs,
+		disconnects:    disconnects,
+		policyMux:      &sync.Mutex{},
+		evictMux:       &sync.Mutex{},
+		stateMux:       &sync.Mutex{},
 	}
 
 	handler.intExistPageFromContext()
+	trackHandler(handler)
 
-	// 设置浏览器关闭事件监听
-	browser.OnDisconnected(handler.OnDisconnected)
+	// 设置浏览器关闭事件监听, 关闭时移除
+	disconnects.add(handler)
 
 	// 设置新页面(标签页)事件监听
 	ctx.OnPage(handler.onPage)
+	ctx.OnClose(handler.onContextClose)
+
+	// 通过CDP连接时可能已有多个标签页, 按可见性和焦点确认活动页面
+	go func() {
+		handler.trackTargets()
+		handler.syncActivePage()
+	}()
+
+	return handler
+}
+
+// NewSession 在同一个浏览器上创建一个独立的 BrowserContext, cookie、标签页和活动页面互不影响
+func (h *BrowserHandler) NewSession() (*BrowserHandler, error) {
+	if h.IsClosed() {
+		return nil, errors.New("browser is closed")
+	}
+
+	ctx, err := h.browser.NewContext(contextOptions(h.headlessMode))
+	if err != nil {
+		return nil, errors.WithMessage(err, "new session context error")
+	}
+
+	// 日志条数和断开回调与所在浏览器共用
+	handler := newBrowserHandler(h.browser, ctx, false, h.headlessMode, h.maxLogs, h.disconnects)
+
+	// 标签页策略复制一份, 之后修改所在浏览器的策略不影响已创建的会话
+	if policy := h.GetTabPolicy(); policy != nil {
+		handler.SetTabPolicy(*policy)
+	}
 
 	return handler, nil
 }
@@ -44,29 +139,85 @@ func NewBrowserHandler(browser playwright.Browser) (*BrowserHandler, error) {
 func (h *BrowserHandler) intExistPageFromContext() {
 	pages := h.browserContext.Pages()
 	for _, page := range pages {
-		handler := NewPageHandler(page, h)
+		handler := newPageHandler(page, h, h.maxLogs)
 		h.pageList.AddPage(handler)
 		h.pageList.SetActivePage(handler.GetPageID())
 	}
 }
 
-func getBrowserContext(browser playwright.Browser) (playwright.BrowserContext, error) {
+func getBrowserContext(browser playwright.Browser, mode string) (playwright.BrowserContext, error) {
 	contexts := browser.Contexts()
 	if len(contexts) > 0 {
 		return contexts[0], nil
 	}
 
-	opt := playwright.BrowserNewContextOptions{
-		NoViewport: playwright.Bool(true),
-	}
+	return browser.NewContext(contextOptions(mode))
+
+}
+
+// disconnectListeners 每个浏览器只注册一次断开连接监听, 转发给浏览器上未关闭的所有会话
+type disconnectListeners struct {
+	handlers map[*BrowserHandler]struct{}
+	mux      sync.Mutex
+}
+
+func newDisconnectListeners(browser playwright.Browser) *disconnectListeners {
+	l := &disconnectListeners{handlers: make(map[*BrowserHandler]struct{})}
+	browser.OnDisconnected(l.onDisconnected)
 
-	return browser.NewContext(opt)
+	return l
+}
+
+func (l *disconnectListeners) add(h *BrowserHandler) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
 
+	l.handlers[h] = struct{}{}
+}
+
+func (l *disconnectListeners) remove(h *BrowserHandler) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	delete(l.handlers, h)
+}
+
+func (l *disconnectListeners) onDisconnected(browser playwright.Browser) {
+	l.mux.Lock()
+	handlers := make([]*BrowserHandler, 0, len(l.handlers))
+	for h := range l.handlers {
+		handlers = append(handlers, h)
+	}
+	l.mux.Unlock()
+
+	for _, h := range handlers {
+		h.OnDisconnected(browser)
+	}
 }
 
 func (h *BrowserHandler) OnDisconnected(_ playwright.Browser) {
 	log.Infof("Browser disconnected")
 	h.isClosed.Store(true)
+
+	if h.closing.Load() {
+		return
+	}
+
+	metricDisconnectTotal.WithLabelValues(h.Engine()).Inc()
+	h.emit(BrowserEvent{Type: EventBrowserDisconnected, Message: "browser disconnected unexpectedly"})
+
+	h.stateMux.Lock()
+	onDisconnect := h.onDisconnect
+	h.stateMux.Unlock()
+
+	if onDisconnect != nil {
+		go onDisconnect()
+	}
+}
+
+func (h *BrowserHandler) onContextClose(_ playwright.BrowserContext) {
+	log.Infof("Browser context closed")
+	h.isClosed.Store(true)
 }
 
 func (h *BrowserHandler) onPage(page playwright.Page) {
@@ -89,16 +240,36 @@ func (h *BrowserHandler) createPage() (*PageHandler, error) {
 	return handler, nil
 }
 func (h *BrowserHandler) createIfNotExistPageHandler(page playwright.Page) *PageHandler {
-	handler := h.pageList.FindPageHandler(page)
-	if handler != nil {
+	openerID, popup := h.findOpener(page)
+
+	// 页面事件和 createPage 可能同时到达, 查找和添加需要在同一把锁内完成
+	handler, created := h.pageList.addIfAbsent(page, func() *PageHandler {
+		handler := newPageHandler(page, h, h.maxLogs)
+		handler.openerID = openerID
+		handler.popup = popup
 		return handler
+	})
+	if !created {
+		return handler
+	}
+
+	if h.shouldActivate(handler) {
+		h.pageList.SetActivePage(handler.GetPageID())
 	}
 
-	handler = NewPageHandler(page, h)
-	h.pageList.AddPage(handler)
-	h.pageList.SetActivePage(handler.GetPageID())
+	go h.trackWindow(handler)
 
-	log.Infof("New page %s opened: %s", handler.GetPageID(), page.URL())
+	h.emit(BrowserEvent{
+		Type:   EventTabOpened,
+		PageID: handler.GetPageID(),
+		URL:    page.URL(),
+		Data:   map[string]interface{}{"popup": handler.IsPopup(), "opener_id": handler.GetOpenerID()},
+	})
+
+	// 事件回调中不能同步关闭页面, 放到协程中执行
+	go h.enforceMaxTabs()
+
+	log.Infof("New page %s opened: %s, opener %q, popup %v", handler.GetPageID(), page.URL(), handler.GetOpenerID(), handler.IsPopup())
 
 	return handler
 }
@@ -132,6 +303,15 @@ func (h *BrowserHandler) getEmptyPage() (*PageHandler, error) {
 }
 
 func (h *BrowserHandler) Close() {
+	h.closing.Store(true)
+	h.detachTargets()
+	h.disconnects.remove(h)
+
+	if h.attached {
+		h.disconnect()
+		return
+	}
+
 	h.pageList.CloseAll()
 
 	err := h.browserContext.Close()
@@ -139,12 +319,56 @@ func (h *BrowserHandler) Close() {
 		log.Errorf("close browser context error: %v", err)
 	}
 
+	h.isClosed.Store(true)
+
+	if !h.ownsBrowser {
+		return
+	}
+
 	err = h.browser.Close()
 	if err != nil {
 		log.Errorf("close browser error: %v", err)
 	}
 }
 
+// disconnect 断开与外部浏览器的连接, 对连接的浏览器调用 Close 只会断开连接并关闭本服务创建的上下文
+func (h *BrowserHandler) disconnect() {
+	h.isClosed.Store(true)
+
+	if err := h.browser.Close(); err != nil {
+		log.Errorf("disconnect browser error: %v", err)
+	}
+
+	log.Infof("disconnected from browser")
+}
+
+// reset 关闭所有页面并清空cookie, 浏览器归还到池中前调用, 避免状态泄露给下一个使用者
+func (h *BrowserHandler) reset() error {
+	h.pageList.CloseAll()
+
+	err := h.browserContext.ClearCookies()
+	if err != nil {
+		return errors.WithMessage(err, "clear cookies error")
+	}
+
+	err = h.browserContext.ClearPermissions()
+	if err != nil {
+		return errors.WithMessage(err, "clear permissions error")
+	}
+
+	return nil
+}
+
+// isHealthy 浏览器进程仍然连接且上下文未关闭
+func (h *BrowserHandler) isHealthy() bool {
+	return !h.IsClosed() && h.browser.IsConnected()
+}
+
+// GetPageCount 返回当前打开的页面数
+func (h *BrowserHandler) GetPageCount() int {
+	return h.pageList.Count()
+}
+
 func (h *BrowserHandler) OnActivePage(pageID string) {
 	if h.pageList.SetActivePage(pageID) {
 		log.Infof("switched to page %s", pageID)
@@ -153,7 +377,14 @@ func (h *BrowserHandler) OnActivePage(pageID string) {
 
 func (h *BrowserHandler) OnClosePage(pageID string) {
 	log.Infof("remove page %s from browser", pageID)
+
+	event := BrowserEvent{Type: EventTabClosed, PageID: pageID}
+	if page := h.pageList.GetPageByID(pageID); page != nil {
+		event.URL = page.GetPage().URL()
+	}
+
 	h.pageList.RemovePage(pageID)
+	h.emit(event)
 }
 
 func (h *BrowserHandler) Screenshot() ([]byte, error) {
@@ -174,6 +405,46 @@ func (h *BrowserHandler) GetLogs() []string {
 	return page.GetLogs()
 }
 
+// GetTab 根据页面ID获取页面, pageID 为空时返回当前活动页面
+func (h *BrowserHandler) GetTab(pageID string) (*PageHandler, error) {
+	if pageID == "" {
+		page := h.GetActiveTab()
+		if page == nil {
+			return nil, errors.ErrCurrentPageEmpty
+		}
+
+		page.Touch()
+
+		return page, nil
+	}
+
+	page := h.pageList.GetPageByID(pageID)
+	if page == nil || page.IsClosed() {
+		return nil, errors.ErrPageNotFound
+	}
+
+	page.Touch()
+
+	return page, nil
+}
+
+// GetTabs 按创建时间返回所有标签页
+func (h *BrowserHandler) GetTabs() []*PageHandler {
+	return h.pageList.GetPages()
+}
+
+// CloseTab 关闭指定标签页
+func (h *BrowserHandler) CloseTab(pageID string) error {
+	page, err := h.GetTab(pageID)
+	if err != nil {
+		return err
+	}
+
+	h.evict(page)
+
+	return nil
+}
+
 func (h *BrowserHandler) GetActiveTab() *PageHandler {
 	if h.isClosed.Load() {
 		return nil
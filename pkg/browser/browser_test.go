@@ -0,0 +1,69 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrowserHandler_CloseAttached(t *testing.T) {
+	browser, ctx, page := &fakeBrowser{}, &fakeContext{}, &fakePage{url: "https://example.com"}
+	h := newFakeBrowserHandler(browser, ctx, page)
+	h.attached = true
+
+	h.Close()
+
+	// 外部浏览器的标签页和上下文保持不变, 只断开连接
+	assert.True(t, h.IsClosed())
+	assert.Equal(t, 0, page.closeCount())
+	assert.Equal(t, 0, ctx.closeCount())
+	assert.Equal(t, 1, browser.closeCount())
+}
+
+func TestBrowserHandler_CloseOwned(t *testing.T) {
+	browser, ctx, page := &fakeBrowser{}, &fakeContext{}, &fakePage{}
+	h := newFakeBrowserHandler(browser, ctx, page)
+	h.ownsBrowser = true
+
+	h.Close()
+
+	assert.Equal(t, 1, page.closeCount())
+	assert.Equal(t, 1, ctx.closeCount())
+	assert.Equal(t, 1, browser.closeCount())
+
+	// 会话只关闭自己的上下文
+	browser, ctx, page = &fakeBrowser{}, &fakeContext{}, &fakePage{}
+	newFakeBrowserHandler(browser, ctx, page).Close()
+
+	assert.Equal(t, 1, page.closeCount())
+	assert.Equal(t, 1, ctx.closeCount())
+	assert.Equal(t, 0, browser.closeCount())
+}
+
+func TestBrowserHandler_SessionDisconnectListeners(t *testing.T) {
+	browser := &fakeBrowser{engine: EngineFirefox}
+	root := newBrowserHandler(browser, &fakeContext{browser: browser}, true, ModeHeadless, defaultMaxLogs, nil)
+
+	sessions := make([]*BrowserHandler, 0, 5)
+	for range 5 {
+		session, err := root.NewSession()
+		require.NoError(t, err)
+		sessions = append(sessions, session)
+	}
+
+	// 关闭的会话不再接收断开连接事件
+	for _, session := range sessions[:4] {
+		session.Close()
+	}
+
+	live := sessions[4]
+	root.disconnects.mux.Lock()
+	assert.Len(t, root.disconnects.handlers, 2)
+	root.disconnects.mux.Unlock()
+
+	// 整个浏览器只注册一次监听, 转发给仍然打开的会话
+	assert.Equal(t, 1, browser.disconnect())
+	assert.True(t, root.IsClosed())
+	assert.True(t, live.IsClosed())
+}
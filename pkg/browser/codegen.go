@@ -0,0 +1,254 @@
+package browser
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	CodegenGo         = "go"
+	CodegenTypeScript = "typescript"
+)
+
+// GenerateCode 把步骤转换为 Playwright 测试代码, 生成的代码不包含步骤的超时和重试设置
+// 去掉了原值的密码输入依次从 BROWSERTOOLS_SECRET_1, BROWSERTOOLS_SECRET_2 ... 环境变量读取
+func GenerateCode(steps []Step, lang string) (string, error) {
+	switch lang {
+	case CodegenGo:
+		return generateGo(steps), nil
+	case CodegenTypeScript:
+		return generateTypeScript(steps), nil
+	}
+
+	return "", fmt.Errorf("unsupported codegen language %q", lang)
+}
+
+// secretEnvPrefix 生成代码中读取密码的环境变量前缀
+const secretEnvPrefix = "BROWSERTOOLS_SECRET_"
+
+var goWaitStates = map[string]string{
+	"visible":  "playwright.WaitForSelectorStateVisible",
+	"hidden":   "playwright.WaitForSelectorStateHidden",
+	"attached": "playwright.WaitForSelectorStateAttached",
+	"detached": "playwright.WaitForSelectorStateDetached",
+}
+
+func generateGo(steps []Step) string {
+	var body strings.Builder
+	usesRegexp := false
+	usesExpect := false
+	secrets := 0
+
+	for i, step := range steps {
+		n := i + 1
+		label := fmt.Sprintf("step %d %s", n, stepLabel(step))
+		locator := fmt.Sprintf("page.Locator(%q).First()", step.Selector)
+
+		fmt.Fprintf(&body, "\n\t// %s\n", label)
+
+		// 标签作为 t.Fatalf 的格式字符串, 需要转义 %
+		format := strings.ReplaceAll(label, "%", "%%")
+
+		check := func(stmt string) {
+			fmt.Fprintf(&body, "\tif %s; err != nil {\n\t\tt.Fatalf(%q, err)\n\t}\n", stmt, format+": %v")
+		}
+
+		switch step.Action {
+		case StepNavigate:
+			check(fmt.Sprintf("_, err := page.Goto(%q)", step.URL))
+		case StepWait:
+			if step.Selector == "" {
+				fmt.Fprintf(&body, "\tpage.WaitForTimeout(%d)\n", step.Delay.Milliseconds())
+				continue
+			}
+
+			state, ok := goWaitStates[step.State]
+			if !ok {
+				state = goWaitStates["visible"]
+			}
+			check(fmt.Sprintf("err := %s.WaitFor(playwright.LocatorWaitForOptions{State: %s})", locator, state))
+		case StepClick:
+			check(fmt.Sprintf("err := %s.Click()", locator))
+		case StepFill:
+			if step.Secret {
+				secrets++
+				check(fmt.Sprintf("err := %s.Fill(os.Getenv(%q))", locator, fmt.Sprintf("%s%d", secretEnvPrefix, secrets)))
+			} else {
+				check(fmt.Sprintf("err := %s.Fill(%q)", locator, step.Value))
+			}
+		case StepSelect:
+			check(fmt.Sprintf("_, err := %s.SelectOption(playwright.SelectOptionValues{Values: &[]string{%q}})", locator, step.Value))
+		case StepPress:
+			if step.Selector == "" {
+				check(fmt.Sprintf("err := page.Keyboard().Press(%q)", step.Key))
+			} else {
+				check(fmt.Sprintf("err := %s.Press(%q)", locator, step.Key))
+			}
+		case StepEvaluate:
+			if step.Arg != nil {
+				fmt.Fprintf(&body, "\t// arg: %s\n", jsLiteral(step.Arg))
+			}
+			check(fmt.Sprintf("_, err := page.Evaluate(%q)", step.Script))
+		case StepScreenshot:
+			path := fmt.Sprintf("step-%d.png", n)
+			if step.Selector == "" {
+				check(fmt.Sprintf("_, err := page.Screenshot(playwright.PageScreenshotOptions{Path: playwright.String(%q), FullPage: playwright.Bool(%v)})", path, step.FullPage))
+			} else {
+				check(fmt.Sprintf("_, err := %s.Screenshot(playwright.LocatorScreenshotOptions{Path: playwright.String(%q)})", locator, path))
+			}
+		case StepAssert:
+			if step.Condition != AssertEvaluate {
+				usesExpect = true
+			}
+
+			switch step.Condition {
+			case AssertVisible:
+				check(fmt.Sprintf("err := expect.Locator(%s).ToBeVisible()", locator))
+			case AssertHidden:
+				check(fmt.Sprintf("err := expect.Locator(%s).ToBeHidden()", locator))
+			case AssertTextContains:
+				check(fmt.Sprintf("err := expect.Locator(page.Locator(%q).First()).ToContainText(%q)", selectorOrBody(step.Selector), step.Expected))
+			case AssertTextEquals:
+				check(fmt.Sprintf("err := expect.Locator(page.Locator(%q).First()).ToHaveText(%q)", selectorOrBody(step.Selector), step.Expected))
+			case AssertURLContains:
+				usesRegexp = true
+				check(fmt.Sprintf("err := expect.Page(page).ToHaveURL(regexp.MustCompile(%q))", regexp.QuoteMeta(step.Expected)))
+			case AssertTitleContains:
+				usesRegexp = true
+				check(fmt.Sprintf("err := expect.Page(page).ToHaveTitle(regexp.MustCompile(%q))", regexp.QuoteMeta(step.Expected)))
+			case AssertEvaluate:
+				fmt.Fprintf(&body, "\tif result, err := page.Evaluate(%q); err != nil || result == nil || result == false {\n", step.Script)
+				fmt.Fprintf(&body, "\t\tt.Fatalf(%q, result, err)\n\t}\n", format+": result %v, error %v")
+			}
+		default:
+			fmt.Fprintf(&body, "\t// %s is not supported in generated code\n", step.Action)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("package recorded\n\nimport (\n")
+	if secrets > 0 {
+		b.WriteString("\t\"os\"\n")
+	}
+	if usesRegexp {
+		b.WriteString("\t\"regexp\"\n")
+	}
+	b.WriteString("\t\"testing\"\n\n\t\"github.com/playwright-community/playwright-go\"\n)\n\n")
+	b.WriteString("func TestRecorded(t *testing.T) {\n")
+	b.WriteString("\tpw, err := playwright.Run()\n\tif err != nil {\n\t\tt.Fatalf(\"start playwright: %v\", err)\n\t}\n\tdefer pw.Stop()\n\n")
+	b.WriteString("\tbrowser, err := pw.Chromium.Launch()\n\tif err != nil {\n\t\tt.Fatalf(\"launch browser: %v\", err)\n\t}\n\tdefer browser.Close()\n\n")
+	b.WriteString("\tpage, err := browser.NewPage()\n\tif err != nil {\n\t\tt.Fatalf(\"new page: %v\", err)\n\t}\n")
+	if usesExpect {
+		b.WriteString("\n\texpect := playwright.NewPlaywrightAssertions()\n")
+	}
+	b.WriteString(body.String())
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+func generateTypeScript(steps []Step) string {
+	var b strings.Builder
+	b.WriteString("import { test, expect } from '@playwright/test';\n\n")
+	b.WriteString("test('recorded', async ({ page }) => {\n")
+
+	secrets := 0
+
+	for i, step := range steps {
+		n := i + 1
+		locator := fmt.Sprintf("page.locator(%s).first()", jsLiteral(step.Selector))
+
+		fmt.Fprintf(&b, "  // step %d %s\n", n, stepLabel(step))
+
+		switch step.Action {
+		case StepNavigate:
+			fmt.Fprintf(&b, "  await page.goto(%s);\n", jsLiteral(step.URL))
+		case StepWait:
+			if step.Selector == "" {
+				fmt.Fprintf(&b, "  await page.waitForTimeout(%d);\n", step.Delay.Milliseconds())
+			} else {
+				fmt.Fprintf(&b, "  await %s.waitFor({ state: %s });\n", locator, jsLiteral(cmp.Or(step.State, "visible")))
+			}
+		case StepClick:
+			fmt.Fprintf(&b, "  await %s.click();\n", locator)
+		case StepFill:
+			if step.Secret {
+				secrets++
+				fmt.Fprintf(&b, "  await %s.fill(process.env.%s%d ?? '');\n", locator, secretEnvPrefix, secrets)
+			} else {
+				fmt.Fprintf(&b, "  await %s.fill(%s);\n", locator, jsLiteral(step.Value))
+			}
+		case StepSelect:
+			fmt.Fprintf(&b, "  await %s.selectOption(%s);\n", locator, jsLiteral(step.Value))
+		case StepPress:
+			if step.Selector == "" {
+				fmt.Fprintf(&b, "  await page.keyboard.press(%s);\n", jsLiteral(step.Key))
+			} else {
+				fmt.Fprintf(&b, "  await %s.press(%s);\n", locator, jsLiteral(step.Key))
+			}
+		case StepEvaluate:
+			if step.Arg != nil {
+				fmt.Fprintf(&b, "  await page.evaluate(%s, %s);\n", jsLiteral(step.Script), jsLiteral(step.Arg))
+			} else {
+				fmt.Fprintf(&b, "  await page.evaluate(%s);\n", jsLiteral(step.Script))
+			}
+		case StepScreenshot:
+			path := jsLiteral(fmt.Sprintf("step-%d.png", n))
+			if step.Selector == "" {
+				fmt.Fprintf(&b, "  await page.screenshot({ path: %s, fullPage: %v });\n", path, step.FullPage)
+			} else {
+				fmt.Fprintf(&b, "  await %s.screenshot({ path: %s });\n", locator, path)
+			}
+		case StepAssert:
+			target := fmt.Sprintf("page.locator(%s).first()", jsLiteral(selectorOrBody(step.Selector)))
+
+			switch step.Condition {
+			case AssertVisible:
+				fmt.Fprintf(&b, "  await expect(%s).toBeVisible();\n", locator)
+			case AssertHidden:
+				fmt.Fprintf(&b, "  await expect(%s).toBeHidden();\n", locator)
+			case AssertTextContains:
+				fmt.Fprintf(&b, "  await expect(%s).toContainText(%s);\n", target, jsLiteral(step.Expected))
+			case AssertTextEquals:
+				fmt.Fprintf(&b, "  await expect(%s).toHaveText(%s);\n", target, jsLiteral(step.Expected))
+			case AssertURLContains:
+				fmt.Fprintf(&b, "  expect(page.url()).toContain(%s);\n", jsLiteral(step.Expected))
+			case AssertTitleContains:
+				fmt.Fprintf(&b, "  expect(await page.title()).toContain(%s);\n", jsLiteral(step.Expected))
+			case AssertEvaluate:
+				fmt.Fprintf(&b, "  expect(await page.evaluate(%s)).toBeTruthy();\n", jsLiteral(step.Script))
+			}
+		default:
+			fmt.Fprintf(&b, "  // %s is not supported in generated code\n", step.Action)
+		}
+	}
+
+	b.WriteString("});\n")
+
+	return b.String()
+}
+
+func stepLabel(step Step) string {
+	if step.Name != "" {
+		return strings.Join(strings.Fields(step.Name), " ")
+	}
+
+	return step.Action
+}
+
+// jsLiteral JSON 是合法的 JavaScript 字面量, 不转义 HTML 字符以便阅读
+func jsLiteral(value interface{}) string {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "null"
+	}
+
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,102 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+var errManagerClosed = errors.New("browser manager is closed")
+
+// getDriver 返回共享的 Playwright 驱动, 第一次调用时启动
+// 所有浏览器共用一个驱动进程, 关闭管理器时统一停止
+func (m *BrowserManager) getDriver() (*playwright.Playwright, error) {
+	m.driverMux.Lock()
+	defer m.driverMux.Unlock()
+
+	if m.driver != nil {
+		return m.driver, nil
+	}
+
+	if m.isClosed() {
+		return nil, errManagerClosed
+	}
+
+	pw, err := playwright.Run()
+	if err != nil {
+		return nil, errors.WithMessage(err, "could not run playwright")
+	}
+
+	log.Infof("playwright driver started")
+	m.driver = pw
+
+	return pw, nil
+}
+
+func (m *BrowserManager) stopDriver() {
+	m.driverMux.Lock()
+	pw := m.driver
+	m.driver = nil
+	m.driverMux.Unlock()
+
+	if pw == nil {
+		return
+	}
+
+	if err := pw.Stop(); err != nil {
+		log.Errorf("stop playwright driver error: %v", err)
+		return
+	}
+
+	log.Infof("playwright driver stopped")
+}
+
+func (m *BrowserManager) isClosed() bool {
+	select {
+	case <-m.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close 依次关闭浏览器池, 命名会话, 默认浏览器和 Playwright 驱动, 停止后台任务和崩溃恢复
+// 关闭后不能再创建浏览器
+func (m *BrowserManager) Close() {
+	m.mutex.Lock()
+	if m.isClosed() {
+		m.mutex.Unlock()
+		return
+	}
+
+	close(m.done)
+	b := m.browserHandler
+	m.setBrowserLocked(nil)
+	pool := m.pool
+	m.pendingTabs = nil
+	m.pendingSessions = make(map[string][]TabSnapshot)
+	m.mutex.Unlock()
+
+	if pool != nil {
+		pool.Close()
+	}
+
+	m.sessions.mux.Lock()
+	sessions := m.sessions.sessions
+	m.sessions.sessions = make(map[string]*session)
+	m.sessions.mux.Unlock()
+
+	// 会话可能共用默认浏览器, 先关闭会话的上下文再关闭浏览器
+	for id, s := range sessions {
+		s.handler.Close()
+		log.Infof("session %s closed", id)
+	}
+
+	if b != nil && !b.IsClosed() {
+		b.Close()
+		log.Infof("default browser closed")
+	}
+
+	m.stopDriver()
+}
@@ -0,0 +1,19 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrowserManager_Close(t *testing.T) {
+	m := NewBrowserManagerWithOptions(DefaultOptions())
+	m.Close()
+	m.Close()
+
+	_, err := m.GetOrCreateBrowser()
+	assert.ErrorIs(t, err, errManagerClosed)
+
+	_, err = m.getDriver()
+	assert.ErrorIs(t, err, errManagerClosed)
+}
@@ -0,0 +1,94 @@
+package browser
+
+import (
+	"browsertools/pkg/errors"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	EngineChromium = "chromium"
+	EngineFirefox  = "firefox"
+	EngineWebKit   = "webkit"
+
+	// ModeHeadful 有界面模式, 需要显示器或 X 服务
+	ModeHeadful = "headful"
+	// ModeHeadless 无头模式
+	ModeHeadless = "headless"
+	// ModeNewHeadless Chrome 新版无头模式, 行为与有界面模式一致, 仅 Chromium 支持
+	ModeNewHeadless = "new-headless"
+
+	headlessWindowWidth  = 1920
+	headlessWindowHeight = 1080
+)
+
+// ValidateEngine 校验引擎和无头模式, 引擎不支持的模式返回 ErrUnsupported
+func ValidateEngine(engine string, mode string) error {
+	switch engine {
+	case EngineChromium, EngineFirefox, EngineWebKit:
+	default:
+		return errors.WithMessagef(errors.ErrArgument, "unknown browser engine %q", engine)
+	}
+
+	switch mode {
+	case ModeHeadful, ModeHeadless:
+	case ModeNewHeadless:
+		if engine != EngineChromium {
+			return errors.WithMessagef(errors.ErrUnsupported, "%s mode is not supported by %s", mode, engine)
+		}
+	default:
+		return errors.WithMessagef(errors.ErrArgument, "unknown headless mode %q", mode)
+	}
+
+	return nil
+}
+
+func browserType(pw *playwright.Playwright, engine string) playwright.BrowserType {
+	switch engine {
+	case EngineFirefox:
+		return pw.Firefox
+	case EngineWebKit:
+		return pw.WebKit
+	default:
+		return pw.Chromium
+	}
+}
+
+// contextOptions 有界面时使用窗口大小, 无头模式没有窗口, 固定视口大小
+func contextOptions(mode string) playwright.BrowserNewContextOptions {
+	if mode == ModeHeadful {
+		return playwright.BrowserNewContextOptions{NoViewport: playwright.Bool(true)}
+	}
+
+	return playwright.BrowserNewContextOptions{
+		Viewport: &playwright.Size{Width: headlessWindowWidth, Height: headlessWindowHeight},
+	}
+}
+
+// Engine 返回浏览器引擎名称
+func (h *BrowserHandler) Engine() string {
+	return h.browser.BrowserType().Name()
+}
+
+// HeadlessMode 返回浏览器的无头模式
+func (h *BrowserHandler) HeadlessMode() string {
+	return h.headlessMode
+}
+
+// Engine 返回页面所在浏览器的引擎名称
+func (h *PageHandler) Engine() string {
+	if b := h.page.Context().Browser(); b != nil {
+		return b.BrowserType().Name()
+	}
+
+	return EngineChromium
+}
+
+// requireCDP CDP 相关功能只有 Chromium 支持
+func (h *PageHandler) requireCDP() error {
+	if engine := h.Engine(); engine != EngineChromium {
+		return errors.WithMessagef(errors.ErrUnsupported, "cdp is not supported by %s", engine)
+	}
+
+	return nil
+}
@@ -0,0 +1,23 @@
+package browser
+
+import (
+	"browsertools/pkg/errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateEngine(t *testing.T) {
+	assert.NoError(t, ValidateEngine(EngineChromium, ModeNewHeadless))
+	assert.NoError(t, ValidateEngine(EngineFirefox, ModeHeadless))
+	assert.NoError(t, ValidateEngine(EngineWebKit, ModeHeadful))
+
+	err := ValidateEngine(EngineFirefox, ModeNewHeadless)
+	assert.True(t, errors.EqualCodeError(err, errors.ErrUnsupported))
+
+	err = ValidateEngine("edge", ModeHeadless)
+	assert.True(t, errors.EqualCodeError(err, errors.ErrArgument))
+
+	err = ValidateEngine(EngineChromium, "hidden")
+	assert.True(t, errors.EqualCodeError(err, errors.ErrArgument))
+}
@@ -0,0 +1,93 @@
+package browser
+
+import (
+	"time"
+)
+
+const (
+	EventTabOpened           = "tab.opened"
+	EventTabClosed           = "tab.closed"
+	EventNavigationFailed    = "navigation.failed"
+	EventPageCrashed         = "page.crashed"
+	EventBrowserDisconnected = "browser.disconnected"
+	EventBrowserRelaunched   = "browser.relaunched"
+	EventDownloadFinished    = "download.finished"
+)
+
+// BrowserEvent 浏览器和标签页事件, 用于对外通知
+type BrowserEvent struct {
+	Type      string
+	SessionID string
+	PageID    string
+	URL       string
+	Message   string
+	Data      map[string]interface{}
+	Time      time.Time
+}
+
+// OnEvent 注册浏览器事件回调, 返回取消注册的函数
+// 回调可能在 playwright 事件协程中执行, 不能阻塞, 也不能同步调用 playwright
+func (m *BrowserManager) OnEvent(fn func(BrowserEvent)) func() {
+	m.eventMux.Lock()
+	defer m.eventMux.Unlock()
+
+	m.eventSeq++
+	seq := m.eventSeq
+	m.eventListeners[seq] = fn
+
+	return func() {
+		m.eventMux.Lock()
+		defer m.eventMux.Unlock()
+
+		delete(m.eventListeners, seq)
+	}
+}
+
+// emitEvent 使用单独的锁, 启动浏览器时持有 m.mutex, 不能在事件协程中等待它
+func (m *BrowserManager) emitEvent(event BrowserEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	m.eventMux.RLock()
+	listeners := make([]func(BrowserEvent), 0, len(m.eventListeners))
+	for _, fn := range m.eventListeners {
+		listeners = append(listeners, fn)
+	}
+	m.eventMux.RUnlock()
+
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// observe 把浏览器的事件转发给管理器的事件回调
+func (m *BrowserManager) observe(b *BrowserHandler, sessionID string) {
+	m.observeFunc(b, func() string { return sessionID })
+}
+
+// observeFunc 事件发生时才获取 sessionID, 池中的浏览器在租用期间使用租约ID
+func (m *BrowserManager) observeFunc(b *BrowserHandler, sessionID func() string) {
+	b.stateMux.Lock()
+	defer b.stateMux.Unlock()
+
+	b.onEvent = func(event BrowserEvent) {
+		event.SessionID = sessionID()
+		m.emitEvent(event)
+	}
+}
+
+func (h *BrowserHandler) emit(event BrowserEvent) {
+	h.stateMux.Lock()
+	onEvent := h.onEvent
+	h.stateMux.Unlock()
+
+	if onEvent != nil {
+		onEvent(event)
+	}
+}
+
+// OnPageEvent 转发页面产生的事件
+func (h *BrowserHandler) OnPageEvent(event BrowserEvent) {
+	h.emit(event)
+}
@@ -0,0 +1,397 @@
+package browser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// 提取脚本, 参数 scope 为可选的CSS选择器, 用于限定提取范围
+const (
+	extractLinksScript = `
+(scope) => {
+  const root = scope ? document.querySelector(scope) : document;
+  if (!root) return [];
+  const clean = (s) => (s || '').replace(/\s+/g, ' ').trim();
+  return Array.from(root.querySelectorAll('a[href], area[href]')).map((a) => ({
+    text: clean(a.innerText || a.textContent || a.getAttribute('aria-label') || a.title || a.getAttribute('alt')),
+    href: a.href,
+    rel: a.getAttribute('rel') || '',
+    target: a.getAttribute('target') || '',
+  }));
+}
+`
+
+	extractFormsScript = `
+(scope) => {
+  const root = scope ? document.querySelector(scope) : document;
+  if (!root) return [];
+  const clean = (s) => (s || '').replace(/\s+/g, ' ').trim();
+  const labelOf = (el) => {
+    if (el.labels && el.labels.length) return clean(Array.from(el.labels).map((l) => l.innerText).join(' '));
+    const aria = el.getAttribute('aria-label');
+    if (aria) return clean(aria);
+    const by = el.getAttribute('aria-labelledby');
+    if (by) return clean(by.split(/\s+/).map((id) => (document.getElementById(id) || {}).innerText || '').join(' '));
+    return clean(el.getAttribute('placeholder') || el.title);
+  };
+  return Array.from(root.querySelectorAll('form')).map((form, index) => ({
+    index,
+    id: form.id || '',
+    name: form.getAttribute('name') || '',
+    action: form.action || '',
+    method: (form.getAttribute('method') || 'get').toLowerCase(),
+    fields: Array.from(form.elements).filter((el) => el.name || el.id).map((el) => {
+      const tag = el.tagName.toLowerCase();
+      const type = (el.type || tag).toLowerCase();
+      const field = {
+        tag, type,
+        name: el.name || '',
+        id: el.id || '',
+        label: labelOf(el),
+        required: !!el.required,
+        disabled: !!el.disabled,
+        value: '',
+      };
+      if (type === 'checkbox' || type === 'radio') {
+        field.value = el.value;
+        field.checked = el.checked;
+      } else if (type === 'password') {
+        field.value = el.value ? '******' : '';
+      } else if (tag === 'select') {
+        field.value = Array.from(el.selectedOptions).map((o) => o.value).join(',');
+        field.options = Array.from(el.options).map((o) => ({ value: o.value, text: clean(o.text), selected: o.selected }));
+      } else if (type !== 'file') {
+        field.value = el.value == null ? '' : String(el.value);
+      }
+      return field;
+    }),
+  }));
+}
+`
+
+	extractTablesScript = `
+(scope) => {
+  const root = scope ? document.querySelector(scope) : document;
+  if (!root) return [];
+  const clean = (s) => (s || '').replace(/\s+/g, ' ').trim();
+  const cells = (row) => Array.from(row.cells).map((c) => clean(c.innerText));
+  return Array.from(root.querySelectorAll('table')).map((table, index) => {
+    const thead = table.tHead;
+    let rows = Array.from(table.rows);
+    let headers = [];
+    if (thead && thead.rows.length) {
+      headers = cells(thead.rows[0]);
+      rows = rows.filter((r) => r.parentElement !== thead);
+    } else if (rows.length && Array.from(rows[0].cells).every((c) => c.tagName === 'TH')) {
+      headers = cells(rows.shift());
+    }
+    return {
+      index,
+      id: table.id || '',
+      caption: table.caption ? clean(table.caption.innerText) : '',
+      headers,
+      rows: rows.map(cells).filter((r) => r.length),
+    };
+  });
+}
+`
+
+	extractMetadataScript = `
+() => {
+  const meta = {}, og = {}, twitter = {};
+  document.querySelectorAll('meta').forEach((m) => {
+    const charset = m.getAttribute('charset');
+    const key = m.getAttribute('property') || m.getAttribute('name') || m.getAttribute('http-equiv') || (charset ? 'charset' : '');
+    if (!key) return;
+    const value = m.getAttribute('content') || charset || '';
+    let target = meta, name = key;
+    if (key.startsWith('og:')) { target = og; name = key.slice(3); }
+    else if (key.startsWith('twitter:')) { target = twitter; name = key.slice(8); }
+    if (!(name in target)) target[name] = value;
+  });
+  const canonical = document.querySelector('link[rel="canonical"]');
+  return {
+    title: document.title,
+    url: location.href,
+    canonical: canonical ? canonical.href : '',
+    lang: document.documentElement.lang || '',
+    description: meta.description || '',
+    meta,
+    open_graph: og,
+    twitter,
+    icons: Array.from(document.querySelectorAll('link[rel~="icon"]')).map((l) => l.href),
+    alternates: Array.from(document.querySelectorAll('link[rel="alternate"][hreflang]')).map((l) => ({ hreflang: l.hreflang, href: l.href })),
+  };
+}
+`
+
+	extractStructuredDataScript = `
+() => {
+  const jsonLd = [], errors = [];
+  document.querySelectorAll('script[type="application/ld+json"]').forEach((s) => {
+    try { jsonLd.push(JSON.parse(s.textContent)); } catch (e) { errors.push(String(e)); }
+  });
+  const propValue = (el) => {
+    if (el.hasAttribute('content')) return el.getAttribute('content');
+    switch (el.tagName) {
+      case 'A': case 'AREA': case 'LINK': return el.href;
+      case 'IMG': case 'AUDIO': case 'VIDEO': case 'SOURCE': case 'IFRAME': case 'EMBED': case 'TRACK': return el.src;
+      case 'OBJECT': return el.data;
+      case 'TIME': return el.dateTime || el.textContent.trim();
+      case 'DATA': case 'METER': return el.value;
+    }
+    return (el.textContent || '').replace(/\s+/g, ' ').trim();
+  };
+  const parseItem = (el) => {
+    const item = { type: (el.getAttribute('itemtype') || '').split(/\s+/).filter(Boolean), id: el.getAttribute('itemid') || '', properties: {} };
+    const visit = (node) => {
+      for (const child of node.children) {
+        if (child.hasAttribute('itemprop')) {
+          const nested = child.hasAttribute('itemscope');
+          const value = nested ? parseItem(child) : propValue(child);
+          child.getAttribute('itemprop').split(/\s+/).filter(Boolean).forEach((name) => {
+            (item.properties[name] = item.properties[name] || []).push(value);
+          });
+          if (nested) continue;
+        } else if (child.hasAttribute('itemscope')) {
+          continue;
+        }
+        visit(child);
+      }
+    };
+    visit(el);
+    return item;
+  };
+  const microdata = Array.from(document.querySelectorAll('[itemscope]:not([itemprop])')).map(parseItem);
+  return { json_ld: jsonLd, microdata, errors };
+}
+`
+)
+
+// Link 页面链接
+type Link struct {
+	Text   string `json:"text"`
+	Href   string `json:"href"`
+	Rel    string `json:"rel"`
+	Target string `json:"target"`
+}
+
+// FormOption 下拉框选项
+type FormOption struct {
+	Value    string `json:"value"`
+	Text     string `json:"text"`
+	Selected bool   `json:"selected"`
+}
+
+// FormField 表单字段, 密码只返回是否已填写
+type FormField struct {
+	Tag      string       `json:"tag"`
+	Type     string       `json:"type"`
+	Name     string       `json:"name"`
+	ID       string       `json:"id"`
+	Label    string       `json:"label"`
+	Value    string       `json:"value"`
+	Checked  *bool        `json:"checked,omitempty"`
+	Required bool         `json:"required"`
+	Disabled bool         `json:"disabled"`
+	Options  []FormOption `json:"options,omitempty"`
+}
+
+// Form 页面表单
+type Form struct {
+	Index  int         `json:"index"`
+	ID     string      `json:"id"`
+	Name   string      `json:"name"`
+	Action string      `json:"action"`
+	Method string      `json:"method"`
+	Fields []FormField `json:"fields"`
+}
+
+// Table 页面表格
+type Table struct {
+	Index   int        `json:"index"`
+	ID      string     `json:"id"`
+	Caption string     `json:"caption"`
+	Headers []string   `json:"headers"`
+	Rows    [][]string `json:"rows"`
+}
+
+// Metadata 页面元信息
+type Metadata struct {
+	Title       string            `json:"title"`
+	Url         string            `json:"url"`
+	Canonical   string            `json:"canonical"`
+	Lang        string            `json:"lang"`
+	Description string            `json:"description"`
+	Meta        map[string]string `json:"meta"`
+	OpenGraph   map[string]string `json:"open_graph"`
+	Twitter     map[string]string `json:"twitter"`
+	Icons       []string          `json:"icons"`
+	Alternates  []struct {
+		Hreflang string `json:"hreflang"`
+		Href     string `json:"href"`
+	} `json:"alternates"`
+}
+
+// MicrodataItem 一个 itemscope 条目, 属性值为字符串或嵌套的 MicrodataItem
+type MicrodataItem struct {
+	Type       []string                 `json:"type"`
+	ID         string                   `json:"id,omitempty"`
+	Properties map[string][]interface{} `json:"properties"`
+}
+
+// StructuredData 页面内嵌的结构化数据
+type StructuredData struct {
+	JSONLD    []interface{}   `json:"json_ld"`
+	Microdata []MicrodataItem `json:"microdata"`
+	Errors    []string        `json:"errors"`
+}
+
+// Columns 返回列名, 没有表头或列名重复时自动生成
+func (t *Table) Columns() []string {
+	count := len(t.Headers)
+	for _, row := range t.Rows {
+		count = max(count, len(row))
+	}
+
+	columns := make([]string, count)
+	used := make(map[string]int)
+
+	for i := range columns {
+		name := ""
+		if i < len(t.Headers) {
+			name = t.Headers[i]
+		}
+		if name == "" {
+			name = "column_" + strconv.Itoa(i+1)
+		}
+
+		used[name]++
+		if used[name] > 1 {
+			name = name + "_" + strconv.Itoa(used[name])
+		}
+
+		columns[i] = name
+	}
+
+	return columns
+}
+
+// Objects 把表格行转换为以列名为键的对象
+func (t *Table) Objects() []map[string]string {
+	columns := t.Columns()
+	objects := make([]map[string]string, 0, len(t.Rows))
+
+	for _, row := range t.Rows {
+		object := make(map[string]string, len(columns))
+		for i, column := range columns {
+			if i < len(row) {
+				object[column] = row[i]
+			} else {
+				object[column] = ""
+			}
+		}
+
+		objects = append(objects, object)
+	}
+
+	return objects
+}
+
+// CSV 把表格转换为CSV, 第一行为列名
+func (t *Table) CSV() (string, error) {
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+
+	columns := t.Columns()
+	if err := writer.Write(columns); err != nil {
+		return "", err
+	}
+
+	for _, row := range t.Rows {
+		record := make([]string, len(columns))
+		copy(record, row)
+
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+
+	return buf.String(), writer.Error()
+}
+
+// ExtractLinks 提取页面中的所有链接
+func (h *PageHandler) ExtractLinks(scope string) ([]Link, error) {
+	links := make([]Link, 0)
+	err := h.evaluateInto(extractLinksScript, scope, &links)
+
+	return links, err
+}
+
+// ExtractForms 提取页面中的表单及字段的当前值
+func (h *PageHandler) ExtractForms(scope string) ([]Form, error) {
+	forms := make([]Form, 0)
+	err := h.evaluateInto(extractFormsScript, scope, &forms)
+
+	return forms, err
+}
+
+// ExtractTables 提取页面中的HTML表格
+func (h *PageHandler) ExtractTables(scope string) ([]Table, error) {
+	tables := make([]Table, 0)
+	err := h.evaluateInto(extractTablesScript, scope, &tables)
+
+	return tables, err
+}
+
+// ExtractMetadata 提取标题、meta、OpenGraph、canonical 等元信息
+func (h *PageHandler) ExtractMetadata() (*Metadata, error) {
+	metadata := &Metadata{}
+	if err := h.evaluateInto(extractMetadataScript, nil, metadata); err != nil {
+		return nil, err
+	}
+
+	return metadata, nil
+}
+
+// ExtractStructuredData 提取 JSON-LD 和 microdata
+func (h *PageHandler) ExtractStructuredData() (*StructuredData, error) {
+	data := &StructuredData{}
+	if err := h.evaluateInto(extractStructuredDataScript, nil, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// evaluateInto 在页面中执行脚本, 并把结果反序列化到 out
+func (h *PageHandler) evaluateInto(script string, arg interface{}, out interface{}) error {
+	if h.IsClosed() {
+		return fmt.Errorf("page %s is closed, cannot evaluate script", h.pageID)
+	}
+
+	if err := h.checkResponsive(); err != nil {
+		return err
+	}
+
+	result, err := h.page.Evaluate(script, arg)
+	if err != nil {
+		return fmt.Errorf("evaluate failed for page %s: %w", h.pageID, err)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshal evaluate result failed for page %s: %w", h.pageID, err)
+	}
+
+	if err = json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("unmarshal evaluate result failed for page %s: %w", h.pageID, err)
+	}
+
+	return nil
+}
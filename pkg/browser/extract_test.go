@@ -0,0 +1,33 @@
+package browser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable_Objects(t *testing.T) {
+	table := &Table{
+		Headers: []string{"Name", "", "Name"},
+		Rows:    [][]string{{"a", "1", "x"}, {"b"}, {"c", "3", "z", "extra"}},
+	}
+
+	assert.Equal(t, []string{"Name", "column_2", "Name_2", "column_4"}, table.Columns())
+
+	objects := table.Objects()
+	assert.Len(t, objects, 3)
+	assert.Equal(t, map[string]string{"Name": "a", "column_2": "1", "Name_2": "x", "column_4": ""}, objects[0])
+	assert.Equal(t, "", objects[1]["column_2"])
+	assert.Equal(t, "extra", objects[2]["column_4"])
+}
+
+func TestTable_CSV(t *testing.T) {
+	table := &Table{
+		Headers: []string{"Name", "Note"},
+		Rows:    [][]string{{"a", "hello, world"}, {"b", `say "hi"`}},
+	}
+
+	data, err := table.CSV()
+	assert.NoError(t, err)
+	assert.Equal(t, "Name,Note\na,\"hello, world\"\nb,\"say \"\"hi\"\"\"\n", data)
+}
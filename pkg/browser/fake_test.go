@@ -0,0 +1,308 @@
+package browser
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// fakeBrowser 记录关闭调用的浏览器, 未实现的方法调用时 panic
+type fakeBrowser struct {
+	playwright.Browser
+	// engine 浏览器类型名称, 不是 chromium 时不使用 CDP
+	engine string
+	mu     sync.Mutex
+	closed int
+	// disconnectListeners 通过 OnDisconnected 注册的监听
+	disconnectListeners []func(playwright.Browser)
+}
+
+// fakeBrowserType 只有名称的浏览器类型
+type fakeBrowserType struct {
+	playwright.BrowserType
+	name string
+}
+
+func (t *fakeBrowserType) Name() string {
+	return t.name
+}
+
+func (b *fakeBrowser) BrowserType() playwright.BrowserType {
+	return &fakeBrowserType{name: b.engine}
+}
+
+func (b *fakeBrowser) NewContext(...playwright.BrowserNewContextOptions) (playwright.BrowserContext, error) {
+	return &fakeContext{browser: b}, nil
+}
+
+func (b *fakeBrowser) OnDisconnected(fn func(playwright.Browser)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.disconnectListeners = append(b.disconnectListeners, fn)
+}
+
+// disconnect 模拟浏览器断开连接, 返回通知的监听数量
+func (b *fakeBrowser) disconnect() int {
+	b.mu.Lock()
+	listeners := append([]func(playwright.Browser){}, b.disconnectListeners...)
+	b.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(b)
+	}
+	return len(listeners)
+}
+
+func (b *fakeBrowser) Close(...playwright.BrowserCloseOptions) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed++
+	return nil
+}
+
+func (b *fakeBrowser) IsConnected() bool {
+	return b.closeCount() == 0
+}
+
+func (b *fakeBrowser) closeCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// fakeContext 记录关闭调用的浏览器上下文
+type fakeContext struct {
+	playwright.BrowserContext
+	browser playwright.Browser
+	mu      sync.Mutex
+	closed  int
+	// created 通过 NewPage 创建的页面
+	created []*fakePage
+	// cdpSend 为空时 NewCDPSession panic
+	cdpSend func(page playwright.Page, method string) (interface{}, error)
+}
+
+func (c *fakeContext) NewCDPSession(page interface{}) (playwright.CDPSession, error) {
+	p := page.(playwright.Page)
+
+	return &fakeCDPSession{send: func(method string) (interface{}, error) {
+		return c.cdpSend(p, method)
+	}}, nil
+}
+
+func (c *fakeContext) Pages() []playwright.Page {
+	return nil
+}
+
+func (c *fakeContext) OnPage(func(playwright.Page)) {}
+
+func (c *fakeContext) OnClose(func(playwright.BrowserContext)) {}
+
+func (c *fakeContext) Browser() playwright.Browser {
+	return c.browser
+}
+
+func (c *fakeContext) NewPage() (playwright.Page, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	page := &fakePage{context: c}
+	c.created = append(c.created, page)
+	return page, nil
+}
+
+func (c *fakeContext) createdPages() []*fakePage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*fakePage{}, c.created...)
+}
+
+func (c *fakeContext) Close(...playwright.BrowserContextCloseOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed++
+	return nil
+}
+
+func (c *fakeContext) ClearCookies(...playwright.BrowserContextClearCookiesOptions) error {
+	return nil
+}
+
+func (c *fakeContext) ClearPermissions() error {
+	return nil
+}
+
+func (c *fakeContext) closeCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// fakePage 记录关闭调用的页面
+type fakePage struct {
+	playwright.Page
+	context *fakeContext
+	url     string
+	// evaluate 为空时 Evaluate 返回 nil
+	evaluate func(expression string, arg ...interface{}) (interface{}, error)
+	mu       sync.Mutex
+	closed   int
+	// gotoTimeouts 每次 Goto 传入的超时毫秒数
+	gotoTimeouts []float64
+	// keyboard 和 locator 为空时调用 panic
+	keyboard playwright.Keyboard
+	locator  *fakeLocator
+}
+
+func (p *fakePage) Keyboard() playwright.Keyboard {
+	return p.keyboard
+}
+
+func (p *fakePage) Locator(string, ...playwright.PageLocatorOptions) playwright.Locator {
+	return p.locator
+}
+
+// fakeKeyboard 按键在 press 返回之前阻塞
+type fakeKeyboard struct {
+	playwright.Keyboard
+	press chan struct{}
+}
+
+func (k *fakeKeyboard) Press(string, ...playwright.KeyboardPressOptions) error {
+	<-k.press
+	return nil
+}
+
+// locatorInterface 嵌入时字段名不能与 Locator 方法冲突
+type locatorInterface = playwright.Locator
+
+// fakeLocator 记录 WaitFor 的参数, waitFor 为空时立即返回
+type fakeLocator struct {
+	locatorInterface
+	waitFor func(playwright.LocatorWaitForOptions) error
+	mu      sync.Mutex
+	waits   []playwright.LocatorWaitForOptions
+}
+
+func (l *fakeLocator) First() playwright.Locator {
+	return l
+}
+
+func (l *fakeLocator) WaitFor(options ...playwright.LocatorWaitForOptions) error {
+	opt := options[0]
+
+	l.mu.Lock()
+	l.waits = append(l.waits, opt)
+	l.mu.Unlock()
+
+	if l.waitFor == nil {
+		return nil
+	}
+	return l.waitFor(opt)
+}
+
+func (p *fakePage) Context() playwright.BrowserContext {
+	return p.context
+}
+
+// On 和注入脚本相关的方法不做任何事
+func (p *fakePage) On(string, interface{}) {}
+
+func (p *fakePage) Opener() (playwright.Page, error) {
+	return nil, nil
+}
+
+func (p *fakePage) ExposeFunction(string, playwright.ExposedFunction) error {
+	return nil
+}
+
+func (p *fakePage) AddInitScript(playwright.Script) error {
+	return nil
+}
+
+func (p *fakePage) Evaluate(expression string, arg ...interface{}) (interface{}, error) {
+	if p.evaluate == nil {
+		return nil, nil
+	}
+
+	return p.evaluate(expression, arg...)
+}
+
+func (p *fakePage) Goto(url string, options ...playwright.PageGotoOptions) (playwright.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.url = url
+	for _, opt := range options {
+		if opt.Timeout != nil {
+			p.gotoTimeouts = append(p.gotoTimeouts, *opt.Timeout)
+		}
+	}
+
+	return nil, nil
+}
+
+func (p *fakePage) URL() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.url
+}
+
+func (p *fakePage) Close(...playwright.PageCloseOptions) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed++
+	return nil
+}
+
+func (p *fakePage) closeCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// fakeCDPSession send 为空时所有命令都返回 nil
+type fakeCDPSession struct {
+	playwright.CDPSession
+	send func(method string) (interface{}, error)
+}
+
+func (s *fakeCDPSession) Send(method string, _ map[string]interface{}) (interface{}, error) {
+	if s.send == nil {
+		return nil, nil
+	}
+	return s.send(method)
+}
+
+func (s *fakeCDPSession) Detach() error {
+	return nil
+}
+
+// newFakeBrowserHandler 不注册事件监听的 BrowserHandler, pages 作为已打开的标签页
+func newFakeBrowserHandler(browser playwright.Browser, ctx playwright.BrowserContext, pages ...playwright.Page) *BrowserHandler {
+	h := &BrowserHandler{
+		browser:        browser,
+		browserContext: ctx,
+		pageList:       NewPageList(),
+		disconnects:    &disconnectListeners{handlers: make(map[*BrowserHandler]struct{})},
+		policyMux:      &sync.Mutex{},
+		evictMux:       &sync.Mutex{},
+		stateMux:       &sync.Mutex{},
+	}
+
+	for i, page := range pages {
+		// 按参数顺序创建, 后面的页面更新
+		handler := &PageHandler{
+			page:         page,
+			pageID:       fmt.Sprintf("page-%d", i+1),
+			createTime:   time.Now().Add(time.Duration(i-len(pages)) * time.Second),
+			mux:          &sync.Mutex{},
+			pageListener: h,
+		}
+		h.pageList.AddPage(handler)
+	}
+
+	return h
+}
@@ -0,0 +1,141 @@
+package browser
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	HealthCheckBinary  = "browser_binary"
+	HealthCheckDriver  = "driver"
+	HealthCheckBrowser = "browser"
+
+	defaultHealthTimeout = 2 * time.Second
+)
+
+// HealthCheck 单个组件的检查结果
+type HealthCheck struct {
+	Name    string
+	OK      bool
+	Message string
+}
+
+// BrowserHealth 默认浏览器的健康状态
+type BrowserHealth struct {
+	Engine          string
+	HeadlessMode    string
+	Path            string
+	Started         bool
+	Connected       bool
+	PageCount       int
+	LastLaunchTime  time.Time
+	LastLaunchError string
+	Checks          []HealthCheck
+}
+
+// Ready 所有检查都通过时才可以接收流量
+func (h BrowserHealth) Ready() bool {
+	for _, check := range h.Checks {
+		if !check.OK {
+			return false
+		}
+	}
+
+	return true
+}
+
+// launchResult 最近一次启动默认浏览器的结果
+type launchResult struct {
+	time time.Time
+	err  error
+}
+
+func (m *BrowserManager) recordLaunch(err error) {
+	m.lastLaunch.Store(&launchResult{time: time.Now(), err: err})
+}
+
+// Health 检查浏览器可执行文件, Playwright 驱动和浏览器连接, 不会启动浏览器
+func (m *BrowserManager) Health() BrowserHealth {
+	health := BrowserHealth{
+		Engine:       m.opt.Engine,
+		HeadlessMode: m.opt.HeadlessMode,
+		Path:         m.path,
+	}
+
+	if last := m.lastLaunch.Load(); last != nil {
+		health.LastLaunchTime = last.time
+		if last.err != nil {
+			health.LastLaunchError = last.err.Error()
+		}
+	}
+
+	binary := HealthCheck{Name: HealthCheckBinary, OK: true, Message: m.path}
+	// Firefox 和 WebKit 使用 playwright 安装的浏览器
+	if m.opt.Engine == EngineChromium && !m.IsInstalled() {
+		binary.OK = false
+		binary.Message = fmt.Sprintf("no browser found in %v", m.opt.PathCandidates)
+	} else if m.opt.Engine != EngineChromium {
+		binary.Message = "managed by playwright"
+	}
+
+	health.Checks = append(health.Checks, binary)
+
+	// 读取默认浏览器不等待 m.mutex, 启动浏览器期间会一直持有该锁
+	b := m.current.Load()
+
+	// 浏览器按需启动, 还没有启动过或者关闭后还没有请求时, 只要上次启动没有失败就认为可用
+	if b == nil || b.IsClosed() {
+		check := HealthCheck{OK: health.LastLaunchError == "", Message: "not started"}
+		if m.launching.Load() {
+			check.Message = "launching"
+		} else if b != nil {
+			check.Message = "closed, will relaunch on next request"
+		}
+
+		if !check.OK {
+			check.Message = "last launch failed: " + health.LastLaunchError
+		}
+
+		health.Checks = append(health.Checks,
+			HealthCheck{Name: HealthCheckDriver, OK: check.OK, Message: check.Message},
+			HealthCheck{Name: HealthCheckBrowser, OK: check.OK, Message: check.Message},
+		)
+		return health
+	}
+
+	health.Started = true
+	health.Connected = b.browser.IsConnected()
+	health.PageCount = b.GetPageCount()
+
+	driver := HealthCheck{Name: HealthCheckDriver, OK: true}
+	if err := b.ping(defaultHealthTimeout); err != nil {
+		driver.OK = false
+		driver.Message = err.Error()
+	}
+
+	browserCheck := HealthCheck{Name: HealthCheckBrowser, OK: health.Connected,
+		Message: fmt.Sprintf("%d pages", health.PageCount)}
+	if !health.Connected {
+		browserCheck.Message = "browser disconnected"
+	}
+
+	health.Checks = append(health.Checks, driver, browserCheck)
+
+	return health
+}
+
+// ping 通过 Playwright 驱动向浏览器发送一次请求, 驱动退出或卡住时返回错误
+func (h *BrowserHandler) ping(timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := h.browserContext.Cookies()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("driver did not respond in %s", timeout)
+	}
+}
@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"browsertools/pkg/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrowserManager_Health(t *testing.T) {
+	opt := DefaultOptions()
+	opt.PathCandidates = []string{"/nonexistent/chrome"}
+
+	m := NewBrowserManagerWithOptions(opt)
+	health := m.Health()
+	assert.False(t, health.Ready())
+	assert.False(t, health.Started)
+	assert.Equal(t, HealthCheckBinary, health.Checks[0].Name)
+	assert.False(t, health.Checks[0].OK)
+
+	opt.Engine = EngineFirefox
+	m = NewBrowserManagerWithOptions(opt)
+	assert.True(t, m.Health().Ready())
+
+	m.recordLaunch(errors.New("could not launch"))
+	health = m.Health()
+	assert.False(t, health.Ready())
+	assert.Equal(t, "could not launch", health.LastLaunchError)
+}
+
+func TestBrowserManager_HealthWhileLaunching(t *testing.T) {
+	opt := DefaultOptions()
+	opt.Engine = EngineFirefox
+	m := NewBrowserManagerWithOptions(opt)
+
+	// 启动浏览器期间持有 m.mutex, 健康检查不等待该锁
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.launching.Store(true)
+
+	done := make(chan BrowserHealth, 1)
+	go func() {
+		done <- m.Health()
+	}()
+
+	var health BrowserHealth
+	select {
+	case health = <-done:
+	case <-time.After(time.Second):
+		t.Fatal("health check blocked by browser launch")
+	}
+	assert.True(t, health.Ready())
+	assert.Equal(t, "launching", health.Checks[2].Message)
+
+	m.recordLaunch(errors.New("could not launch"))
+	assert.False(t, m.Health().Ready())
+}
@@ -0,0 +1,346 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	interactionBinding = "__browsertoolsRecordAction"
+
+	// interactionScript 通过 AddInitScript 注入, 捕获用户的点击, 输入, 选择和按键, 只处理真实的用户事件
+	// 选择器按 data-testid 等测试属性, id, name, aria-label, placeholder, 文本, CSS 路径的顺序生成
+	interactionScript = `
+(() => {
+  if (window.top !== window || window.__browsertoolsInteraction) {
+    return;
+  }
+  window.__browsertoolsInteraction = true;
+
+  const send = (action) => {
+    if (typeof window.` + interactionBinding + ` === 'function') {
+      window.` + interactionBinding + `(action).catch(() => {});
+    }
+  };
+  const clean = (s) => (s || '').replace(/\s+/g, ' ').trim();
+  const quote = (s) => JSON.stringify(s);
+  const escape = (s) => (window.CSS && CSS.escape ? CSS.escape(s) : s);
+  const stableID = (id) => id && !/^\d|\d{3,}|[:.]/.test(id);
+  const unique = (selector, el) => {
+    try {
+      const list = document.querySelectorAll(selector);
+      return list.length === 1 && list[0] === el;
+    } catch (e) {
+      return false;
+    }
+  };
+
+  const selectorOf = (el) => {
+    const tag = el.tagName.toLowerCase();
+    for (const attr of ['data-testid', 'data-test', 'data-qa', 'data-cy']) {
+      const value = el.getAttribute(attr);
+      const selector = '[' + attr + '=' + quote(value) + ']';
+      if (value && unique(selector, el)) return selector;
+    }
+    if (stableID(el.id) && unique('#' + escape(el.id), el)) return '#' + escape(el.id);
+    for (const attr of ['name', 'aria-label', 'placeholder']) {
+      const value = el.getAttribute(attr);
+      const selector = tag + '[' + attr + '=' + quote(value) + ']';
+      if (value && unique(selector, el)) return selector;
+    }
+    if (['a', 'button', 'label', 'summary'].includes(tag) || el.getAttribute('role')) {
+      const text = clean(el.innerText);
+      if (text && text.length <= 50 &&
+          Array.from(document.querySelectorAll(tag)).filter((e) => clean(e.innerText) === text).length === 1) {
+        return tag + ':text-is(' + quote(text) + ')';
+      }
+    }
+    const parts = [];
+    for (let node = el; node && node.nodeType === 1 && node !== document.documentElement; node = node.parentElement) {
+      if (stableID(node.id)) {
+        parts.unshift('#' + escape(node.id));
+        break;
+      }
+      let part = node.tagName.toLowerCase();
+      const parent = node.parentElement;
+      if (parent) {
+        const siblings = Array.from(parent.children).filter((c) => c.tagName === node.tagName);
+        if (siblings.length > 1) part += ':nth-of-type(' + (siblings.indexOf(node) + 1) + ')';
+      }
+      parts.unshift(part);
+    }
+    return parts.join(' > ');
+  };
+
+  const clickable = 'a,button,input,select,textarea,label,summary,[role],[onclick],[data-testid],[data-test],[data-qa],[data-cy]';
+  const nonText = ['checkbox', 'radio', 'button', 'submit', 'reset', 'file', 'image', 'range', 'color'];
+  const isText = (el) => el instanceof HTMLTextAreaElement || el.isContentEditable ||
+    (el instanceof HTMLInputElement && !nonText.includes(el.type));
+  const keys = new Set(['Enter', 'Escape', 'Tab', 'ArrowUp', 'ArrowDown', 'ArrowLeft', 'ArrowRight']);
+
+  document.addEventListener('click', (e) => {
+    if (!e.isTrusted || !(e.target instanceof Element)) return;
+    const el = e.target.closest(clickable) || e.target;
+    // 文本框的内容通过 fill 回放, 下拉框通过 select 回放
+    if (isText(el) || el instanceof HTMLSelectElement) return;
+    send({type: 'click', selector: selectorOf(el), text: clean(el.innerText).slice(0, 80)});
+  }, true);
+
+  document.addEventListener('input', (e) => {
+    const el = e.target;
+    if (!e.isTrusted || !(el instanceof Element) || !isText(el)) return;
+    send({
+      type: 'fill',
+      selector: selectorOf(el),
+      value: el.isContentEditable ? el.innerText : el.value,
+      secret: el instanceof HTMLInputElement && el.type === 'password',
+    });
+  }, true);
+
+  document.addEventListener('change', (e) => {
+    const el = e.target;
+    if (!e.isTrusted || !(el instanceof HTMLSelectElement)) return;
+    send({type: 'select', selector: selectorOf(el), value: el.value});
+  }, true);
+
+  document.addEventListener('keydown', (e) => {
+    if (!e.isTrusted || !keys.has(e.key) || e.isComposing) return;
+    const el = e.target instanceof Element && e.target !== document.body ? e.target : null;
+    send({type: 'press', selector: el ? selectorOf(el) : '', key: e.key});
+  }, true);
+})();
+`
+
+	// interactionNavigationGrace 用户操作后这段时间内的导航视为操作引起的, 不单独记录
+	interactionNavigationGrace = 2 * time.Second
+)
+
+// RecordedAction 录制到的一次用户操作
+type RecordedAction struct {
+	Type     string `json:"type"`
+	Selector string `json:"selector"`
+	Value    string `json:"value"`
+	Key      string `json:"key"`
+	Text     string `json:"text"`
+	URL      string `json:"url"`
+	// Secret 密码框的输入, 默认不在回放步骤中保留原值
+	Secret bool      `json:"secret"`
+	Time   time.Time `json:"-"`
+}
+
+// interactionRecording 一个标签页上正在进行的操作录制
+type interactionRecording struct {
+	actions []RecordedAction
+	mux     sync.Mutex
+}
+
+func (r *interactionRecording) add(action RecordedAction) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if n := len(r.actions); n > 0 {
+		last := &r.actions[n-1]
+
+		// 连续输入同一个文本框只保留最后的值
+		if action.Type == StepFill && last.Type == StepFill && last.Selector == action.Selector {
+			*last = action
+			return
+		}
+
+		// 操作引起的导航和重复的导航不需要回放
+		if action.Type == StepNavigate && (last.URL == action.URL || action.Time.Sub(last.Time) < interactionNavigationGrace) {
+			last.URL = action.URL
+			return
+		}
+	}
+
+	if action.Type == StepNavigate {
+		action.URL = strings.TrimSpace(action.URL)
+	}
+
+	r.actions = append(r.actions, action)
+}
+
+// steps 转换为可以通过 RunSteps 回放的步骤, includeSecrets 为 false 时去掉密码框的输入
+func (r *interactionRecording) steps(includeSecrets bool) []Step {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	steps := make([]Step, 0, len(r.actions))
+	for _, action := range r.actions {
+		step := Step{Action: action.Type, Selector: action.Selector, Value: action.Value, Key: action.Key}
+
+		switch action.Type {
+		case StepNavigate:
+			step = Step{Action: StepNavigate, URL: action.URL}
+		case StepClick:
+			if action.Text != "" {
+				step.Name = "click " + action.Text
+			}
+		case StepFill:
+			if action.Secret {
+				step.Name = "fill password"
+				if !includeSecrets {
+					step.Value = ""
+					step.Secret = true
+				}
+			}
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps
+}
+
+// StartInteractionRecording 开始录制标签页上的用户操作, 只支持有界面的浏览器
+func (h *BrowserHandler) StartInteractionRecording(pageID string) (*PageHandler, error) {
+	if h.HeadlessMode() != ModeHeadful {
+		return nil, errors.ErrHeadfulRequired
+	}
+
+	page, err := h.GetTab(pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	return page, page.startInteractionRecording()
+}
+
+// StopInteractionRecording 停止录制并返回可回放的步骤, 密码框的输入只有 includeSecrets 时保留
+func (h *BrowserHandler) StopInteractionRecording(pageID string, includeSecrets bool) (*PageHandler, []Step, error) {
+	page, err := h.GetTab(pageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	steps, err := page.stopInteractionRecording(includeSecrets)
+
+	return page, steps, err
+}
+
+// IsInteractionRecording 是否正在录制用户操作
+func (h *PageHandler) IsInteractionRecording() bool {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	return h.interaction != nil
+}
+
+func (h *PageHandler) startInteractionRecording() error {
+	if err := h.checkResponsive(); err != nil {
+		return err
+	}
+
+	h.mux.Lock()
+	if h.interaction != nil {
+		h.mux.Unlock()
+		return errors.ErrInteractionRunning
+	}
+
+	recording := &interactionRecording{}
+	h.interaction = recording
+	h.mux.Unlock()
+
+	// 从录制开始时的页面回放
+	if url := h.page.URL(); url != "" && url != "about:blank" {
+		recording.add(RecordedAction{Type: StepNavigate, URL: url})
+	}
+
+	if err := h.setupInteractionTracking(); err != nil {
+		h.mux.Lock()
+		h.interaction = nil
+		h.mux.Unlock()
+
+		return err
+	}
+
+	log.Infof("Page %s interaction recording started", h.pageID)
+
+	return nil
+}
+
+func (h *PageHandler) stopInteractionRecording(includeSecrets bool) ([]Step, error) {
+	h.mux.Lock()
+	recording := h.interaction
+	h.interaction = nil
+	h.mux.Unlock()
+
+	if recording == nil {
+		return nil, errors.ErrInteractionMissing
+	}
+
+	steps := recording.steps(includeSecrets)
+	log.Infof("Page %s interaction recording stopped, %d steps", h.pageID, len(steps))
+
+	return steps, nil
+}
+
+// setupInteractionTracking 注入脚本只需要一次, 之后的导航由初始化脚本重新注入, 没有录制时丢弃上报的操作
+func (h *PageHandler) setupInteractionTracking() error {
+	if h.interactionReady.Load() {
+		return nil
+	}
+
+	err := h.page.ExposeFunction(interactionBinding, func(args ...interface{}) interface{} {
+		h.onInteraction(args)
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessage(err, "expose interaction function error")
+	}
+
+	err = h.page.AddInitScript(playwright.Script{Content: playwright.String(interactionScript)})
+	if err != nil {
+		return errors.WithMessage(err, "add interaction init script error")
+	}
+
+	h.interactionReady.Store(true)
+
+	if _, err := h.page.Evaluate(interactionScript); err != nil {
+		log.Debugf("Failed to run interaction script for page %s: %v", h.pageID, err)
+	}
+
+	return nil
+}
+
+// onInteraction 在 playwright 事件协程中执行, 只记录数据
+func (h *PageHandler) onInteraction(args []interface{}) {
+	h.mux.Lock()
+	recording := h.interaction
+	h.mux.Unlock()
+
+	if recording == nil || len(args) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(args[0])
+	if err != nil {
+		return
+	}
+
+	var action RecordedAction
+	if err := json.Unmarshal(data, &action); err != nil || action.Type == "" {
+		log.Debugf("Page %s invalid interaction: %s", h.pageID, data)
+		return
+	}
+
+	action.Time = time.Now()
+	recording.add(action)
+}
+
+func (h *PageHandler) recordNavigation(url string) {
+	h.mux.Lock()
+	recording := h.interaction
+	h.mux.Unlock()
+
+	if recording != nil {
+		recording.add(RecordedAction{Type: StepNavigate, URL: url, Time: time.Now()})
+	}
+}
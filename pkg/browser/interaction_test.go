@@ -0,0 +1,99 @@
+package browser
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInteractionRecording_Steps(t *testing.T) {
+	now := time.Now()
+	r := &interactionRecording{}
+
+	r.add(RecordedAction{Type: StepNavigate, URL: "https://example.com/login"})
+	r.add(RecordedAction{Type: StepFill, Selector: "#user", Value: "a", Time: now})
+	r.add(RecordedAction{Type: StepFill, Selector: "#user", Value: "admin", Time: now})
+	r.add(RecordedAction{Type: StepFill, Selector: "input[name=\"password\"]", Value: "secret", Secret: true, Time: now})
+	r.add(RecordedAction{Type: StepClick, Selector: "button:text-is(\"Sign in\")", Text: "Sign in", Time: now})
+	// 点击引起的导航不记录
+	r.add(RecordedAction{Type: StepNavigate, URL: "https://example.com/home", Time: now.Add(time.Second)})
+	// 重复的导航不记录
+	r.add(RecordedAction{Type: StepNavigate, URL: "https://example.com/home", Time: now.Add(5 * time.Second)})
+	// 用户在地址栏输入的导航
+	r.add(RecordedAction{Type: StepNavigate, URL: "https://example.com/settings", Time: now.Add(10 * time.Second)})
+	r.add(RecordedAction{Type: StepPress, Key: "Escape", Time: now.Add(11 * time.Second)})
+
+	steps := r.steps(false)
+	require.Len(t, steps, 6)
+
+	assert.Equal(t, Step{Action: StepNavigate, URL: "https://example.com/login"}, steps[0])
+	assert.Equal(t, Step{Action: StepFill, Selector: "#user", Value: "admin"}, steps[1])
+	// 密码默认不保留
+	assert.Equal(t, Step{Name: "fill password", Action: StepFill, Selector: "input[name=\"password\"]", Secret: true}, steps[2])
+	assert.Equal(t, "click Sign in", steps[3].Name)
+	assert.Equal(t, Step{Action: StepNavigate, URL: "https://example.com/settings"}, steps[4])
+	assert.Equal(t, Step{Action: StepPress, Key: "Escape"}, steps[5])
+
+	steps = r.steps(true)
+	assert.Equal(t, Step{Name: "fill password", Action: StepFill, Selector: "input[name=\"password\"]", Value: "secret"}, steps[2])
+}
+
+func TestGenerateCode_Secret(t *testing.T) {
+	steps := []Step{
+		{Name: "fill password", Action: StepFill, Selector: "#password", Secret: true},
+		{Name: "fill password", Action: StepFill, Selector: "#confirm", Secret: true},
+	}
+
+	code, err := GenerateCode(steps, CodegenGo)
+	require.NoError(t, err)
+
+	formatted, err := format.Source([]byte(code))
+	require.NoError(t, err, code)
+	assert.Equal(t, code, string(formatted))
+	assert.Contains(t, code, `"os"`)
+	assert.Contains(t, code, `page.Locator("#password").First().Fill(os.Getenv("BROWSERTOOLS_SECRET_1"))`)
+	assert.Contains(t, code, `page.Locator("#confirm").First().Fill(os.Getenv("BROWSERTOOLS_SECRET_2"))`)
+
+	code, err = GenerateCode(steps, CodegenTypeScript)
+	require.NoError(t, err)
+	assert.Contains(t, code, `await page.locator("#password").first().fill(process.env.BROWSERTOOLS_SECRET_1 ?? '');`)
+}
+
+func TestGenerateCode(t *testing.T) {
+	steps := []Step{
+		{Action: StepNavigate, URL: "https://example.com"},
+		{Action: StepFill, Selector: "input[name=\"q\"]", Value: "say \"hi\""},
+		{Action: StepSelect, Selector: "#lang", Value: "go"},
+		{Action: StepPress, Selector: "input[name=\"q\"]", Key: "Enter"},
+		{Action: StepWait, Selector: "#results", State: "attached"},
+		{Action: StepWait, Delay: 500 * time.Millisecond},
+		{Name: "click 100% off", Action: StepClick, Selector: "a:text-is(\"Next\")"},
+		{Action: StepScreenshot, FullPage: true},
+		{Action: StepAssert, Condition: AssertURLContains, Expected: "example.com/?q"},
+		{Action: StepAssert, Condition: AssertTextContains, Selector: "#results", Expected: "hi"},
+		{Action: StepAssert, Condition: AssertEvaluate, Script: "() => document.title !== ''"},
+	}
+
+	code, err := GenerateCode(steps, CodegenGo)
+	require.NoError(t, err)
+
+	formatted, err := format.Source([]byte(code))
+	require.NoError(t, err, code)
+	assert.Equal(t, code, string(formatted))
+	assert.Contains(t, code, `"regexp"`)
+	assert.Contains(t, code, `t.Fatalf("step 7 click 100%% off: %v", err)`)
+
+	code, err = GenerateCode(steps, CodegenTypeScript)
+	require.NoError(t, err)
+	assert.Contains(t, code, `await page.locator("input[name=\"q\"]").first().fill("say \"hi\"");`)
+	assert.Contains(t, code, `await page.waitForTimeout(500);`)
+	assert.Contains(t, code, `expect(page.url()).toContain("example.com/?q");`)
+	assert.Equal(t, 1, strings.Count(code, "test('recorded'"))
+
+	_, err = GenerateCode(steps, "python")
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// GetOpenerID 返回打开此页面的页面ID, 不是弹出页面或打开者不在列表中时为空
+func (h *PageHandler) GetOpenerID() string {
+	return h.openerID
+}
+
+// IsPopup 是否由其他页面通过 window.open 或 target=_blank 打开
+func (h *PageHandler) IsPopup() bool {
+	return h.popup
+}
+
+// GetWindowID 返回页面所在的浏览器窗口, 未知时为 0
+func (h *PageHandler) GetWindowID() int64 {
+	return h.windowID.Load()
+}
+
+// GetNavigationCount 返回主框架的导航次数
+func (h *PageHandler) GetNavigationCount() int {
+	return int(h.navigations.Load())
+}
+
+// resolveWindowID 通过 CDP 查询页面所在窗口, 不能在 playwright 事件回调中同步调用
+func (h *PageHandler) resolveWindowID() error {
+	if err := h.requireCDP(); err != nil {
+		return err
+	}
+
+	session, err := h.page.Context().NewCDPSession(h.page)
+	if err != nil {
+		return errors.WithMessage(err, "new cdp session error")
+	}
+	defer func() {
+		_ = session.Detach()
+	}()
+
+	result, err := session.Send("Browser.getWindowForTarget", nil)
+	if err != nil {
+		return errors.WithMessage(err, "get window for target error")
+	}
+
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		return errors.New("unexpected window for target result")
+	}
+
+	if id, ok := data["windowId"].(float64); ok {
+		h.windowID.Store(int64(id))
+	}
+
+	return nil
+}
+
+// findOpener 返回弹出页面的打开者ID, 打开者已关闭时ID为空
+func (h *BrowserHandler) findOpener(page playwright.Page) (string, bool) {
+	opener, err := page.Opener()
+	if err != nil || opener == nil {
+		return "", false
+	}
+
+	if openerHandler := h.pageList.FindPageHandler(opener); openerHandler != nil {
+		return openerHandler.GetPageID(), true
+	}
+
+	return "", true
+}
+
+// shouldActivate 新页面是否成为活动页面, 弹出页面按标签页策略决定, 没有活动页面时总是激活
+func (h *BrowserHandler) shouldActivate(handler *PageHandler) bool {
+	if !handler.IsPopup() || h.pageList.GetActivePage() == nil {
+		return true
+	}
+
+	policy := h.GetTabPolicy()
+
+	return policy == nil || policy.ActivatePopups
+}
+
+func (h *BrowserHandler) trackWindow(handler *PageHandler) {
+	if handler.Engine() != EngineChromium {
+		return
+	}
+
+	if err := handler.resolveWindowID(); err != nil {
+		log.Debugf("resolve window of page %s error: %v", handler.GetPageID(), err)
+	}
+}
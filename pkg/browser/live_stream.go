@@ -0,0 +1,130 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+	"sync"
+	"time"
+)
+
+// LiveStream 活动页面的实时画面流, 活动页面切换时自动切换到新页面
+type LiveStream struct {
+	handler     *BrowserHandler
+	opt         ScreencastOptions
+	interval    time.Duration
+	frames      chan *ScreencastFrame
+	switchCh    chan struct{}
+	closeCh     chan struct{}
+	closeOnce   sync.Once
+	unsubscribe func()
+	lastFrame   time.Time
+	mux         *sync.Mutex
+}
+
+// StartLiveStream 开始推送活动页面的画面, maxFPS 大于0时限制推送帧率
+func (h *BrowserHandler) StartLiveStream(opt ScreencastOptions, maxFPS int) (*LiveStream, error) {
+	if engine := h.Engine(); engine != EngineChromium {
+		return nil, errors.WithMessagef(errors.ErrUnsupported, "live stream is not supported by %s", engine)
+	}
+
+	stream := &LiveStream{
+		handler:  h,
+		opt:      opt,
+		frames:   make(chan *ScreencastFrame, 1),
+		switchCh: make(chan struct{}, 1),
+		closeCh:  make(chan struct{}),
+		mux:      &sync.Mutex{},
+	}
+
+	if maxFPS > 0 {
+		stream.interval = time.Second / time.Duration(maxFPS)
+	}
+
+	stream.unsubscribe = h.pageList.OnActiveChange(func(_ string) {
+		stream.notifySwitch()
+	})
+
+	go stream.run()
+
+	return stream, nil
+}
+
+// Frames 返回帧通道, 消费过慢时旧帧会被丢弃
+func (s *LiveStream) Frames() <-chan *ScreencastFrame {
+	return s.frames
+}
+
+// Close 停止画面流
+func (s *LiveStream) Close() {
+	s.closeOnce.Do(func() {
+		s.unsubscribe()
+		close(s.closeCh)
+	})
+}
+
+func (s *LiveStream) notifySwitch() {
+	select {
+	case s.switchCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *LiveStream) run() {
+	var current *Screencast
+
+	defer func() {
+		if current != nil {
+			current.Stop()
+		}
+	}()
+
+	// 定时检查, 防止页面关闭或浏览器断开时没有收到切换通知
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		page := s.handler.GetActiveTab()
+
+		if current != nil && (page == nil || page.GetPageID() != current.GetPageID()) {
+			current.Stop()
+			current = nil
+		}
+
+		if current == nil && page != nil {
+			sc, err := page.StartScreencast(s.opt, s.onFrame)
+			if err != nil {
+				log.Errorf("start live stream on page %s error: %v", page.GetPageID(), err)
+			} else {
+				current = sc
+			}
+		}
+
+		select {
+		case <-s.closeCh:
+			return
+		case <-s.switchCh:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *LiveStream) onFrame(frame *ScreencastFrame) {
+	s.mux.Lock()
+	if s.interval > 0 && frame.Timestamp.Sub(s.lastFrame) < s.interval {
+		s.mux.Unlock()
+		return
+	}
+	s.lastFrame = frame.Timestamp
+	s.mux.Unlock()
+
+	// 只保留最新的一帧
+	select {
+	case <-s.frames:
+	default:
+	}
+
+	select {
+	case s.frames <- frame:
+	default:
+	}
+}
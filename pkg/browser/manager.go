@@ -3,43 +3,141 @@ package browser
 import (
 	"browsertools/log"
 	"browsertools/pkg/errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/playwright-community/playwright-go"
 )
 
 type BrowserManager struct {
+	opt            Options
 	path           string
 	browserHandler *BrowserHandler
 	mutex          *sync.Mutex
+	sessions       *sessionList
+	pool           *BrowserPool
+	tabPolicy      TabPolicy
+	// 崩溃恢复: 等待恢复的标签页和会话, 以及恢复事件回调, 由 mutex 保护
+	pendingTabs       []TabSnapshot
+	pendingSessions   map[string][]TabSnapshot
+	restoredTabs      int
+	recoveryListeners map[int]func(RecoveryEvent)
+	recoveryEvents    []RecoveryEvent
+	listenerSeq       int
+	// lastLaunch 最近一次启动默认浏览器的结果, 用于健康检查
+	lastLaunch atomic.Pointer[launchResult]
+	// current 与 browserHandler 相同, launching 启动默认浏览器期间为 true, 健康检查读取时不需要等待 mutex
+	current   atomic.Pointer[BrowserHandler]
+	launching atomic.Bool
+	// driver 所有浏览器共用的 Playwright 驱动, 由 driverMux 保护
+	driver    *playwright.Playwright
+	driverMux *sync.Mutex
+	// done 关闭管理器时关闭, 停止后台任务
+	done chan struct{}
+	// eventListeners 浏览器事件回调, 由 eventMux 保护
+	eventListeners map[int]func(BrowserEvent)
+	eventSeq       int
+	eventMux       *sync.RWMutex
 }
 
 func NewBrowserManager() *BrowserManager {
-	return &BrowserManager{path: getBrowserPath(), browserHandler: nil, mutex: &sync.Mutex{}}
+	return NewBrowserManagerWithOptions(DefaultOptions())
 }
 
-func getBrowserPath() string {
-	path := "/usr/chrome-linux64/chrome"
-	_, err := os.Lstat(path)
-	if err == nil {
-		return path
+// NewBrowserManagerWithOptions 按参数创建浏览器管理器
+func NewBrowserManagerWithOptions(opt Options) *BrowserManager {
+	if opt.MaxLogs <= 0 {
+		opt.MaxLogs = defaultMaxLogs
 	}
 
-	path, err = exec.LookPath("google-chrome")
-	if err == nil {
-		return path
+	if opt.LaunchTimeout <= 0 {
+		opt.LaunchTimeout = defaultLaunchTimeout
 	}
 
-	path, err = exec.LookPath("chromium-browser")
-	if err == nil {
-		return path
+	if opt.Engine == "" {
+		opt.Engine = EngineChromium
+	}
+
+	if opt.HeadlessMode == "" {
+		opt.HeadlessMode = ModeHeadful
+	}
+
+	if opt.Recovery.MaxAttempts <= 0 {
+		opt.Recovery.MaxAttempts = defaultRecoveryMaxAttempts
+	}
+
+	if opt.Recovery.InitialBackoff <= 0 {
+		opt.Recovery.InitialBackoff = defaultRecoveryInitialBackoff
+	}
+
+	if opt.Recovery.MaxBackoff < opt.Recovery.InitialBackoff {
+		opt.Recovery.MaxBackoff = max(defaultRecoveryMaxBackoff, opt.Recovery.InitialBackoff)
+	}
+
+	if opt.Recovery.SnapshotInterval <= 0 {
+		opt.Recovery.SnapshotInterval = defaultRecoverySnapshotInterval
+	}
+
+	path := opt.Path
+	if path == "" {
+		path = getBrowserPath(opt.PathCandidates)
+	}
+
+	m := &BrowserManager{
+		opt:            opt,
+		path:           path,
+		browserHandler: nil,
+		mutex:          &sync.Mutex{},
+		sessions:       newSessionList(),
+		tabPolicy:      opt.TabPolicy,
+
+		pendingSessions:   make(map[string][]TabSnapshot),
+		recoveryListeners: make(map[int]func(RecoveryEvent)),
+
+		driverMux: &sync.Mutex{},
+		done:      make(chan struct{}),
+
+		eventListeners: make(map[int]func(BrowserEvent)),
+		eventMux:       &sync.RWMutex{},
+	}
+
+	m.sessions.idleTimeout = opt.SessionIdleTimeout
+
+	go m.reapIdleSessions()
+	go m.snapshotLoop()
+
+	return m
+}
+
+// getBrowserPath 返回第一个存在的候选路径
+func getBrowserPath(candidates []string) string {
+	for _, candidate := range candidates {
+		if strings.ContainsRune(candidate, os.PathSeparator) {
+			if _, err := os.Lstat(candidate); err == nil {
+				return candidate
+			}
+
+			continue
+		}
+
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path
+		}
 	}
 
 	return ""
 }
 
+// GetBrowserPath 返回使用的浏览器可执行文件路径
+func (m *BrowserManager) GetBrowserPath() string {
+	return m.path
+}
+
 func (m *BrowserManager) IsInstalled() bool {
 	return m.path != ""
 }
@@ -52,59 +150,160 @@ func (m *BrowserManager) GetOrCreateBrowser() (*BrowserHandler, error) {
 		return m.browserHandler, nil
 	}
 
+	return m.createLocked()
+}
+
+// createLocked 创建默认浏览器并恢复崩溃前的标签页, 需要持有 m.mutex 调用
+func (m *BrowserManager) createLocked() (*BrowserHandler, error) {
+	if m.isClosed() {
+		return nil, errManagerClosed
+	}
+
+	m.launching.Store(true)
 	b, err := m.create()
+	m.recordLaunch(err)
+	m.launching.Store(false)
 	if err != nil {
 		return nil, errors.WithMessage(err, "could not create browser")
 	}
 
-	m.browserHandler = b
+	b.SetTabPolicy(m.tabPolicy)
+	m.supervise(b, "")
+	m.restorePendingLocked(b)
+	m.setBrowserLocked(b)
 
 	return b, nil
 }
 
+// setBrowserLocked 替换默认浏览器, 需要持有 m.mutex 调用
+func (m *BrowserManager) setBrowserLocked(b *BrowserHandler) {
+	m.browserHandler = b
+	m.current.Store(b)
+}
+
+// SetTabPolicy 设置标签页策略, 对默认浏览器及之后创建的会话和池中浏览器生效
+func (m *BrowserManager) SetTabPolicy(policy TabPolicy) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.tabPolicy = policy
+
+	if m.browserHandler != nil && !m.browserHandler.IsClosed() {
+		m.browserHandler.SetTabPolicy(policy)
+	}
+}
+
 func (m *BrowserManager) create() (*BrowserHandler, error) {
-	if !m.IsInstalled() {
-		return nil, errors.BrowserNotInstalled
+	// 只有 Chromium 支持通过CDP连接已有的浏览器
+	if m.opt.Engine == EngineChromium && m.opt.CDPEndpoint != "" {
+		handler, err := m.connect()
+		if err == nil {
+			log.Infof("connected to browser %s successfully!", m.opt.CDPEndpoint)
+			return handler, nil
+		}
+
+		log.Infof("could not connect to Chromium: %v. we will start chrome server", err)
 	}
 
-	pw, err := playwright.Run()
+	return m.launchBrowser(m.opt.Engine, m.opt.HeadlessMode)
+}
+
+func (m *BrowserManager) connect() (*BrowserHandler, error) {
+	pw, err := m.getDriver()
 	if err != nil {
-		return nil, errors.WithMessage(err, "could not run playwright")
+		return nil, err
 	}
 
-	browser, err := pw.Chromium.ConnectOverCDP("http://localhost:29229")
+	browser, err := pw.Chromium.ConnectOverCDP(m.opt.CDPEndpoint)
 	if err != nil {
-		log.Infof("could not connect to Chromium: %v. we will start chrome server", err)
+		return nil, err
+	}
 
-		opt := playwright.BrowserTypeLaunchOptions{
-			ExecutablePath: &m.path,
-			Args: []string{
-				// 窗口管理
-				// 启动时最大化窗口
-				"--start-maximized",
-				"--process-per-site", "--disable-field-trial-config", "--disable-background-networking",
-				"--enable-features=NetworkService,NetworkServiceInProcess", "--disable-background-timer-throttling", "--disable-backgrounding-occluded-windows",
-				"--disable-back-forward-cache", "--disable-breakpad", "--disable-client-side-phishing-detection", "--disable-component-extensions-with-background-pages",
-				"--disable-component-update", "--no-default-browser-check", "--disable-default-apps", "--disable-dev-shm-usage",
-				"--disable-features=ImprovedCookieControls,LazyFrameLoading,GlobalMediaControls,DestroyProfileOnBrowserClose,MediaRouter,DialMediaRouteProvider,AcceptCHFrame,AutoExpandDetailsElement,CertificateTransparencyComponentUpdater,AvoidUnnecessaryBeforeUnloadCheckSync,Translate,HttpsUpgrades,PaintHolding",
-				"--allow-pre-commit-input", "--disable-hang-monitor", "--disable-ipc-flooding-protection", "--disable-popup-blocking", "--disable-prompt-on-repost",
-				"--disable-renderer-backgrounding", "--force-color-profile=srgb", "--metrics-recording-only", "--no-first-run", "--enable-automation", "--disable-infobars",
-				"--password-store=basic", "--use-mock-keychain", "--no-service-autorun", "--export-tagged-pdf", "--disable-search-engine-choice-screen", "--mute-audio",
-				"--blink-settings=primaryHoverType=2,availableHoverTypes=2,primaryPointerType=4,availablePointerTypes=4", "--no-sandbox", "--disable-blink-features=AutomationControlled",
-				"--use-angle=swiftshader-webgl", "--noerrdialogs", "--disable-gpu",
-				//"--remote-debugging-port=29229",
-			},
-			Timeout:  playwright.Float(30000), // 设置超时时间（毫秒）
-			Headless: playwright.Bool(false),  // 禁用无头模式
-		}
+	return attachBrowserHandler(browser, m.opt.HeadlessMode, m.opt.MaxLogs)
+}
+
+// launch 启动一个新的浏览器进程, 不尝试连接已有的CDP端口, 供浏览器池使用
+func (m *BrowserManager) launch() (*BrowserHandler, error) {
+	return m.launchWithPolicy(m.opt.Engine, m.opt.HeadlessMode)
+}
+
+// launchWithPolicy 启动新的浏览器进程并应用标签页策略
+func (m *BrowserManager) launchWithPolicy(engine string, mode string) (*BrowserHandler, error) {
+	handler, err := m.launchBrowser(engine, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	policy := m.tabPolicy
+	m.mutex.Unlock()
+
+	handler.SetTabPolicy(policy)
+
+	return handler, nil
+}
+
+func (m *BrowserManager) launchBrowser(engine string, mode string) (handler *BrowserHandler, err error) {
+	if err := ValidateEngine(engine, mode); err != nil {
+		return nil, err
+	}
 
-		browser, err = pw.Chromium.Launch(opt)
-		if err != nil {
-			return nil, errors.WithMessage(err, "could not launch browser")
+	start := time.Now()
+	defer func() {
+		metricLaunchTotal.WithLabelValues(engine, resultLabel(err)).Inc()
+		if err == nil {
+			metricLaunchDurations.WithLabelValues(engine).Observe(float64(time.Since(start) / time.Millisecond))
 		}
+	}()
+
+	// Firefox 和 WebKit 使用 playwright 安装的浏览器, 不依赖本机的 Chrome
+	if engine == EngineChromium && !m.IsInstalled() {
+		return nil, errors.BrowserNotInstalled
+	}
+
+	pw, err := m.getDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	browser, err := browserType(pw, engine).Launch(m.launchOptions(engine, mode))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "could not launch %s browser", engine)
+	}
+
+	log.Infof("launched %s browser in %s mode successfully!", engine, mode)
+
+	return newOwnedBrowserHandler(browser, mode, m.opt.MaxLogs)
+}
+
+func (m *BrowserManager) launchOptions(engine string, mode string) playwright.BrowserTypeLaunchOptions {
+	opt := playwright.BrowserTypeLaunchOptions{
+		Timeout:  playwright.Float(float64(m.opt.LaunchTimeout.Milliseconds())),
+		Headless: playwright.Bool(mode == ModeHeadless),
+	}
+
+	// 预设参数都是 Chrome 的命令行参数, 其他引擎只使用自定义参数
+	if engine != EngineChromium {
+		opt.Args = append([]string{}, m.opt.Args...)
+		return opt
+	}
+
+	args, ok := PresetArgs(m.opt.Preset)
+	if !ok {
+		args, _ = PresetArgs(PresetDefault)
+	}
+
+	if mode == ModeNewHeadless {
+		args = append(args, "--headless=new")
+	}
+
+	// 无头模式没有窗口可以最大化, 指定窗口大小
+	if mode != ModeHeadful {
+		args = append(args, fmt.Sprintf("--window-size=%d,%d", headlessWindowWidth, headlessWindowHeight))
 	}
 
-	log.Infof("launched browser successfully!")
+	opt.ExecutablePath = &m.path
+	opt.Args = append(args, m.opt.Args...)
 
-	return NewBrowserHandler(browser)
+	return opt
 }
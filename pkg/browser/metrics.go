@@ -0,0 +1,145 @@
+package browser
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const browserNamespace = "browser"
+
+var (
+	metricRecoveryTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "recovery",
+		Name:        "total",
+		Help:        "browser and tab crash recovery count.",
+		ConstLabels: map[string]string{},
+	}, []string{"type", "result"})
+
+	metricWatchdogTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "watchdog",
+		Name:        "total",
+		Help:        "hung and crashed page count and actions taken by the watchdog.",
+		ConstLabels: map[string]string{},
+	}, []string{"event"})
+
+	metricRecoveryDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "recovery",
+		Name:        "duration_ms",
+		Help:        "browser crash recovery duration(ms).",
+		ConstLabels: map[string]string{},
+		Buckets:     []float64{100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000, 120000},
+	}, []string{"type"})
+
+	metricLaunchTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "launch",
+		Name:        "total",
+		Help:        "browser launch count by engine and result.",
+		ConstLabels: map[string]string{},
+	}, []string{"engine", "result"})
+
+	metricLaunchDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "launch",
+		Name:        "duration_ms",
+		Help:        "browser launch duration(ms).",
+		ConstLabels: map[string]string{},
+		Buckets:     []float64{100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000},
+	}, []string{"engine"})
+
+	metricDisconnectTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "connection",
+		Name:        "disconnect_total",
+		Help:        "unexpected browser disconnect count.",
+		ConstLabels: map[string]string{},
+	}, []string{"engine"})
+
+	metricConsoleMessageTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "page",
+		Name:        "console_message_total",
+		Help:        "page console message count by level.",
+		ConstLabels: map[string]string{},
+	}, []string{"level"})
+
+	metricPageCrashTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "page",
+		Name:        "crash_total",
+		Help:        "page renderer crash count.",
+		ConstLabels: map[string]string{},
+	})
+
+	metricScreenshotDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "screenshot",
+		Name:        "duration_ms",
+		Help:        "page screenshot duration(ms).",
+		ConstLabels: map[string]string{},
+		Buckets:     []float64{25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000},
+	}, []string{"result"})
+
+	metricScreenshotSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "screenshot",
+		Name:        "size_bytes",
+		Help:        "page screenshot size(bytes).",
+		ConstLabels: map[string]string{},
+		Buckets:     prometheus.ExponentialBuckets(16*1024, 2, 10),
+	})
+
+	metricNavigationDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "navigation",
+		Name:        "duration_ms",
+		Help:        "page navigation duration(ms).",
+		ConstLabels: map[string]string{},
+		Buckets:     []float64{100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000},
+	}, []string{"result"})
+
+	_ = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   browserNamespace,
+		Subsystem:   "tabs",
+		Name:        "open",
+		Help:        "open tabs across all browsers.",
+		ConstLabels: map[string]string{},
+	}, countOpenTabs)
+)
+
+// liveHandlers 所有未关闭的浏览器, 统计打开的标签页数量, 关闭的浏览器在统计时移除
+var liveHandlers sync.Map
+
+func trackHandler(h *BrowserHandler) {
+	liveHandlers.Store(h, struct{}{})
+}
+
+func countOpenTabs() float64 {
+	count := 0
+
+	liveHandlers.Range(func(key, _ any) bool {
+		h := key.(*BrowserHandler)
+		if h.IsClosed() {
+			liveHandlers.Delete(h)
+			return true
+		}
+
+		count += h.GetPageCount()
+		return true
+	})
+
+	return float64(count)
+}
+
+func resultLabel(err error) string {
+	if err != nil {
+		return "fail"
+	}
+
+	return "success"
+}
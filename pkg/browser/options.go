@@ -0,0 +1,114 @@
+package browser
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// PresetDefault 默认启动参数
+	PresetDefault = "default"
+	// PresetLowMemory 限制渲染进程数量和缓存, 适合内存较小的容器
+	PresetLowMemory = "low-memory"
+	// PresetDebug 开启远程调试端口和详细日志, 方便排查问题
+	PresetDebug = "debug"
+
+	defaultCDPEndpoint   = "http://localhost:29229"
+	defaultLaunchTimeout = 30 * time.Second
+	defaultMaxLogs       = 1000
+)
+
+var defaultPathCandidates = []string{"/usr/chrome-linux64/chrome", "google-chrome", "chromium-browser"}
+
+var defaultArgs = []string{
+	// 窗口管理
+	// 启动时最大化窗口
+	"--start-maximized",
+	"--process-per-site", "--disable-field-trial-config", "--disable-background-networking",
+	"--enable-features=NetworkService,NetworkServiceInProcess", "--disable-background-timer-throttling", "--disable-backgrounding-occluded-windows",
+	"--disable-back-forward-cache", "--disable-breakpad", "--disable-client-side-phishing-detection", "--disable-component-extensions-with-background-pages",
+	"--disable-component-update", "--no-default-browser-check", "--disable-default-apps", "--disable-dev-shm-usage",
+	"--disable-features=ImprovedCookieControls,LazyFrameLoading,GlobalMediaControls,DestroyProfileOnBrowserClose,MediaRouter,DialMediaRouteProvider,AcceptCHFrame,AutoExpandDetailsElement,CertificateTransparencyComponentUpdater,AvoidUnnecessaryBeforeUnloadCheckSync,Translate,HttpsUpgrades,PaintHolding",
+	"--allow-pre-commit-input", "--disable-hang-monitor", "--disable-ipc-flooding-protection", "--disable-popup-blocking", "--disable-prompt-on-repost",
+	"--disable-renderer-backgrounding", "--force-color-profile=srgb", "--metrics-recording-only", "--no-first-run", "--enable-automation", "--disable-infobars",
+	"--password-store=basic", "--use-mock-keychain", "--no-service-autorun", "--export-tagged-pdf", "--disable-search-engine-choice-screen", "--mute-audio",
+	"--blink-settings=primaryHoverType=2,availableHoverTypes=2,primaryPointerType=4,availablePointerTypes=4", "--no-sandbox", "--disable-blink-features=AutomationControlled",
+	"--use-angle=swiftshader-webgl", "--noerrdialogs", "--disable-gpu",
+}
+
+// launchPresets 预置的启动参数组合
+var launchPresets = map[string][]string{
+	PresetDefault: defaultArgs,
+	PresetLowMemory: append(append([]string{}, defaultArgs...),
+		"--renderer-process-limit=2", "--js-flags=--max-old-space-size=512", "--disable-extensions",
+		"--disk-cache-size=1048576", "--media-cache-size=1048576", "--aggressive-cache-discard",
+		"--disable-site-isolation-trials", "--disable-software-rasterizer",
+	),
+	PresetDebug: append(append([]string{}, defaultArgs...),
+		"--remote-debugging-port=29229", "--enable-logging=stderr", "--v=1",
+	),
+}
+
+// Options 浏览器管理参数
+type Options struct {
+	// Path 浏览器可执行文件路径, 为空时依次查找 PathCandidates
+	Path string
+	// PathCandidates 候选路径, 不含路径分隔符时从 PATH 中查找
+	PathCandidates []string
+	// CDPEndpoint 优先连接的已有浏览器地址, 为空时直接启动新浏览器, 连接的浏览器关闭时只断开连接
+	CDPEndpoint string
+	// Preset 启动参数预设
+	Preset string
+	// Args 追加在预设之后的启动参数
+	Args          []string
+	LaunchTimeout time.Duration
+	// Engine 浏览器引擎: chromium, firefox 或 webkit
+	Engine string
+	// HeadlessMode 无头模式: headful, headless 或 new-headless
+	HeadlessMode string
+	// MaxLogs 每个页面保留的控制台日志条数
+	MaxLogs            int
+	SessionIdleTimeout time.Duration
+	TabPolicy          TabPolicy
+	Pool               PoolOptions
+	Recovery           RecoveryOptions
+}
+
+// DefaultOptions 默认参数
+func DefaultOptions() Options {
+	return Options{
+		PathCandidates:     append([]string{}, defaultPathCandidates...),
+		CDPEndpoint:        defaultCDPEndpoint,
+		Preset:             PresetDefault,
+		LaunchTimeout:      defaultLaunchTimeout,
+		Engine:             EngineChromium,
+		HeadlessMode:       ModeHeadful,
+		MaxLogs:            defaultMaxLogs,
+		SessionIdleTimeout: defaultSessionIdleTimeout,
+		TabPolicy:          DefaultTabPolicy(),
+		Pool:               DefaultPoolOptions(),
+		Recovery:           DefaultRecoveryOptions(),
+	}
+}
+
+// PresetArgs 返回预设对应的启动参数
+func PresetArgs(name string) ([]string, bool) {
+	args, ok := launchPresets[name]
+	if !ok {
+		return nil, false
+	}
+
+	return append([]string{}, args...), true
+}
+
+// PresetNames 返回所有预设名称
+func PresetNames() []string {
+	names := make([]string, 0, len(launchPresets))
+	for name := range launchPresets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -4,47 +4,82 @@ import (
 	"browsertools/log"
 	"context"
 	"fmt"
-	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/playwright-community/playwright-go"
 )
 
-// 常量定义
-const (
-	// 页面可见性监听脚本
-	visibilityScript = `
-(() => {
-  document.addEventListener('visibilitychange', () => {
-    window.onVisibilityChange(document.visibilityState === 'visible');
-  });
-})();
-`
-)
+// ScreenshotOptions 截图参数
+type ScreenshotOptions struct {
+	FullPage bool
+	// MaskSelectors 匹配的元素会被纯色遮盖, 用于屏蔽时间、广告等动态内容
+	MaskSelectors []string
+	// Timeout 为空时使用 Playwright 的默认超时
+	Timeout time.Duration
+}
 
 type PageListener interface {
 	OnClosePage(pageID string)
 	OnActivePage(pageID string)
+	OnCrashPage(pageID string)
+	OnPageEvent(event BrowserEvent)
 }
 
 type PageHandler struct {
-	page         playwright.Page
-	pageID       string
-	createTime   time.Time
-	consoleLogs  []string
+	page        playwright.Page
+	pageID      string
+	createTime  time.Time
+	consoleLogs []string
+	// maxLogs 保留的控制台日志条数
+	maxLogs      int
 	mux          *sync.Mutex
 	isClosed     bool
 	pageListener PageListener
+	// lastUsed 最近一次被使用的时间(UnixMilli), 用于空闲回收和LRU淘汰
+	lastUsed atomic.Int64
+	// pinned 固定的页面不会被标签页策略关闭
+	pinned atomic.Bool
+	// state 看门狗检测到的页面状态
+	state         atomic.Value
+	probing       atomic.Bool
+	probeFailures atomic.Int32
+	// measuring 正在查询 JS 堆内存, 上一次查询未返回时不再重复查询
+	measuring atomic.Bool
+	// openerID 打开此页面的页面, popup 为 true 时表示由 window.open 或 target=_blank 打开
+	openerID string
+	popup    bool
+	// windowID 所在浏览器窗口, 仅 Chromium 可以获取
+	windowID    atomic.Int64
+	navigations atomic.Int32
+	// evaluating 超时后仍在执行的步骤脚本, 结束时关闭, 由 mux 保护
+	evaluating chan struct{}
+	// interaction 正在进行的操作录制, 由 mux 保护
+	interaction      *interactionRecording
+	interactionReady atomic.Bool
+	// activity 控制台和网络事件的订阅者
+	activity     activityListeners
+	networkReady atomic.Bool
 }
 
 func NewPageHandler(page playwright.Page, pageListener PageListener) *PageHandler {
-	id := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return newPageHandler(page, pageListener, defaultMaxLogs)
+}
+
+// newPageHandler 创建页面并注册事件, 保留最近 maxLogs 条控制台日志
+func newPageHandler(page playwright.Page, pageListener PageListener, maxLogs int) *PageHandler {
+	if maxLogs <= 0 {
+		maxLogs = defaultMaxLogs
+	}
+
+	id := newID()
 
 	handler := &PageHandler{
 		page:         page,
 		pageID:       id,
 		createTime:   time.Now(),
+		maxLogs:      maxLogs,
 		consoleLogs:  make([]string, 0, maxLogs),
 		mux:          &sync.Mutex{},
 		isClosed:     false,
@@ -55,14 +90,20 @@ func NewPageHandler(page playwright.Page, pageListener PageListener) *PageHandle
 	page.On("console", handler.onConsoleMessage)
 	page.On("close", handler.onClose)
 	page.On("bringtofront", handler.onBringToFront)
+	page.On("crash", handler.onCrash)
+	page.On("framenavigated", handler.onFrameNavigated)
+	page.On("download", handler.onDownload)
 
-	// 启动可见性监听
-	go handler.setupVisibilityTracking()
+	// 启动活动页面监听
+	go handler.setupActiveTracking()
 
 	return handler
 }
 
 func (h *PageHandler) onConsoleMessage(msg playwright.ConsoleMessage) {
+	metricConsoleMessageTotal.WithLabelValues(msg.Type()).Inc()
+	h.onConsoleActivity(msg)
+
 	h.mux.Lock()
 	defer h.mux.Unlock()
 
@@ -71,7 +112,7 @@ func (h *PageHandler) onConsoleMessage(msg playwright.ConsoleMessage) {
 		return
 	}
 
-	if len(h.consoleLogs) >= maxLogs {
+	if len(h.consoleLogs) >= h.maxLogs {
 		// 移除最旧的日志
 		h.consoleLogs = h.consoleLogs[1:]
 	}
@@ -79,6 +120,45 @@ func (h *PageHandler) onConsoleMessage(msg playwright.ConsoleMessage) {
 	h.consoleLogs = append(h.consoleLogs, msg.Text())
 }
 
+// onFrameNavigated 只统计主框架的导航次数
+func (h *PageHandler) onFrameNavigated(frame playwright.Frame) {
+	if frame == h.page.MainFrame() {
+		h.navigations.Add(1)
+		h.recordNavigation(frame.URL())
+	}
+}
+
+// onDownload 下载在后台完成后通知, 等待下载是同步调用, 不能在事件回调中执行
+func (h *PageHandler) onDownload(download playwright.Download) {
+	go func() {
+		event := BrowserEvent{
+			Type: EventDownloadFinished,
+			URL:  download.URL(),
+			Data: map[string]interface{}{"filename": download.SuggestedFilename()},
+		}
+
+		path, err := download.Path()
+		if err != nil {
+			event.Message = err.Error()
+			event.Data["success"] = false
+		} else {
+			event.Data["success"] = true
+			event.Data["path"] = path
+		}
+
+		log.Infof("Page %s download %s finished, error: %v", h.pageID, download.SuggestedFilename(), err)
+		h.notify(event)
+	}()
+}
+
+func (h *PageHandler) notify(event BrowserEvent) {
+	event.PageID = h.pageID
+
+	if h.pageListener != nil {
+		h.pageListener.OnPageEvent(event)
+	}
+}
+
 func (h *PageHandler) onBringToFront() {
 	log.Infof("Page %s brought to front", h.pageID)
 
@@ -87,6 +167,15 @@ func (h *PageHandler) onBringToFront() {
 	}
 }
 
+func (h *PageHandler) onCrash() {
+	log.Errorf("Page %s renderer crashed", h.pageID)
+	metricPageCrashTotal.Inc()
+
+	if h.pageListener != nil {
+		h.pageListener.OnCrashPage(h.pageID)
+	}
+}
+
 func (h *PageHandler) onClose() {
 	log.Infof("Page %s close event received", h.pageID)
 
@@ -101,8 +190,12 @@ func (h *PageHandler) onClose() {
 }
 
 func (h *PageHandler) Goto(ctx context.Context, url string) error {
+	start := time.Now()
 	_, err := h.page.Goto(url)
+	metricNavigationDurations.WithLabelValues(resultLabel(err)).Observe(float64(time.Since(start) / time.Millisecond))
+
 	if err != nil {
+		h.notify(BrowserEvent{Type: EventNavigationFailed, URL: url, Message: err.Error()})
 		return fmt.Errorf("page %s navigation to %s failed: %w", h.pageID, url, err)
 	}
 	return nil
@@ -126,23 +219,64 @@ func (h *PageHandler) Close() {
 }
 
 func (h *PageHandler) Screenshot() ([]byte, error) {
+	return h.ScreenshotWithOptions(ScreenshotOptions{FullPage: true})
+}
+
+func (h *PageHandler) ScreenshotWithOptions(opt ScreenshotOptions) ([]byte, error) {
 	if h.IsClosed() {
 		return nil, fmt.Errorf("page %s is closed, cannot take screenshot", h.pageID)
 	}
 
-	data, err := h.page.Screenshot(playwright.PageScreenshotOptions{
-		FullPage: playwright.Bool(true),
-		Type:     playwright.ScreenshotTypePng,
-	})
+	if err := h.checkResponsive(); err != nil {
+		return nil, err
+	}
+
+	masks := make([]playwright.Locator, 0, len(opt.MaskSelectors))
+	for _, selector := range opt.MaskSelectors {
+		masks = append(masks, h.page.Locator(selector))
+	}
+
+	options := playwright.PageScreenshotOptions{
+		FullPage:   playwright.Bool(opt.FullPage),
+		Type:       playwright.ScreenshotTypePng,
+		Mask:       masks,
+		Animations: playwright.ScreenshotAnimationsDisabled,
+	}
+	if opt.Timeout > 0 {
+		options.Timeout = playwright.Float(float64(opt.Timeout / time.Millisecond))
+	}
+
+	start := time.Now()
+	data, err := h.page.Screenshot(options)
+	metricScreenshotDurations.WithLabelValues(resultLabel(err)).Observe(float64(time.Since(start) / time.Millisecond))
 
 	if err != nil {
 		return nil, fmt.Errorf("screenshot failed for page %s: %w", h.pageID, err)
 	}
 
+	metricScreenshotSize.Observe(float64(len(data)))
 	log.Debugf("Screenshot successful for page %s, size: %d bytes", h.pageID, len(data))
 	return data, nil
 }
 
+// Content 返回页面当前的HTML和地址
+func (h *PageHandler) Content() (string, string, error) {
+	if h.IsClosed() {
+		return "", "", fmt.Errorf("page %s is closed, cannot get content", h.pageID)
+	}
+
+	if err := h.checkResponsive(); err != nil {
+		return "", "", err
+	}
+
+	content, err := h.page.Content()
+	if err != nil {
+		return "", "", fmt.Errorf("get content failed for page %s: %w", h.pageID, err)
+	}
+
+	return content, h.page.URL(), nil
+}
+
 func (h *PageHandler) GetLogs() []string {
 	h.mux.Lock()
 	defer h.mux.Unlock()
@@ -167,35 +301,31 @@ func (h *PageHandler) GetCreateTime() time.Time {
 	return h.createTime
 }
 
-func (h *PageHandler) IsClosed() bool {
-	h.mux.Lock()
-	defer h.mux.Unlock()
-	return h.isClosed
+// Touch 更新最近使用时间
+func (h *PageHandler) Touch() {
+	h.lastUsed.Store(time.Now().UnixMilli())
 }
 
-// setupVisibilityTracking 设置页面可见性追踪功能
-func (h *PageHandler) setupVisibilityTracking() {
-	err := h.page.ExposeFunction("onVisibilityChange", func(args ...interface{}) interface{} {
-		isVisible := args[0].(bool)
-		log.Infof("Page %s visibility changed: %v", h.pageID, isVisible)
-
-		if isVisible && h.pageListener != nil {
-			h.pageListener.OnActivePage(h.pageID)
-		}
+// GetLastUsed 返回最近使用时间, 从未使用过时为创建时间
+func (h *PageHandler) GetLastUsed() time.Time {
+	if ms := h.lastUsed.Load(); ms > 0 {
+		return time.UnixMilli(ms)
+	}
 
-		return nil
-	})
+	return h.createTime
+}
 
-	if err != nil {
-		log.Errorf("Failed to expose visibility change function for page %s: %v", h.pageID, err)
-		return
-	}
+// SetPinned 固定或取消固定页面
+func (h *PageHandler) SetPinned(pinned bool) {
+	h.pinned.Store(pinned)
+}
 
-	_, err = h.page.AddScriptTag(playwright.PageAddScriptTagOptions{
-		Content: playwright.String(visibilityScript),
-	})
+func (h *PageHandler) IsPinned() bool {
+	return h.pinned.Load()
+}
 
-	if err != nil {
-		log.Errorf("Failed to add visibility tracking script for page %s: %v", h.pageID, err)
-	}
+func (h *PageHandler) IsClosed() bool {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	return h.isClosed
 }
@@ -3,23 +3,60 @@ package browser
 import (
 	"browsertools/log"
 	"github.com/playwright-community/playwright-go"
+	"sort"
 	"sync"
 	"time"
 )
 
 // PageList 管理多个页面的结构体
 type PageList struct {
-	pages      map[string]*PageHandler
-	mux        *sync.Mutex
-	activePage string
+	pages           map[string]*PageHandler
+	mux             *sync.Mutex
+	activePage      string
+	activeListeners map[int]func(pageID string)
+	listenerSeq     int
 }
 
 // NewPageList 创建一个新的PageList实例
 func NewPageList() *PageList {
 	return &PageList{
-		pages:      make(map[string]*PageHandler),
-		mux:        &sync.Mutex{},
-		activePage: "",
+		pages:           make(map[string]*PageHandler),
+		mux:             &sync.Mutex{},
+		activePage:      "",
+		activeListeners: make(map[int]func(pageID string)),
+	}
+}
+
+// OnActiveChange 注册活动页面变化的回调, 返回取消注册的函数
+func (p *PageList) OnActiveChange(fn func(pageID string)) func() {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.listenerSeq++
+	seq := p.listenerSeq
+	p.activeListeners[seq] = fn
+
+	return func() {
+		p.mux.Lock()
+		defer p.mux.Unlock()
+
+		delete(p.activeListeners, seq)
+	}
+}
+
+// getActiveListenersWithoutLock 复制回调列表, 回调需在释放锁之后执行
+func (p *PageList) getActiveListenersWithoutLock() []func(pageID string) {
+	listeners := make([]func(pageID string), 0, len(p.activeListeners))
+	for _, fn := range p.activeListeners {
+		listeners = append(listeners, fn)
+	}
+
+	return listeners
+}
+
+func notifyActiveChange(listeners []func(pageID string), pageID string) {
+	for _, fn := range listeners {
+		fn(pageID)
 	}
 }
 
@@ -43,6 +80,23 @@ func (p *PageList) AddPage(pageHandler *PageHandler) {
 	p.pages[pageHandler.GetPageID()] = pageHandler
 }
 
+// addIfAbsent 页面不在列表中时创建并添加, 返回的 bool 表示是否新建
+func (p *PageList) addIfAbsent(page playwright.Page, create func() *PageHandler) (*PageHandler, bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	for _, pageHandler := range p.pages {
+		if pageHandler.GetPage() == page {
+			return pageHandler, false
+		}
+	}
+
+	pageHandler := create()
+	p.pages[pageHandler.GetPageID()] = pageHandler
+
+	return pageHandler, true
+}
+
 // RemovePage 从列表中移除页面
 func (p *PageList) RemovePage(pageID string) {
 	if pageID == "" {
@@ -50,19 +104,32 @@ func (p *PageList) RemovePage(pageID string) {
 	}
 
 	p.mux.Lock()
-	defer p.mux.Unlock()
 
+	removed := p.pages[pageID]
 	delete(p.pages, pageID)
 
+	var listeners []func(pageID string)
+
 	if p.activePage == pageID {
-		p.activePage = p.getNextActivePageIDWithoutLock(pageID)
+		p.activePage = p.getNextActivePageIDWithoutLock(removed)
+		listeners = p.getActiveListenersWithoutLock()
 	}
 
-	log.Infof("current active page id: %s", p.activePage)
+	activePage := p.activePage
+	p.mux.Unlock()
+
+	log.Infof("current active page id: %s", activePage)
+
+	notifyActiveChange(listeners, activePage)
 }
 
-func (p *PageList) getNextActivePageIDWithoutLock(removedPageID string) string {
-	// TODO: 目前只取得新的
+// getNextActivePageIDWithoutLock 弹出页面关闭后回到打开它的页面, 否则使用最新创建的页面
+func (p *PageList) getNextActivePageIDWithoutLock(removed *PageHandler) string {
+	if removed != nil {
+		if _, exists := p.pages[removed.GetOpenerID()]; exists {
+			return removed.GetOpenerID()
+		}
+	}
 
 	nextPageID := ""
 	var createTime time.Time
@@ -70,6 +137,7 @@ func (p *PageList) getNextActivePageIDWithoutLock(removedPageID string) string {
 	for id, page := range p.pages {
 		if page.GetCreateTime().After(createTime) {
 			nextPageID = id
+			createTime = page.GetCreateTime()
 		}
 	}
 
@@ -83,14 +151,24 @@ func (p *PageList) SetActivePage(pageID string) bool {
 	}
 
 	p.mux.Lock()
-	defer p.mux.Unlock()
 
-	if _, exists := p.pages[pageID]; exists {
-		p.activePage = pageID
-		return true
+	if _, exists := p.pages[pageID]; !exists {
+		p.mux.Unlock()
+		return false
+	}
+
+	var listeners []func(pageID string)
+	if p.activePage != pageID {
+		listeners = p.getActiveListenersWithoutLock()
 	}
 
-	return false
+	p.activePage = pageID
+	p.pages[pageID].Touch()
+	p.mux.Unlock()
+
+	notifyActiveChange(listeners, pageID)
+
+	return true
 }
 
 func (p *PageList) CloseAll() {
@@ -104,6 +182,52 @@ func (p *PageList) CloseAll() {
 	p.pages = make(map[string]*PageHandler)
 }
 
+// Count 返回页面数量
+func (p *PageList) Count() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	return len(p.pages)
+}
+
+// GetPages 按创建时间返回所有页面
+func (p *PageList) GetPages() []*PageHandler {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	pages := make([]*PageHandler, 0, len(p.pages))
+	for _, page := range p.pages {
+		pages = append(pages, page)
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].GetCreateTime().Before(pages[j].GetCreateTime())
+	})
+
+	return pages
+}
+
+// GetEvictablePages 按最近使用时间从旧到新返回可以被关闭的页面, 不包括固定页面和活动页面
+func (p *PageList) GetEvictablePages() []*PageHandler {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	pages := make([]*PageHandler, 0, len(p.pages))
+	for id, page := range p.pages {
+		if id == p.activePage || page.IsPinned() {
+			continue
+		}
+
+		pages = append(pages, page)
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].GetLastUsed().Before(pages[j].GetLastUsed())
+	})
+
+	return pages
+}
+
 // GetPageByID 通过ID获取页面
 func (p *PageList) GetPageByID(pageID string) *PageHandler {
 	p.mux.Lock()
@@ -0,0 +1,86 @@
+package browser
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestPageHandler(id string) *PageHandler {
+	return &PageHandler{pageID: id, createTime: time.Now(), mux: &sync.Mutex{}}
+}
+
+func TestPageList_OnActiveChange(t *testing.T) {
+	list := NewPageList()
+	list.AddPage(newTestPageHandler("1"))
+	list.AddPage(newTestPageHandler("2"))
+
+	var changes []string
+	unsubscribe := list.OnActiveChange(func(pageID string) {
+		changes = append(changes, pageID)
+	})
+
+	assert.True(t, list.SetActivePage("1"))
+	assert.True(t, list.SetActivePage("1"))
+	assert.False(t, list.SetActivePage("3"))
+	assert.True(t, list.SetActivePage("2"))
+	assert.Equal(t, []string{"1", "2"}, changes)
+
+	list.RemovePage("2")
+	assert.Equal(t, []string{"1", "2", "1"}, changes)
+	assert.Equal(t, "1", list.GetActivePage().GetPageID())
+
+	unsubscribe()
+	list.RemovePage("1")
+	assert.Len(t, changes, 3)
+	assert.Nil(t, list.GetActivePage())
+}
+
+func TestPageList_GetEvictablePages(t *testing.T) {
+	list := NewPageList()
+
+	now := time.Now()
+	for i, id := range []string{"1", "2", "3", "4"} {
+		page := newTestPageHandler(id)
+		page.lastUsed.Store(now.Add(time.Duration(i) * time.Second).UnixMilli())
+		list.AddPage(page)
+	}
+
+	list.GetPageByID("3").lastUsed.Store(now.Add(-time.Minute).UnixMilli())
+	list.GetPageByID("2").SetPinned(true)
+	list.activePage = "4"
+
+	ids := make([]string, 0)
+	for _, page := range list.GetEvictablePages() {
+		ids = append(ids, page.GetPageID())
+	}
+
+	assert.Equal(t, []string{"3", "1"}, ids)
+}
+
+func TestPageList_RemovePopupActivatesOpener(t *testing.T) {
+	list := NewPageList()
+
+	now := time.Now()
+	opener := newTestPageHandler("opener")
+	opener.createTime = now
+	other := newTestPageHandler("other")
+	other.createTime = now.Add(time.Second)
+	popup := newTestPageHandler("popup")
+	popup.createTime = now.Add(2 * time.Second)
+	popup.openerID = "opener"
+	popup.popup = true
+
+	list.AddPage(opener)
+	list.AddPage(other)
+	list.AddPage(popup)
+
+	assert.True(t, list.SetActivePage("popup"))
+	list.RemovePage("popup")
+	assert.Equal(t, "opener", list.GetActivePage().GetPageID())
+
+	list.RemovePage("opener")
+	assert.Equal(t, "other", list.GetActivePage().GetPageID())
+}
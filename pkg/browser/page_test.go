@@ -0,0 +1,55 @@
+package browser
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConsoleMessage 只有类型和文本的控制台消息
+type fakeConsoleMessage struct {
+	playwright.ConsoleMessage
+	text string
+}
+
+func (m *fakeConsoleMessage) Type() string {
+	return "log"
+}
+
+func (m *fakeConsoleMessage) Text() string {
+	return m.text
+}
+
+func (m *fakeConsoleMessage) Location() *playwright.ConsoleMessageLocation {
+	return nil
+}
+
+func TestPageHandler_MaxLogs(t *testing.T) {
+	// 每个管理器使用各自的日志条数, 互不影响
+	small := NewBrowserManagerWithOptions(Options{MaxLogs: 2})
+	other := NewBrowserManagerWithOptions(Options{})
+	assert.Equal(t, 2, small.opt.MaxLogs)
+	assert.Equal(t, defaultMaxLogs, other.opt.MaxLogs)
+
+	var wg sync.WaitGroup
+	pages := make([]*PageHandler, 0, 2)
+	for _, limit := range []int{2, 3} {
+		page := &PageHandler{pageID: fmt.Sprintf("limit-%d", limit), maxLogs: limit, mux: &sync.Mutex{}}
+		pages = append(pages, page)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 5 {
+				page.onConsoleMessage(&fakeConsoleMessage{text: fmt.Sprintf("log %d", i)})
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"log 3", "log 4"}, pages[0].GetLogs())
+	assert.Equal(t, []string{"log 2", "log 3", "log 4"}, pages[1].GetLogs())
+}
@@ -0,0 +1,474 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// leaseIDPrefix 租约ID前缀, 租约ID可以直接作为 session_id 使用
+	leaseIDPrefix = "lease-"
+
+	defaultPoolMaxSize             = 4
+	defaultPoolMaxUses             = 50
+	defaultPoolMaxAge              = 30 * time.Minute
+	defaultPoolMaxLeaseDuration    = 30 * time.Minute
+	defaultPoolHealthCheckInterval = 30 * time.Second
+)
+
+// PoolOptions 浏览器池参数
+type PoolOptions struct {
+	// MinSize 预热并常驻的浏览器数量
+	MinSize int
+	// MaxSize 浏览器数量上限, 达到上限后租用需要等待归还
+	MaxSize int
+	// MaxUses 浏览器被租用多少次后回收重建, 0 表示不限制
+	MaxUses int
+	// MaxAge 浏览器启动多久后回收重建, 0 表示不限制
+	MaxAge time.Duration
+	// MaxLeaseDuration 租约最长持有时间, 超时未归还时强制回收, 0 表示不限制
+	MaxLeaseDuration time.Duration
+	// HealthCheckInterval 健康检查间隔
+	HealthCheckInterval time.Duration
+}
+
+// DefaultPoolOptions 默认浏览器池参数
+func DefaultPoolOptions() PoolOptions {
+	return PoolOptions{
+		MinSize:             0,
+		MaxSize:             defaultPoolMaxSize,
+		MaxUses:             defaultPoolMaxUses,
+		MaxAge:              defaultPoolMaxAge,
+		MaxLeaseDuration:    defaultPoolMaxLeaseDuration,
+		HealthCheckInterval: defaultPoolHealthCheckInterval,
+	}
+}
+
+// Lease 浏览器租约, 持有期间浏览器为调用方独占
+type Lease struct {
+	ID        string
+	BrowserID string
+	LeaseTime time.Time
+	handler   *BrowserHandler
+}
+
+// PoolStatus 浏览器池状态
+type PoolStatus struct {
+	MinSize  int
+	MaxSize  int
+	Size     int
+	Idle     int
+	Leased   int
+	Starting int
+}
+
+type pooledBrowser struct {
+	id         string
+	handler    *BrowserHandler
+	createTime time.Time
+	uses       int
+	lease      *Lease
+	// leaseID 当前的租约ID, 空闲时为空, 在事件回调中读取
+	leaseID atomic.Value
+}
+
+func (b *pooledBrowser) currentLeaseID() string {
+	id, _ := b.leaseID.Load().(string)
+	return id
+}
+
+// expired 是否达到回收条件
+func (b *pooledBrowser) expired(opt PoolOptions) bool {
+	if opt.MaxUses > 0 && b.uses >= opt.MaxUses {
+		return true
+	}
+
+	return opt.MaxAge > 0 && time.Since(b.createTime) > opt.MaxAge
+}
+
+// BrowserPool 维护多个独立的浏览器进程, 调用方通过租用/归还独占使用
+type BrowserPool struct {
+	opt    PoolOptions
+	launch func() (*BrowserHandler, error)
+	// observe 转发新启动的浏览器的事件, 为空时不转发
+	observe  func(b *BrowserHandler, sessionID func() string)
+	idle     []*pooledBrowser
+	leases   map[string]*pooledBrowser
+	starting int
+	// available 有浏览器归还或释放名额时关闭并替换, 用于唤醒等待中的租用请求
+	available chan struct{}
+	closed    bool
+	done      chan struct{}
+	mux       *sync.Mutex
+}
+
+func newBrowserPool(opt PoolOptions, launch func() (*BrowserHandler, error), observe func(*BrowserHandler, func() string)) *BrowserPool {
+	if opt.MaxSize <= 0 {
+		opt.MaxSize = defaultPoolMaxSize
+	}
+
+	opt.MinSize = min(max(opt.MinSize, 0), opt.MaxSize)
+
+	if opt.HealthCheckInterval <= 0 {
+		opt.HealthCheckInterval = defaultPoolHealthCheckInterval
+	}
+
+	p := &BrowserPool{
+		opt:       opt,
+		launch:    launch,
+		observe:   observe,
+		idle:      make([]*pooledBrowser, 0),
+		leases:    make(map[string]*pooledBrowser),
+		available: make(chan struct{}),
+		done:      make(chan struct{}),
+		mux:       &sync.Mutex{},
+	}
+
+	go p.healthCheck()
+
+	return p
+}
+
+// EnablePool 开启浏览器池模式并预热 MinSize 个浏览器, 重复调用时返回已有的池
+func (m *BrowserManager) EnablePool(opt PoolOptions) *BrowserPool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.pool == nil {
+		m.pool = newBrowserPool(opt, m.launch, m.observeFunc)
+		go m.pool.warmUp()
+	}
+
+	return m.pool
+}
+
+// GetPool 返回浏览器池, 未开启时按配置的参数开启
+func (m *BrowserManager) GetPool() *BrowserPool {
+	m.mutex.Lock()
+	pool := m.pool
+	m.mutex.Unlock()
+
+	if pool != nil {
+		return pool
+	}
+
+	return m.EnablePool(m.opt.Pool)
+}
+
+// Lease 从池中租用一个浏览器, 没有空闲浏览器且已达上限时最多等待 timeout
+func (p *BrowserPool) Lease(ctx context.Context, timeout time.Duration) (*Lease, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		p.mux.Lock()
+
+		if p.closed {
+			p.mux.Unlock()
+			return nil, errors.New("browser pool is closed")
+		}
+
+		if b := p.popIdleWithoutLock(); b != nil {
+			lease := p.leaseWithoutLock(b)
+			p.mux.Unlock()
+
+			return lease, nil
+		}
+
+		if p.sizeWithoutLock() < p.opt.MaxSize {
+			p.starting++
+			p.mux.Unlock()
+
+			b, err := p.start()
+
+			p.mux.Lock()
+			p.starting--
+			if err != nil || p.closed {
+				p.notifyWithoutLock()
+				p.mux.Unlock()
+
+				if err != nil {
+					return nil, err
+				}
+
+				b.handler.Close()
+				return nil, errors.New("browser pool is closed")
+			}
+
+			lease := p.leaseWithoutLock(b)
+			p.mux.Unlock()
+
+			return lease, nil
+		}
+
+		available := p.available
+		p.mux.Unlock()
+
+		select {
+		case <-available:
+		case <-ctx.Done():
+			return nil, errors.ErrPoolExhausted
+		}
+	}
+}
+
+// Return 归还租用的浏览器, 达到回收条件或状态异常的浏览器会被关闭
+func (p *BrowserPool) Return(leaseID string) error {
+	p.mux.Lock()
+	b, exists := p.leases[leaseID]
+	delete(p.leases, leaseID)
+	p.mux.Unlock()
+
+	if !exists {
+		return errors.ErrLeaseNotFound
+	}
+
+	log.Infof("browser %s returned, lease %s", b.id, leaseID)
+
+	p.release(b)
+
+	return nil
+}
+
+// GetLease 根据租约ID获取浏览器
+func (p *BrowserPool) GetLease(leaseID string) (*BrowserHandler, error) {
+	p.mux.Lock()
+	b, exists := p.leases[leaseID]
+	p.mux.Unlock()
+
+	if !exists {
+		return nil, errors.ErrLeaseNotFound
+	}
+
+	if !b.handler.isHealthy() {
+		return nil, errors.WithMessage(errors.ErrLeaseNotFound, "leased browser is disconnected")
+	}
+
+	return b.handler, nil
+}
+
+// Status 返回浏览器池当前状态
+func (p *BrowserPool) Status() PoolStatus {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	return PoolStatus{
+		MinSize:  p.opt.MinSize,
+		MaxSize:  p.opt.MaxSize,
+		Size:     p.sizeWithoutLock(),
+		Idle:     len(p.idle),
+		Leased:   len(p.leases),
+		Starting: p.starting,
+	}
+}
+
+// Close 关闭池中所有浏览器, 包括尚未归还的
+func (p *BrowserPool) Close() {
+	p.mux.Lock()
+	if p.closed {
+		p.mux.Unlock()
+		return
+	}
+
+	p.closed = true
+	close(p.done)
+
+	browsers := p.idle
+	for _, b := range p.leases {
+		browsers = append(browsers, b)
+	}
+
+	p.idle = nil
+	p.leases = make(map[string]*pooledBrowser)
+	p.notifyWithoutLock()
+	p.mux.Unlock()
+
+	for _, b := range browsers {
+		b.handler.Close()
+	}
+}
+
+// release 重置浏览器后放回空闲队列, 无法复用时关闭
+func (p *BrowserPool) release(b *pooledBrowser) {
+	b.lease = nil
+	b.leaseID.Store("")
+
+	reusable := b.handler.isHealthy() && !b.expired(p.opt)
+	if reusable {
+		if err := b.handler.reset(); err != nil {
+			log.Errorf("reset browser %s error: %v", b.id, err)
+			reusable = false
+		}
+	}
+
+	p.mux.Lock()
+	reusable = reusable && !p.closed
+	if reusable {
+		p.idle = append(p.idle, b)
+	}
+	p.notifyWithoutLock()
+	p.mux.Unlock()
+
+	if !reusable {
+		log.Infof("recycle browser %s, uses %d, age %v", b.id, b.uses, time.Since(b.createTime).Round(time.Second))
+		b.handler.Close()
+	}
+}
+
+func (p *BrowserPool) start() (*pooledBrowser, error) {
+	handler, err := p.launch()
+	if err != nil {
+		return nil, errors.WithMessage(err, "launch pooled browser error")
+	}
+
+	b := &pooledBrowser{id: newID(), handler: handler, createTime: time.Now()}
+	if p.observe != nil {
+		p.observe(handler, b.currentLeaseID)
+	}
+	log.Infof("pooled browser %s started", b.id)
+
+	return b, nil
+}
+
+// warmUp 启动浏览器直到数量达到 MinSize
+func (p *BrowserPool) warmUp() {
+	for {
+		p.mux.Lock()
+		if p.closed || p.sizeWithoutLock() >= p.opt.MinSize {
+			p.mux.Unlock()
+			return
+		}
+		p.starting++
+		p.mux.Unlock()
+
+		b, err := p.start()
+
+		p.mux.Lock()
+		p.starting--
+		if err == nil && !p.closed {
+			p.idle = append(p.idle, b)
+		}
+		p.notifyWithoutLock()
+		closed := p.closed
+		p.mux.Unlock()
+
+		if err != nil {
+			log.Errorf("warm up browser pool error: %v", err)
+			return
+		}
+
+		if closed {
+			b.handler.Close()
+			return
+		}
+	}
+}
+
+// healthCheck 定期移除断开或过期的空闲浏览器, 回收超时未归还的租约, 并补足 MinSize
+func (p *BrowserPool) healthCheck() {
+	ticker := time.NewTicker(p.opt.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+
+		removed := make([]*pooledBrowser, 0)
+		overdue := make([]*pooledBrowser, 0)
+
+		p.mux.Lock()
+		idle := make([]*pooledBrowser, 0, len(p.idle))
+		for _, b := range p.idle {
+			if b.handler.isHealthy() && !b.expired(p.opt) {
+				idle = append(idle, b)
+			} else {
+				removed = append(removed, b)
+			}
+		}
+		p.idle = idle
+
+		for id, b := range p.leases {
+			if !b.handler.isHealthy() {
+				delete(p.leases, id)
+				removed = append(removed, b)
+			} else if p.opt.MaxLeaseDuration > 0 && time.Since(b.lease.LeaseTime) > p.opt.MaxLeaseDuration {
+				delete(p.leases, id)
+				overdue = append(overdue, b)
+			}
+		}
+
+		if len(removed) > 0 {
+			p.notifyWithoutLock()
+		}
+		p.mux.Unlock()
+
+		for _, b := range removed {
+			log.Infof("remove unhealthy or expired browser %s", b.id)
+			b.handler.Close()
+		}
+
+		for _, b := range overdue {
+			log.Infof("lease %s of browser %s exceeded %v, reclaiming", b.lease.ID, b.id, p.opt.MaxLeaseDuration)
+			p.release(b)
+		}
+
+		p.warmUp()
+	}
+}
+
+func (p *BrowserPool) popIdleWithoutLock() *pooledBrowser {
+	for len(p.idle) > 0 {
+		b := p.idle[0]
+		p.idle = p.idle[1:]
+
+		if b.handler.isHealthy() {
+			return b
+		}
+
+		log.Infof("drop disconnected browser %s", b.id)
+		go b.handler.Close()
+	}
+
+	return nil
+}
+
+func (p *BrowserPool) leaseWithoutLock(b *pooledBrowser) *Lease {
+	b.uses++
+	b.lease = &Lease{
+		ID:        leaseIDPrefix + newID(),
+		BrowserID: b.id,
+		LeaseTime: time.Now(),
+		handler:   b.handler,
+	}
+
+	b.leaseID.Store(b.lease.ID)
+	p.leases[b.lease.ID] = b
+	log.Infof("browser %s leased, lease %s, uses %d", b.id, b.lease.ID, b.uses)
+
+	return b.lease
+}
+
+func (p *BrowserPool) sizeWithoutLock() int {
+	return len(p.idle) + len(p.leases) + p.starting
+}
+
+func (p *BrowserPool) notifyWithoutLock() {
+	close(p.available)
+	p.available = make(chan struct{})
+}
+
+// isLeaseID 判断 session_id 是否为租约ID
+func isLeaseID(id string) bool {
+	return strings.HasPrefix(id, leaseIDPrefix)
+}
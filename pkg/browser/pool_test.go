@@ -0,0 +1,43 @@
+package browser
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrowserPool_LeaseEvents(t *testing.T) {
+	m := NewBrowserManagerWithOptions(Options{})
+	defer m.Close()
+
+	events := make(chan BrowserEvent, 4)
+	m.OnEvent(func(event BrowserEvent) { events <- event })
+
+	var handler *BrowserHandler
+	launch := func() (*BrowserHandler, error) {
+		handler = newFakeBrowserHandler(&fakeBrowser{}, &fakeContext{})
+		return handler, nil
+	}
+
+	pool := newBrowserPool(PoolOptions{MaxSize: 1, HealthCheckInterval: time.Hour}, launch, m.observeFunc)
+	defer pool.Close()
+
+	lease, err := pool.Lease(context.Background(), time.Second)
+	require.NoError(t, err)
+
+	// 租用期间的事件带有租约ID
+	handler.emit(BrowserEvent{Type: EventTabOpened, PageID: "page-1"})
+	event := <-events
+	assert.Equal(t, EventTabOpened, event.Type)
+	assert.Equal(t, lease.ID, event.SessionID)
+
+	require.NoError(t, pool.Return(lease.ID))
+
+	handler.emit(BrowserEvent{Type: EventPageCrashed, PageID: "page-1"})
+	event = <-events
+	assert.Equal(t, EventPageCrashed, event.Type)
+	assert.Empty(t, event.SessionID)
+}
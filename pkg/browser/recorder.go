@@ -0,0 +1,449 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/avi"
+	"browsertools/pkg/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRecordFPS         = 5
+	maxRecordFPS             = 25
+	defaultRecordMaxDuration = 10 * time.Minute
+	maxRecordDuration        = 60 * time.Minute
+	defaultRecordMaxBytes    = 200 << 20
+	maxRecordBytes           = 1 << 30
+)
+
+// 录制状态
+const (
+	RecordingRunning = "recording"
+	RecordingStopped = "stopped"
+	RecordingLimited = "limit_reached"
+	RecordingFailed  = "failed"
+)
+
+// RecordingOptions 录制参数, 零值使用默认值, 超过上限的值会被截断
+type RecordingOptions struct {
+	FPS         int
+	Quality     int
+	MaxWidth    int
+	MaxHeight   int
+	MaxDuration time.Duration
+	MaxBytes    int64
+}
+
+// Recording 一次录制的信息
+type Recording struct {
+	ID        string
+	PageID    string
+	Status    string
+	Path      string
+	StartTime time.Time
+	EndTime   time.Time
+	Frames    int
+	Size      int64
+	Error     string
+}
+
+// Duration 返回录制时长, 录制中则返回已录制的时长
+func (r Recording) Duration() time.Duration {
+	if r.EndTime.IsZero() {
+		return time.Since(r.StartTime)
+	}
+
+	return r.EndTime.Sub(r.StartTime)
+}
+
+type recordTask struct {
+	info       Recording
+	opt        RecordingOptions
+	page       *PageHandler
+	file       *os.File
+	writer     *avi.Writer
+	screencast *Screencast
+	latest     []byte
+	stopOnce   sync.Once
+	stopCh     chan struct{}
+	done       chan struct{}
+	mux        *sync.Mutex
+}
+
+// RecordingRetention 已结束录制的保留策略, 零值表示不限制
+type RecordingRetention struct {
+	// MaxAge 结束超过该时间的录制被删除, 也用于清理之前运行遗留的文件
+	MaxAge time.Duration
+	// MaxCount 已结束的录制超过该数量时删除最早的
+	MaxCount int
+}
+
+// VideoRecorder 通过CDP截屏流录制页面视频, 在服务端编码为 MJPEG AVI 文件
+type VideoRecorder struct {
+	dir       string
+	retention RecordingRetention
+	tasks     map[string]*recordTask
+	mux       *sync.Mutex
+}
+
+// NewVideoRecorder 创建录制器, 录制文件保存在 dir 目录下
+func NewVideoRecorder(dir string) *VideoRecorder {
+	return &VideoRecorder{
+		dir:   dir,
+		tasks: make(map[string]*recordTask),
+		mux:   &sync.Mutex{},
+	}
+}
+
+// SetRetention 设置保留策略, 在每次开始录制时清理
+func (r *VideoRecorder) SetRetention(retention RecordingRetention) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.retention = retention
+}
+
+func normalizeRecordingOptions(opt RecordingOptions) RecordingOptions {
+	if opt.FPS <= 0 {
+		opt.FPS = defaultRecordFPS
+	}
+	if opt.FPS > maxRecordFPS {
+		opt.FPS = maxRecordFPS
+	}
+	if opt.MaxDuration <= 0 {
+		opt.MaxDuration = defaultRecordMaxDuration
+	}
+	if opt.MaxDuration > maxRecordDuration {
+		opt.MaxDuration = maxRecordDuration
+	}
+	if opt.MaxBytes <= 0 {
+		opt.MaxBytes = defaultRecordMaxBytes
+	}
+	if opt.MaxBytes > maxRecordBytes {
+		opt.MaxBytes = maxRecordBytes
+	}
+
+	return opt
+}
+
+// Start 开始录制页面, 同一页面同时只能有一个录制
+func (r *VideoRecorder) Start(page *PageHandler, opt RecordingOptions) (Recording, error) {
+	opt = normalizeRecordingOptions(opt)
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	for _, task := range r.tasks {
+		if task.page == page && task.getInfo().Status == RecordingRunning {
+			return Recording{}, errors.ErrRecordingRunning
+		}
+	}
+
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return Recording{}, errors.WithMessage(err, "create recording dir error")
+	}
+
+	r.pruneWithoutLock()
+
+	id := newID()
+	path := filepath.Join(r.dir, id+".avi")
+
+	file, err := os.Create(path)
+	if err != nil {
+		return Recording{}, errors.WithMessage(err, "create recording file error")
+	}
+
+	writer, err := avi.NewWriter(file, opt.FPS)
+	if err != nil {
+		errors.Ignore(file.Close())
+		return Recording{}, err
+	}
+
+	task := &recordTask{
+		info: Recording{
+			ID:        id,
+			PageID:    page.GetPageID(),
+			Status:    RecordingRunning,
+			Path:      path,
+			StartTime: time.Now(),
+		},
+		opt:    opt,
+		page:   page,
+		file:   file,
+		writer: writer,
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+		mux:    &sync.Mutex{},
+	}
+
+	screencastOpt := ScreencastOptions{Quality: opt.Quality, MaxWidth: opt.MaxWidth, MaxHeight: opt.MaxHeight}
+
+	task.screencast, err = page.StartScreencast(screencastOpt, task.onFrame)
+	if err != nil {
+		errors.Ignore(file.Close())
+		errors.Ignore(os.Remove(path))
+		return Recording{}, err
+	}
+
+	r.tasks[id] = task
+
+	go task.run()
+
+	log.Infof("recording %s started on page %s", id, page.GetPageID())
+
+	return task.getInfo(), nil
+}
+
+// Stop 停止录制并等待文件写入完成
+func (r *VideoRecorder) Stop(id string) (Recording, error) {
+	task := r.getTask(id)
+	if task == nil {
+		return Recording{}, errors.ErrRecordingNotFound
+	}
+
+	task.stop()
+	<-task.done
+
+	return task.getInfo(), nil
+}
+
+// Delete 删除录制和文件, 正在进行的录制先停止
+func (r *VideoRecorder) Delete(id string) error {
+	task := r.getTask(id)
+	if task == nil {
+		return errors.ErrRecordingNotFound
+	}
+
+	task.stop()
+	<-task.done
+
+	r.mux.Lock()
+	delete(r.tasks, id)
+	r.mux.Unlock()
+
+	return removeRecordingFile(task.info.Path)
+}
+
+// StopAll 停止所有正在进行的录制
+func (r *VideoRecorder) StopAll() {
+	r.mux.Lock()
+	tasks := make([]*recordTask, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		tasks = append(tasks, task)
+	}
+	r.mux.Unlock()
+
+	for _, task := range tasks {
+		task.stop()
+		<-task.done
+	}
+}
+
+// Get 获取录制信息
+func (r *VideoRecorder) Get(id string) (Recording, error) {
+	task := r.getTask(id)
+	if task == nil {
+		return Recording{}, errors.ErrRecordingNotFound
+	}
+
+	return task.getInfo(), nil
+}
+
+// List 按开始时间返回所有录制
+func (r *VideoRecorder) List() []Recording {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	list := make([]Recording, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		list = append(list, task.getInfo())
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartTime.Before(list[j].StartTime)
+	})
+
+	return list
+}
+
+// pruneWithoutLock 按保留策略删除已结束的录制和目录中不属于任何录制的过期文件
+func (r *VideoRecorder) pruneWithoutLock() {
+	finished := make([]Recording, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		if info := task.getInfo(); info.Status != RecordingRunning {
+			finished = append(finished, info)
+		}
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].StartTime.Before(finished[j].StartTime)
+	})
+
+	for i, info := range finished {
+		expired := r.retention.MaxAge > 0 && time.Since(info.EndTime) > r.retention.MaxAge
+		excess := r.retention.MaxCount > 0 && len(finished)-i > r.retention.MaxCount
+		if !expired && !excess {
+			continue
+		}
+
+		delete(r.tasks, info.ID)
+		if err := removeRecordingFile(info.Path); err != nil {
+			log.Warnf("remove recording %s error: %v", info.ID, err)
+		}
+	}
+
+	if r.retention.MaxAge <= 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".avi")
+		if !ok || entry.IsDir() || r.tasks[id] != nil {
+			continue
+		}
+
+		fileInfo, err := entry.Info()
+		if err != nil || time.Since(fileInfo.ModTime()) <= r.retention.MaxAge {
+			continue
+		}
+
+		if err := removeRecordingFile(filepath.Join(r.dir, entry.Name())); err != nil {
+			log.Warnf("remove recording file %s error: %v", entry.Name(), err)
+		}
+	}
+}
+
+func removeRecordingFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.WithMessage(err, "remove recording file error")
+	}
+
+	return nil
+}
+
+func (r *VideoRecorder) getTask(id string) *recordTask {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.tasks[id]
+}
+
+func (t *recordTask) onFrame(frame *ScreencastFrame) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.latest = frame.Data
+}
+
+func (t *recordTask) getInfo() Recording {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	return t.info
+}
+
+func (t *recordTask) stop() {
+	t.stopOnce.Do(func() {
+		close(t.stopCh)
+	})
+}
+
+// run 按固定帧率写入最新的一帧, 截屏流只在页面变化时推帧, 空闲时重复上一帧以保证时间轴正确
+func (t *recordTask) run() {
+	ticker := time.NewTicker(time.Second / time.Duration(t.opt.FPS))
+	defer ticker.Stop()
+
+	status := RecordingStopped
+	var runErr error
+
+loop:
+	for {
+		select {
+		case <-t.stopCh:
+			break loop
+		case <-ticker.C:
+			if t.page.IsClosed() {
+				break loop
+			}
+
+			// 页面一直没有画面时也要在达到时长上限后结束
+			if time.Since(t.info.StartTime) >= t.opt.MaxDuration {
+				status = RecordingLimited
+				break loop
+			}
+
+			t.mux.Lock()
+			frame := t.latest
+			t.mux.Unlock()
+
+			if frame == nil {
+				continue
+			}
+
+			// 写入前检查, 保证文件不会超过大小上限
+			if t.writer.Size()+int64(len(frame)) > t.opt.MaxBytes {
+				status = RecordingLimited
+				break loop
+			}
+
+			if err := t.writer.WriteFrame(frame); err != nil {
+				status = RecordingFailed
+				runErr = err
+				break loop
+			}
+
+			t.mux.Lock()
+			t.info.Frames = t.writer.Frames()
+			t.info.Size = t.writer.Size()
+			t.mux.Unlock()
+		}
+	}
+
+	t.finish(status, runErr)
+}
+
+func (t *recordTask) finish(status string, runErr error) {
+	t.screencast.Stop()
+
+	if err := t.writer.Close(); err != nil && runErr == nil {
+		status = RecordingFailed
+		runErr = err
+	}
+
+	if err := t.file.Close(); err != nil && runErr == nil {
+		status = RecordingFailed
+		runErr = err
+	}
+
+	fileInfo, err := os.Stat(t.info.Path)
+
+	t.mux.Lock()
+	t.info.Status = status
+	t.info.EndTime = time.Now()
+	if err == nil {
+		t.info.Size = fileInfo.Size()
+	}
+	if runErr != nil {
+		t.info.Error = runErr.Error()
+	}
+	info := t.info
+	t.mux.Unlock()
+
+	if runErr != nil {
+		log.Errorf("recording %s failed: %v", info.ID, runErr)
+	} else {
+		log.Infof("recording %s finished: %s, %d frames", info.ID, status, info.Frames)
+	}
+
+	close(t.done)
+}
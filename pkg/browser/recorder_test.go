@@ -0,0 +1,154 @@
+package browser
+
+import (
+	"browsertools/pkg/avi"
+	"browsertools/pkg/errors"
+	"bytes"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeRecordTask 不连接截屏流的录制, 写入 dir 下的文件
+func newFakeRecordTask(t *testing.T, dir string, id string, opt RecordingOptions) *recordTask {
+	h := newFakeBrowserHandler(&fakeBrowser{}, &fakeContext{}, &fakePage{})
+	page := h.pageList.GetPageByID("page-1")
+
+	path := filepath.Join(dir, id+".avi")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	opt = normalizeRecordingOptions(opt)
+	writer, err := avi.NewWriter(file, opt.FPS)
+	require.NoError(t, err)
+
+	return &recordTask{
+		info:       Recording{ID: id, PageID: page.GetPageID(), Status: RecordingRunning, Path: path, StartTime: time.Now()},
+		opt:        opt,
+		page:       page,
+		file:       file,
+		writer:     writer,
+		screencast: &Screencast{pageID: page.GetPageID(), session: &fakeCDPSession{}},
+		stopCh:     make(chan struct{}),
+		done:       make(chan struct{}),
+		mux:        &sync.Mutex{},
+	}
+}
+
+func TestRecordTask_MaxDurationWithoutFrames(t *testing.T) {
+	task := newFakeRecordTask(t, t.TempDir(), "idle", RecordingOptions{FPS: maxRecordFPS, MaxDuration: 100 * time.Millisecond})
+
+	// 页面没有推送任何画面
+	go task.run()
+
+	select {
+	case <-task.done:
+	case <-time.After(5 * time.Second):
+		task.stop()
+		t.Fatal("recording without frames did not stop at max duration")
+	}
+
+	info := task.getInfo()
+	assert.Equal(t, RecordingLimited, info.Status)
+	assert.Zero(t, info.Frames)
+}
+
+func TestRecordTask_MaxBytes(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, jpeg.Encode(buf, image.NewRGBA(image.Rect(0, 0, 16, 16)), nil))
+	frame := buf.Bytes()
+
+	task := newFakeRecordTask(t, t.TempDir(), "limited", RecordingOptions{FPS: maxRecordFPS})
+	task.latest = frame
+	task.opt.MaxBytes = task.writer.Size() + int64(len(frame))*5/2
+
+	go task.run()
+
+	select {
+	case <-task.done:
+	case <-time.After(5 * time.Second):
+		task.stop()
+		t.Fatal("recording did not stop at max bytes")
+	}
+
+	// 写入下一帧会超过上限时在写入前结束
+	info := task.getInfo()
+	assert.Equal(t, RecordingLimited, info.Status)
+	assert.Equal(t, 2, info.Frames)
+	assert.LessOrEqual(t, task.writer.Size(), task.opt.MaxBytes)
+}
+
+func TestVideoRecorder_Retention(t *testing.T) {
+	dir := t.TempDir()
+	r := NewVideoRecorder(dir)
+	r.SetRetention(RecordingRetention{MaxAge: time.Hour, MaxCount: 2})
+
+	finished := func(id string, end time.Time) *recordTask {
+		task := newFakeRecordTask(t, dir, id, RecordingOptions{})
+		task.stop()
+		task.finish(RecordingStopped, nil)
+		task.info.StartTime = end.Add(-time.Minute)
+		task.info.EndTime = end
+		r.tasks[id] = task
+		return task
+	}
+
+	now := time.Now()
+	expired := finished("expired", now.Add(-2*time.Hour))
+	oldest := finished("oldest", now.Add(-3*time.Minute))
+	finished("older", now.Add(-2*time.Minute))
+	finished("newest", now.Add(-time.Minute))
+
+	running := newFakeRecordTask(t, dir, "running", RecordingOptions{})
+	r.tasks["running"] = running
+	defer func() {
+		running.stop()
+		running.finish(RecordingStopped, nil)
+	}()
+
+	// 之前运行遗留的文件只按时间清理
+	staleFile := filepath.Join(dir, "stale.avi")
+	freshFile := filepath.Join(dir, "fresh.avi")
+	require.NoError(t, os.WriteFile(staleFile, nil, 0o644))
+	require.NoError(t, os.WriteFile(freshFile, nil, 0o644))
+	require.NoError(t, os.Chtimes(staleFile, now.Add(-2*time.Hour), now.Add(-2*time.Hour)))
+
+	r.mux.Lock()
+	r.pruneWithoutLock()
+	r.mux.Unlock()
+
+	ids := make([]string, 0)
+	for _, recording := range r.List() {
+		ids = append(ids, recording.ID)
+	}
+	assert.ElementsMatch(t, []string{"older", "newest", "running"}, ids)
+
+	assert.NoFileExists(t, expired.info.Path)
+	assert.NoFileExists(t, oldest.info.Path)
+	assert.NoFileExists(t, staleFile)
+	assert.FileExists(t, freshFile)
+	assert.FileExists(t, running.info.Path)
+}
+
+func TestVideoRecorder_Delete(t *testing.T) {
+	dir := t.TempDir()
+	r := NewVideoRecorder(dir)
+
+	// 正在进行的录制先停止再删除
+	task := newFakeRecordTask(t, dir, "running", RecordingOptions{})
+	r.tasks["running"] = task
+	go task.run()
+
+	require.NoError(t, r.Delete("running"))
+	assert.NoFileExists(t, task.info.Path)
+	assert.Empty(t, r.List())
+
+	assert.True(t, errors.Is(r.Delete("running"), errors.ErrRecordingNotFound))
+}
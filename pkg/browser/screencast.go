@@ -0,0 +1,119 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+	"encoding/base64"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// ScreencastOptions CDP Page.startScreencast 的参数
+type ScreencastOptions struct {
+	Quality       int // jpeg 质量 0-100
+	MaxWidth      int
+	MaxHeight     int
+	EveryNthFrame int
+}
+
+// ScreencastFrame 截屏流中的一帧
+type ScreencastFrame struct {
+	PageID    string
+	Data      []byte // jpeg 数据
+	Timestamp time.Time
+}
+
+// Screencast 基于CDP的页面截屏流, 只在页面内容变化时推送帧
+type Screencast struct {
+	pageID  string
+	session playwright.CDPSession
+	onFrame func(*ScreencastFrame)
+	once    sync.Once
+}
+
+// StartScreencast 在页面上开启截屏流, 每收到一帧调用一次 onFrame
+func (h *PageHandler) StartScreencast(opt ScreencastOptions, onFrame func(*ScreencastFrame)) (*Screencast, error) {
+	if h.IsClosed() {
+		return nil, errors.Errorf("page %s is closed, cannot start screencast", h.pageID)
+	}
+
+	if err := h.requireCDP(); err != nil {
+		return nil, err
+	}
+
+	session, err := h.page.Context().NewCDPSession(h.page)
+	if err != nil {
+		return nil, errors.WithMessage(err, "new cdp session error")
+	}
+
+	sc := &Screencast{pageID: h.pageID, session: session, onFrame: onFrame}
+	session.On("Page.screencastFrame", sc.handleFrame)
+
+	params := map[string]interface{}{"format": "jpeg"}
+	if opt.Quality > 0 {
+		params["quality"] = opt.Quality
+	}
+	if opt.MaxWidth > 0 {
+		params["maxWidth"] = opt.MaxWidth
+	}
+	if opt.MaxHeight > 0 {
+		params["maxHeight"] = opt.MaxHeight
+	}
+	if opt.EveryNthFrame > 0 {
+		params["everyNthFrame"] = opt.EveryNthFrame
+	}
+
+	if _, err = session.Send("Page.startScreencast", params); err != nil {
+		errors.Ignore(session.Detach())
+		return nil, errors.WithMessage(err, "start screencast error")
+	}
+
+	log.Infof("Page %s screencast started", h.pageID)
+
+	return sc, nil
+}
+
+func (s *Screencast) handleFrame(params map[string]interface{}) {
+	// 事件在playwright的消息循环中回调, 同步发送ack会死锁
+	go func() {
+		_, err := s.session.Send("Page.screencastFrameAck", map[string]interface{}{"sessionId": params["sessionId"]})
+		if err != nil {
+			log.Debugf("Page %s screencast frame ack error: %v", s.pageID, err)
+		}
+	}()
+
+	str, ok := params["data"].(string)
+	if !ok {
+		return
+	}
+
+	data, err := base64.StdEncoding.DecodeString(str)
+	if err != nil {
+		log.Errorf("Page %s decode screencast frame error: %v", s.pageID, err)
+		return
+	}
+
+	s.onFrame(&ScreencastFrame{PageID: s.pageID, Data: data, Timestamp: time.Now()})
+}
+
+// GetPageID 返回截屏流所属的页面ID
+func (s *Screencast) GetPageID() string {
+	return s.pageID
+}
+
+// Stop 停止截屏流并断开CDP会话, 可重复调用
+func (s *Screencast) Stop() {
+	s.once.Do(func() {
+		if _, err := s.session.Send("Page.stopScreencast", nil); err != nil {
+			log.Debugf("Page %s stop screencast error: %v", s.pageID, err)
+		}
+
+		if err := s.session.Detach(); err != nil {
+			log.Debugf("Page %s detach cdp session error: %v", s.pageID, err)
+		}
+
+		log.Infof("Page %s screencast stopped", s.pageID)
+	})
+}
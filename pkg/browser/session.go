@@ -0,0 +1,305 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSessionID 默认会话, 即所有客户端共享的浏览器上下文
+	DefaultSessionID = "default"
+
+	defaultSessionIdleTimeout = 30 * time.Minute
+	sessionReapInterval       = time.Minute
+)
+
+var sessionIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_\-]{1,64}$`)
+
+type session struct {
+	id         string
+	handler    *BrowserHandler
+	createTime time.Time
+	lastUsed   time.Time
+	// inUse 正在使用会话的长请求数量, 大于 0 时不会因空闲被关闭
+	inUse int
+}
+
+// SessionInfo 会话信息
+type SessionInfo struct {
+	ID           string
+	Engine       string
+	HeadlessMode string
+	CreateTime   time.Time
+	LastUsed     time.Time
+	PageCount    int
+}
+
+// SessionOptions 会话参数, 为空时与默认浏览器一致
+type SessionOptions struct {
+	Engine       string
+	HeadlessMode string
+}
+
+type sessionList struct {
+	sessions    map[string]*session
+	idleTimeout time.Duration
+	mux         *sync.Mutex
+}
+
+func newSessionList() *sessionList {
+	return &sessionList{
+		sessions:    make(map[string]*session),
+		idleTimeout: defaultSessionIdleTimeout,
+		mux:         &sync.Mutex{},
+	}
+}
+
+// SetSessionIdleTimeout 设置会话空闲超时时间, 超时未使用的会话会被关闭
+func (m *BrowserManager) SetSessionIdleTimeout(timeout time.Duration) {
+	m.sessions.mux.Lock()
+	defer m.sessions.mux.Unlock()
+
+	m.sessions.idleTimeout = timeout
+}
+
+// CreateSession 创建命名会话, id 为空时自动生成
+// 引擎和无头模式与默认浏览器相同时在默认浏览器上创建独立的上下文, 否则启动一个会话独占的浏览器
+func (m *BrowserManager) CreateSession(id string, opt SessionOptions) (string, error) {
+	if id == "" {
+		id = newID()
+	}
+
+	if id == DefaultSessionID || isLeaseID(id) || !sessionIDRegexp.MatchString(id) {
+		return "", errors.ErrArgument
+	}
+
+	if opt.Engine == "" {
+		opt.Engine = m.opt.Engine
+	}
+
+	if opt.HeadlessMode == "" {
+		opt.HeadlessMode = m.opt.HeadlessMode
+	}
+
+	if err := ValidateEngine(opt.Engine, opt.HeadlessMode); err != nil {
+		return "", err
+	}
+
+	if m.sessionExists(id) || m.isPendingSession(id) {
+		return "", errors.ErrSessionExists
+	}
+
+	// 启动浏览器比较慢, 不持有会话锁
+	handler, err := m.newSessionHandler(id, opt)
+	if err != nil {
+		return "", errors.WithMessage(err, "create session error")
+	}
+
+	m.sessions.mux.Lock()
+	defer m.sessions.mux.Unlock()
+
+	if s, exists := m.sessions.sessions[id]; exists && !s.handler.IsClosed() {
+		go handler.Close()
+		return "", errors.ErrSessionExists
+	}
+
+	now := time.Now()
+	m.sessions.sessions[id] = &session{id: id, handler: handler, createTime: now, lastUsed: now}
+
+	log.Infof("session %s created, engine %s, mode %s", id, opt.Engine, opt.HeadlessMode)
+
+	return id, nil
+}
+
+func (m *BrowserManager) sessionExists(id string) bool {
+	m.sessions.mux.Lock()
+	defer m.sessions.mux.Unlock()
+
+	s, exists := m.sessions.sessions[id]
+
+	return exists && !s.handler.IsClosed()
+}
+
+func (m *BrowserManager) newSessionHandler(id string, opt SessionOptions) (*BrowserHandler, error) {
+	var (
+		handler *BrowserHandler
+		err     error
+	)
+
+	if opt.Engine != m.opt.Engine || opt.HeadlessMode != m.opt.HeadlessMode {
+		handler, err = m.launchWithPolicy(opt.Engine, opt.HeadlessMode)
+	} else {
+		var b *BrowserHandler
+		if b, err = m.GetOrCreateBrowser(); err == nil {
+			handler, err = b.NewSession()
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	m.supervise(handler, id)
+
+	return handler, nil
+}
+
+// GetSession 获取会话对应的浏览器, id 为空或 default 时返回共享的默认浏览器, 租约ID返回租用的浏览器
+func (m *BrowserManager) GetSession(id string) (*BrowserHandler, error) {
+	handler, _, err := m.getSession(id, false)
+
+	return handler, err
+}
+
+// AcquireSession 与 GetSession 相同, 在调用 release 之前命名会话不会因空闲被关闭
+// 用于执行步骤, 实时画面和事件流等可能超过空闲超时时间的请求, release 可以重复调用
+func (m *BrowserManager) AcquireSession(id string) (*BrowserHandler, func(), error) {
+	return m.getSession(id, true)
+}
+
+func (m *BrowserManager) getSession(id string, acquire bool) (*BrowserHandler, func(), error) {
+	if id == "" || id == DefaultSessionID {
+		handler, err := m.GetOrCreateBrowser()
+		return handler, func() {}, err
+	}
+
+	// 租约ID对应浏览器池中独占的浏览器
+	if isLeaseID(id) {
+		handler, err := m.GetPool().GetLease(id)
+		return handler, func() {}, err
+	}
+
+	// 浏览器崩溃后会话等待恢复, 由请求触发重启
+	if m.isPendingSession(id) {
+		if _, err := m.GetOrCreateBrowser(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	m.sessions.mux.Lock()
+	defer m.sessions.mux.Unlock()
+
+	s, exists := m.sessions.sessions[id]
+	if !exists {
+		return nil, nil, errors.ErrSessionNotFound
+	}
+
+	// 浏览器断开后会话随之失效
+	if s.handler.IsClosed() {
+		delete(m.sessions.sessions, id)
+		log.Infof("session %s removed, browser context is closed", id)
+
+		return nil, nil, errors.ErrSessionNotFound
+	}
+
+	s.lastUsed = time.Now()
+
+	if !acquire {
+		return s.handler, func() {}, nil
+	}
+
+	s.inUse++
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			m.sessions.mux.Lock()
+			defer m.sessions.mux.Unlock()
+
+			s.inUse--
+			s.lastUsed = time.Now()
+		})
+	}
+
+	return s.handler, release, nil
+}
+
+// CloseSession 关闭命名会话及其所有页面
+func (m *BrowserManager) CloseSession(id string) error {
+	m.sessions.mux.Lock()
+	s, exists := m.sessions.sessions[id]
+	delete(m.sessions.sessions, id)
+	m.sessions.mux.Unlock()
+
+	if !exists {
+		if m.discardPendingSession(id) {
+			log.Infof("session %s discarded before recovery", id)
+			return nil
+		}
+
+		return errors.ErrSessionNotFound
+	}
+
+	s.handler.Close()
+	log.Infof("session %s closed", id)
+
+	return nil
+}
+
+// ListSessions 按创建时间返回所有命名会话
+func (m *BrowserManager) ListSessions() []SessionInfo {
+	m.sessions.mux.Lock()
+	defer m.sessions.mux.Unlock()
+
+	list := make([]SessionInfo, 0, len(m.sessions.sessions))
+	for _, s := range m.sessions.sessions {
+		if s.handler.IsClosed() {
+			continue
+		}
+
+		list = append(list, SessionInfo{
+			ID:           s.id,
+			Engine:       s.handler.Engine(),
+			HeadlessMode: s.handler.HeadlessMode(),
+			CreateTime:   s.createTime,
+			LastUsed:     s.lastUsed,
+			PageCount:    s.handler.GetPageCount(),
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreateTime.Before(list[j].CreateTime)
+	})
+
+	return list
+}
+
+// reapIdleSessions 定期关闭空闲超时或已失效的会话
+func (m *BrowserManager) reapIdleSessions() {
+	ticker := time.NewTicker(sessionReapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		m.reapSessions()
+	}
+}
+
+// reapSessions 关闭空闲超时或已失效的会话, 正在被长请求使用的会话不算空闲
+func (m *BrowserManager) reapSessions() {
+	expired := make([]*session, 0)
+
+	m.sessions.mux.Lock()
+	for id, s := range m.sessions.sessions {
+		idle := s.inUse == 0 && m.sessions.idleTimeout > 0 && time.Since(s.lastUsed) > m.sessions.idleTimeout
+		if s.handler.IsClosed() || idle {
+			expired = append(expired, s)
+			delete(m.sessions.sessions, id)
+		}
+	}
+	m.sessions.mux.Unlock()
+
+	for _, s := range expired {
+		log.Infof("session %s idle for %v, closing", s.id, time.Since(s.lastUsed).Round(time.Second))
+		s.handler.Close()
+	}
+}
@@ -0,0 +1,42 @@
+package browser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrowserManager_ReapSessionsInUse(t *testing.T) {
+	m := NewBrowserManagerWithOptions(Options{})
+	m.sessions.idleTimeout = time.Minute
+
+	idle := time.Now().Add(-time.Hour)
+	for _, id := range []string{"busy", "idle"} {
+		h := newFakeBrowserHandler(&fakeBrowser{}, &fakeContext{})
+		m.sessions.sessions[id] = &session{id: id, handler: h, createTime: idle, lastUsed: idle}
+	}
+
+	_, release, err := m.AcquireSession("busy")
+	require.NoError(t, err)
+
+	// 长请求期间超过空闲时间也不会被关闭
+	m.sessions.sessions["busy"].lastUsed = idle
+	m.reapSessions()
+
+	_, err = m.GetSession("idle")
+	assert.Error(t, err)
+	busy, err := m.GetSession("busy")
+	require.NoError(t, err)
+	assert.False(t, busy.IsClosed())
+
+	// 释放后重新计算空闲时间, 重复释放不影响计数
+	release()
+	release()
+	assert.Zero(t, m.sessions.sessions["busy"].inUse)
+
+	m.sessions.sessions["busy"].lastUsed = idle
+	m.reapSessions()
+	assert.True(t, busy.IsClosed())
+}
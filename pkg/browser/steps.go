@@ -0,0 +1,570 @@
+package browser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	StepNavigate   = "navigate"
+	StepWait       = "wait"
+	StepClick      = "click"
+	StepFill       = "fill"
+	StepSelect     = "select"
+	StepPress      = "press"
+	StepEvaluate   = "evaluate"
+	StepScreenshot = "screenshot"
+	StepExtract    = "extract"
+	StepAssert     = "assert"
+
+	StepStatusOK      = "ok"
+	StepStatusFailed  = "failed"
+	StepStatusSkipped = "skipped"
+
+	// 提取类型
+	ExtractText           = "text"
+	ExtractHTML           = "html"
+	ExtractAttribute      = "attribute"
+	ExtractLinks          = "links"
+	ExtractForms          = "forms"
+	ExtractTables         = "tables"
+	ExtractMetadata       = "metadata"
+	ExtractStructuredData = "structured_data"
+
+	// 断言条件
+	AssertVisible       = "visible"
+	AssertHidden        = "hidden"
+	AssertTextContains  = "text_contains"
+	AssertTextEquals    = "text_equals"
+	AssertURLContains   = "url_contains"
+	AssertTitleContains = "title_contains"
+	AssertEvaluate      = "evaluate"
+
+	defaultStepTimeout    = 30 * time.Second
+	defaultStepRetryDelay = 500 * time.Millisecond
+)
+
+// Step 脚本中的一个步骤, 不同动作使用的字段不同
+type Step struct {
+	Name   string
+	Action string
+	// URL navigate 的地址
+	URL string
+	// Selector wait, click, fill, select, press, screenshot, extract 和 assert 的目标元素
+	Selector string
+	// Value fill 填入的值或 select 选择的选项值
+	Value string
+	// Secret 录制时去掉了密码框的输入, 生成的代码从环境变量读取, 回放前需要填写 Value
+	Secret bool
+	// Key press 的按键, 例如 Enter, 没有选择器时发送到当前焦点元素
+	Key string
+	// State wait 等待的元素状态: visible(默认), hidden, attached 或 detached
+	State string
+	// Delay wait 没有选择器时固定等待的时间
+	Delay time.Duration
+	// Script evaluate 和 assert evaluate 执行的脚本, Arg 为脚本参数
+	Script string
+	Arg    interface{}
+	// Extract 提取类型, 为空时提取文本
+	Extract   string
+	Attribute string
+	// FullPage screenshot 没有选择器时是否截取整个页面
+	FullPage bool
+	// Condition 断言条件, Expected 为期望值
+	Condition string
+	Expected  string
+	// Timeout 单个步骤每次尝试的超时时间, 为空时使用 RunOptions.StepTimeout
+	Timeout time.Duration
+	// Retries 失败后的重试次数
+	Retries int
+	// ContinueOnError 失败后继续执行后续步骤
+	ContinueOnError bool
+}
+
+// StepArtifact 步骤产生的文件, 目前只有截图
+type StepArtifact struct {
+	Type string
+	Data []byte
+}
+
+// StepResult 单个步骤的执行结果
+type StepResult struct {
+	Index    int
+	Name     string
+	Action   string
+	Status   string
+	Attempts int
+	Duration time.Duration
+	Error    string
+	Value    interface{}
+	Artifact *StepArtifact
+}
+
+// RunOptions 执行参数
+type RunOptions struct {
+	StepTimeout time.Duration
+	RetryDelay  time.Duration
+}
+
+func (opt RunOptions) withDefaults() RunOptions {
+	if opt.StepTimeout <= 0 {
+		opt.StepTimeout = defaultStepTimeout
+	}
+
+	if opt.RetryDelay <= 0 {
+		opt.RetryDelay = defaultStepRetryDelay
+	}
+
+	return opt
+}
+
+// stepTimeout 步骤每次尝试的超时时间
+func (s Step) stepTimeout(opt RunOptions) time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+
+	return opt.StepTimeout
+}
+
+// CheckSteps 在执行前检查步骤参数, 固定等待的时间不能超过步骤的超时时间
+func CheckSteps(steps []Step, opt RunOptions) error {
+	opt = opt.withDefaults()
+
+	for i, step := range steps {
+		if err := step.check(opt); err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (s Step) check(opt RunOptions) error {
+	if s.Action == StepWait && s.Selector == "" && s.Delay > s.stepTimeout(opt) {
+		return fmt.Errorf("wait delay %v exceeds the step timeout %v", s.Delay, s.stepTimeout(opt))
+	}
+
+	if s.Action == StepFill && s.Secret && s.Value == "" {
+		return fmt.Errorf("fill %s is a recorded password without value, set the value before running", s.Selector)
+	}
+
+	return nil
+}
+
+// RunResult 脚本的执行结果, 任一步骤失败且没有设置 ContinueOnError 时后续步骤跳过
+type RunResult struct {
+	PageID   string
+	Success  bool
+	Duration time.Duration
+	Steps    []StepResult
+}
+
+// RunSteps 在标签页上按顺序执行步骤, ctx 结束后剩余步骤跳过
+func (h *PageHandler) RunSteps(ctx context.Context, steps []Step, opt RunOptions) *RunResult {
+	opt = opt.withDefaults()
+
+	start := time.Now()
+	result := &RunResult{PageID: h.pageID, Success: true, Steps: make([]StepResult, 0, len(steps))}
+
+	stopped := false
+	for i, step := range steps {
+		item := StepResult{Index: i, Name: step.Name, Action: step.Action, Status: StepStatusSkipped}
+
+		if stopped || ctx.Err() != nil {
+			if ctx.Err() != nil {
+				item.Error = ctx.Err().Error()
+			}
+			result.Success = false
+			result.Steps = append(result.Steps, item)
+			continue
+		}
+
+		h.runStep(ctx, step, opt, &item)
+		h.Touch()
+
+		if item.Status == StepStatusFailed {
+			result.Success = false
+			stopped = !step.ContinueOnError
+		}
+
+		result.Steps = append(result.Steps, item)
+	}
+
+	result.Duration = time.Since(start)
+
+	return result
+}
+
+func (h *PageHandler) runStep(ctx context.Context, step Step, opt RunOptions, item *StepResult) {
+	timeout := step.stepTimeout(opt)
+
+	start := time.Now()
+	defer func() {
+		item.Duration = time.Since(start)
+	}()
+
+	err := step.check(opt)
+	if err != nil {
+		item.Status = StepStatusFailed
+		item.Error = err.Error()
+		return
+	}
+
+	for attempt := 0; attempt <= step.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				item.Status = StepStatusFailed
+				item.Error = ctx.Err().Error()
+				return
+			case <-time.After(opt.RetryDelay):
+			}
+		}
+
+		item.Attempts = attempt + 1
+
+		var value interface{}
+		var artifact *StepArtifact
+		value, artifact, err = h.attemptStep(ctx, step, timeout)
+		if err == nil {
+			item.Status = StepStatusOK
+			item.Value = value
+			item.Artifact = artifact
+			return
+		}
+	}
+
+	item.Status = StepStatusFailed
+	item.Error = err.Error()
+}
+
+// attemptStep 执行一次步骤, 超时时间不超过 ctx 的剩余时间, 通过 Playwright 的 Timeout 参数传入
+// 上一次超时的脚本仍在执行时先等待它结束, 同一个页面上的步骤不会并发执行
+func (h *PageHandler) attemptStep(ctx context.Context, step Step, timeout time.Duration) (interface{}, *StepArtifact, error) {
+	if h.IsClosed() {
+		return nil, nil, fmt.Errorf("page %s is closed", h.pageID)
+	}
+
+	if err := h.checkResponsive(); err != nil {
+		return nil, nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = min(timeout, time.Until(deadline))
+	}
+
+	if timeout <= 0 {
+		return nil, nil, context.DeadlineExceeded
+	}
+
+	// 等待的时间计入本次尝试
+	start := time.Now()
+	if err := h.waitEvaluating(ctx, timeout); err != nil {
+		return nil, nil, err
+	}
+
+	remaining := timeout - time.Since(start)
+	if remaining <= 0 {
+		return nil, nil, fmt.Errorf("step timed out after %v", timeout)
+	}
+
+	return h.execStep(ctx, step, remaining)
+}
+
+// evaluateStep 脚本求值没有超时参数, 在协程中执行, 超时后不再等待结果
+// 仍在执行的脚本记录在页面上, 下一次执行步骤前需要等待它结束
+func (h *PageHandler) evaluateStep(ctx context.Context, timeout time.Duration, fn func() (interface{}, error)) (interface{}, error) {
+	type evalReturn struct {
+		value interface{}
+		err   error
+	}
+
+	done := make(chan evalReturn, 1)
+	finished := make(chan struct{})
+
+	h.mux.Lock()
+	h.evaluating = finished
+	h.mux.Unlock()
+
+	go func() {
+		defer close(finished)
+
+		value, err := fn()
+		done <- evalReturn{value, err}
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case ret := <-done:
+		return ret.value, ret.err
+	case <-timer.C:
+		return nil, fmt.Errorf("step timed out after %v", timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waitEvaluating 等待之前超时的脚本结束, 超过 timeout 仍未结束时返回错误
+func (h *PageHandler) waitEvaluating(ctx context.Context, timeout time.Duration) error {
+	h.mux.Lock()
+	evaluating := h.evaluating
+	h.mux.Unlock()
+
+	if evaluating == nil {
+		return nil
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-evaluating:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("previous script is still running after %v", timeout)
+	case <-ctx.Done():
+		return fmt.Errorf("previous script is still running: %w", ctx.Err())
+	}
+}
+
+func (h *PageHandler) execStep(ctx context.Context, step Step, timeout time.Duration) (interface{}, *StepArtifact, error) {
+	ms := playwright.Float(float64(timeout / time.Millisecond))
+
+	switch step.Action {
+	case StepNavigate:
+		start := time.Now()
+		resp, err := h.page.Goto(step.URL, playwright.PageGotoOptions{Timeout: ms})
+		metricNavigationDurations.WithLabelValues(resultLabel(err)).Observe(float64(time.Since(start) / time.Millisecond))
+		if err != nil {
+			h.notify(BrowserEvent{Type: EventNavigationFailed, URL: step.URL, Message: err.Error()})
+			return nil, nil, err
+		}
+
+		value := map[string]interface{}{"url": h.page.URL()}
+		if resp != nil {
+			value["status"] = resp.Status()
+		}
+		return value, nil, nil
+
+	case StepWait:
+		if step.Selector == "" {
+			delay := time.NewTimer(step.Delay)
+			defer delay.Stop()
+
+			select {
+			case <-delay.C:
+				return nil, nil, nil
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+
+		state := playwright.WaitForSelectorStateVisible
+		if step.State != "" {
+			custom := playwright.WaitForSelectorState(step.State)
+			state = &custom
+		}
+		return nil, nil, h.page.Locator(step.Selector).First().WaitFor(playwright.LocatorWaitForOptions{State: state, Timeout: ms})
+
+	case StepClick:
+		return nil, nil, h.page.Locator(step.Selector).First().Click(playwright.LocatorClickOptions{Timeout: ms})
+
+	case StepFill:
+		return nil, nil, h.page.Locator(step.Selector).First().Fill(step.Value, playwright.LocatorFillOptions{Timeout: ms})
+
+	case StepSelect:
+		_, err := h.page.Locator(step.Selector).First().SelectOption(
+			playwright.SelectOptionValues{Values: &[]string{step.Value}},
+			playwright.LocatorSelectOptionOptions{Timeout: ms},
+		)
+		return nil, nil, err
+
+	case StepPress:
+		if step.Selector == "" {
+			// 键盘按键没有超时参数, 与脚本一样在超时后放弃等待
+			_, err := h.evaluateStep(ctx, timeout, func() (interface{}, error) {
+				return nil, h.page.Keyboard().Press(step.Key)
+			})
+			return nil, nil, err
+		}
+		return nil, nil, h.page.Locator(step.Selector).First().Press(step.Key, playwright.LocatorPressOptions{Timeout: ms})
+
+	case StepEvaluate:
+		value, err := h.evaluateStep(ctx, timeout, func() (interface{}, error) {
+			var value interface{}
+			err := h.evaluateInto(step.Script, step.Arg, &value)
+			return value, err
+		})
+		return value, nil, err
+
+	case StepScreenshot:
+		if step.Selector == "" {
+			data, err := h.ScreenshotWithOptions(ScreenshotOptions{FullPage: step.FullPage, Timeout: timeout})
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, &StepArtifact{Type: "png", Data: data}, nil
+		}
+
+		data, err := h.page.Locator(step.Selector).First().Screenshot(playwright.LocatorScreenshotOptions{
+			Timeout:    ms,
+			Type:       playwright.ScreenshotTypePng,
+			Animations: playwright.ScreenshotAnimationsDisabled,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &StepArtifact{Type: "png", Data: data}, nil
+
+	case StepExtract:
+		value, err := h.extractStep(ctx, step, timeout)
+		return value, nil, err
+
+	case StepAssert:
+		value, err := h.assertStep(ctx, step, timeout)
+		return value, nil, err
+	}
+
+	return nil, nil, fmt.Errorf("unknown step action %q", step.Action)
+}
+
+func (h *PageHandler) extractStep(ctx context.Context, step Step, timeout time.Duration) (interface{}, error) {
+	ms := playwright.Float(float64(timeout / time.Millisecond))
+
+	switch step.Extract {
+	case "", ExtractText:
+		return h.page.Locator(selectorOrBody(step.Selector)).First().InnerText(playwright.LocatorInnerTextOptions{Timeout: ms})
+	case ExtractHTML:
+		return h.page.Locator(selectorOrBody(step.Selector)).First().InnerHTML(playwright.LocatorInnerHTMLOptions{Timeout: ms})
+	case ExtractAttribute:
+		return h.page.Locator(step.Selector).First().GetAttribute(step.Attribute, playwright.LocatorGetAttributeOptions{Timeout: ms})
+	case ExtractLinks:
+		return h.evaluateStep(ctx, timeout, func() (interface{}, error) { return h.ExtractLinks(step.Selector) })
+	case ExtractForms:
+		return h.evaluateStep(ctx, timeout, func() (interface{}, error) { return h.ExtractForms(step.Selector) })
+	case ExtractTables:
+		return h.evaluateStep(ctx, timeout, func() (interface{}, error) { return h.ExtractTables(step.Selector) })
+	case ExtractMetadata:
+		return h.evaluateStep(ctx, timeout, func() (interface{}, error) { return h.ExtractMetadata() })
+	case ExtractStructuredData:
+		return h.evaluateStep(ctx, timeout, func() (interface{}, error) { return h.ExtractStructuredData() })
+	}
+
+	return nil, fmt.Errorf("unknown extract type %q", step.Extract)
+}
+
+// assertStep 断言失败时返回的错误包含实际值, 便于定位
+func (h *PageHandler) assertStep(ctx context.Context, step Step, timeout time.Duration) (interface{}, error) {
+	ms := playwright.Float(float64(timeout / time.Millisecond))
+
+	switch step.Condition {
+	case AssertVisible, AssertHidden:
+		// 与生成代码中的 expect 一样在超时时间内等待元素变为期望的状态
+		visible := step.Condition == AssertVisible
+		state := playwright.WaitForSelectorStateHidden
+		if visible {
+			state = playwright.WaitForSelectorStateVisible
+		}
+
+		err := h.page.Locator(step.Selector).First().WaitFor(playwright.LocatorWaitForOptions{State: state, Timeout: ms})
+		if err != nil {
+			return !visible, fmt.Errorf("assert %s failed: element %s: %w", step.Condition, step.Selector, err)
+		}
+		return visible, nil
+
+	case AssertTextContains, AssertTextEquals:
+		text, err := h.page.Locator(selectorOrBody(step.Selector)).First().InnerText(playwright.LocatorInnerTextOptions{Timeout: ms})
+		if err != nil {
+			return nil, err
+		}
+
+		ok := strings.Contains(text, step.Expected)
+		if step.Condition == AssertTextEquals {
+			ok = strings.TrimSpace(text) == step.Expected
+		}
+
+		if !ok {
+			return text, fmt.Errorf("assert %s failed: expected %q, got %q", step.Condition, step.Expected, abbreviate(text))
+		}
+		return text, nil
+
+	case AssertURLContains:
+		url := h.page.URL()
+		if !strings.Contains(url, step.Expected) {
+			return url, fmt.Errorf("assert %s failed: expected %q, got %q", step.Condition, step.Expected, url)
+		}
+		return url, nil
+
+	case AssertTitleContains:
+		title, err := h.page.Title()
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.Contains(title, step.Expected) {
+			return title, fmt.Errorf("assert %s failed: expected %q, got %q", step.Condition, step.Expected, title)
+		}
+		return title, nil
+
+	case AssertEvaluate:
+		value, err := h.evaluateStep(ctx, timeout, func() (interface{}, error) {
+			var value interface{}
+			err := h.evaluateInto(step.Script, step.Arg, &value)
+			return value, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !truthy(value) {
+			data, _ := json.Marshal(value)
+			return value, fmt.Errorf("assert %s failed: script returned %s", step.Condition, data)
+		}
+		return value, nil
+	}
+
+	return nil, fmt.Errorf("unknown assert condition %q", step.Condition)
+}
+
+func selectorOrBody(selector string) string {
+	if selector == "" {
+		return "body"
+	}
+
+	return selector
+}
+
+// truthy 与 JavaScript 的真值规则一致
+func truthy(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	}
+
+	return true
+}
+
+func abbreviate(s string) string {
+	const limit = 200
+
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+
+	return string(runes[:limit]) + "..."
+}
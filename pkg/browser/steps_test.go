@@ -0,0 +1,197 @@
+package browser
+
+import (
+	"browsertools/pkg/errors"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageHandler_RunSteps(t *testing.T) {
+	// 卡死的页面不会调用 playwright, 每个步骤都直接失败
+	h := &PageHandler{pageID: "page-1", mux: &sync.Mutex{}}
+	h.setState(TabStateHung)
+
+	result := h.RunSteps(context.Background(), []Step{
+		{Name: "open menu", Action: StepClick, Selector: "#menu", Retries: 2, ContinueOnError: true},
+		{Action: StepFill, Selector: "#name", Value: "test"},
+		{Action: StepAssert, Condition: AssertURLContains, Expected: "example"},
+	}, RunOptions{RetryDelay: time.Millisecond})
+
+	assert.Equal(t, "page-1", result.PageID)
+	assert.False(t, result.Success)
+	assert.Len(t, result.Steps, 3)
+
+	assert.Equal(t, "open menu", result.Steps[0].Name)
+	assert.Equal(t, StepStatusFailed, result.Steps[0].Status)
+	assert.Equal(t, 3, result.Steps[0].Attempts)
+	assert.Contains(t, result.Steps[0].Error, errors.ErrPageUnresponsive.Error())
+
+	assert.Equal(t, StepStatusFailed, result.Steps[1].Status)
+	assert.Equal(t, 1, result.Steps[1].Attempts)
+
+	assert.Equal(t, StepStatusSkipped, result.Steps[2].Status)
+	assert.Equal(t, 0, result.Steps[2].Attempts)
+}
+
+func TestPageHandler_RunStepsCanceled(t *testing.T) {
+	h := &PageHandler{pageID: "page-1", mux: &sync.Mutex{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := h.RunSteps(ctx, []Step{{Action: StepClick, Selector: "#submit"}}, RunOptions{})
+
+	assert.False(t, result.Success)
+	assert.Equal(t, StepStatusSkipped, result.Steps[0].Status)
+	assert.Equal(t, context.Canceled.Error(), result.Steps[0].Error)
+}
+
+func TestTruthy(t *testing.T) {
+	assert.False(t, truthy(nil))
+	assert.False(t, truthy(false))
+	assert.False(t, truthy(float64(0)))
+	assert.False(t, truthy(""))
+	assert.True(t, truthy(true))
+	assert.True(t, truthy(float64(2)))
+	assert.True(t, truthy("ok"))
+	assert.True(t, truthy([]interface{}{}))
+	assert.True(t, truthy(map[string]interface{}{}))
+}
+
+func TestCheckSteps(t *testing.T) {
+	// 固定等待超过步骤超时时间的步骤不可能成功
+	err := CheckSteps([]Step{{Action: StepWait, Delay: time.Minute}}, RunOptions{})
+	assert.ErrorContains(t, err, "step 0: wait delay 1m0s exceeds the step timeout 30s")
+
+	assert.NoError(t, CheckSteps([]Step{{Action: StepWait, Delay: time.Minute, Timeout: 2 * time.Minute}}, RunOptions{}))
+	assert.NoError(t, CheckSteps([]Step{{Action: StepWait, Delay: time.Minute}}, RunOptions{StepTimeout: time.Minute}))
+	assert.NoError(t, CheckSteps([]Step{{Action: StepWait, Selector: "#done", Delay: time.Minute}}, RunOptions{}))
+
+	// 去掉了原值的密码框输入需要先填写 Value
+	err = CheckSteps([]Step{{Action: StepFill, Selector: "#password", Secret: true}}, RunOptions{})
+	assert.ErrorContains(t, err, "step 0: fill #password is a recorded password without value")
+	assert.NoError(t, CheckSteps([]Step{{Action: StepFill, Selector: "#password", Value: "secret", Secret: true}}, RunOptions{}))
+
+	h := &PageHandler{pageID: "page-1", page: &fakePage{}, mux: &sync.Mutex{}}
+	result := h.RunSteps(context.Background(), []Step{{Action: StepWait, Delay: time.Minute}}, RunOptions{})
+	assert.Equal(t, StepStatusFailed, result.Steps[0].Status)
+	assert.Equal(t, 0, result.Steps[0].Attempts)
+}
+
+func TestPageHandler_RunStepsWaitCanceled(t *testing.T) {
+	h := &PageHandler{pageID: "page-1", page: &fakePage{}, mux: &sync.Mutex{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result := h.RunSteps(ctx, []Step{{Action: StepWait, Delay: 10 * time.Second}}, RunOptions{})
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, StepStatusFailed, result.Steps[0].Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), result.Steps[0].Error)
+}
+
+func TestPageHandler_RunStepsTimeoutFromContext(t *testing.T) {
+	page := &fakePage{}
+	h := &PageHandler{pageID: "page-1", page: page, mux: &sync.Mutex{}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	result := h.RunSteps(ctx, []Step{{Action: StepNavigate, URL: "https://example.com"}}, RunOptions{})
+	assert.Equal(t, StepStatusOK, result.Steps[0].Status)
+
+	// playwright 的超时不超过请求的剩余时间
+	assert.Len(t, page.gotoTimeouts, 1)
+	assert.LessOrEqual(t, page.gotoTimeouts[0], float64(1000))
+	assert.Greater(t, page.gotoTimeouts[0], float64(0))
+}
+
+func TestPageHandler_RunStepsEvaluateTimeout(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning, calls := 0, 0, 0
+
+	page := &fakePage{evaluate: func(string, ...interface{}) (interface{}, error) {
+		mu.Lock()
+		running++
+		calls++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		return "done", nil
+	}}
+	h := &PageHandler{pageID: "page-1", page: page, mux: &sync.Mutex{}}
+
+	result := h.RunSteps(context.Background(), []Step{
+		{Action: StepEvaluate, Script: "slow()", Timeout: 20 * time.Millisecond, Retries: 2, ContinueOnError: true},
+		{Action: StepEvaluate, Script: "slow()"},
+	}, RunOptions{RetryDelay: time.Millisecond})
+
+	assert.Equal(t, StepStatusFailed, result.Steps[0].Status)
+	assert.Contains(t, result.Steps[0].Error, "still running")
+
+	// 超时的脚本结束之前不会在同一个页面上执行下一个脚本
+	assert.Equal(t, StepStatusOK, result.Steps[1].Status)
+	assert.Equal(t, "done", result.Steps[1].Value)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, maxRunning)
+	assert.Equal(t, 2, calls)
+}
+
+func TestPageHandler_RunStepsPressTimeout(t *testing.T) {
+	keyboard := &fakeKeyboard{press: make(chan struct{})}
+	defer close(keyboard.press)
+
+	h := &PageHandler{pageID: "page-1", page: &fakePage{keyboard: keyboard}, mux: &sync.Mutex{}}
+
+	// 没有选择器的按键在卡住时按步骤超时结束
+	start := time.Now()
+	result := h.RunSteps(context.Background(), []Step{{Action: StepPress, Key: "Enter", Timeout: 50 * time.Millisecond}}, RunOptions{})
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, StepStatusFailed, result.Steps[0].Status)
+	assert.Contains(t, result.Steps[0].Error, "timed out")
+}
+
+func TestPageHandler_RunStepsAssertVisible(t *testing.T) {
+	locator := &fakeLocator{waitFor: func(opt playwright.LocatorWaitForOptions) error {
+		if opt.State == playwright.WaitForSelectorStateHidden {
+			return errors.New("timeout exceeded")
+		}
+		return nil
+	}}
+	h := &PageHandler{pageID: "page-1", page: &fakePage{locator: locator}, mux: &sync.Mutex{}}
+
+	result := h.RunSteps(context.Background(), []Step{
+		{Action: StepAssert, Condition: AssertVisible, Selector: "#toast", Timeout: time.Second, ContinueOnError: true},
+		{Action: StepAssert, Condition: AssertHidden, Selector: "#toast", Timeout: time.Second},
+	}, RunOptions{})
+
+	assert.Equal(t, StepStatusOK, result.Steps[0].Status)
+	assert.Equal(t, true, result.Steps[0].Value)
+	assert.Equal(t, StepStatusFailed, result.Steps[1].Status)
+	assert.Contains(t, result.Steps[1].Error, "assert hidden failed: element #toast")
+
+	// 等待元素状态时使用步骤的超时时间
+	locator.mu.Lock()
+	defer locator.mu.Unlock()
+	require.Len(t, locator.waits, 2)
+	assert.Equal(t, playwright.WaitForSelectorStateVisible, locator.waits[0].State)
+	assert.LessOrEqual(t, *locator.waits[0].Timeout, float64(1000))
+	assert.Greater(t, *locator.waits[0].Timeout, float64(0))
+}
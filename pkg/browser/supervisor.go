@@ -0,0 +1,479 @@
+package browser
+
+import (
+	"browsertools/log"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// RecoveryBrowser 浏览器断开后重新启动并恢复标签页
+	RecoveryBrowser = "browser"
+	// RecoveryTab 页面渲染进程崩溃后重新打开
+	RecoveryTab = "tab"
+
+	defaultRecoveryMaxAttempts      = 5
+	defaultRecoveryInitialBackoff   = time.Second
+	defaultRecoveryMaxBackoff       = 30 * time.Second
+	defaultRecoverySnapshotInterval = 2 * time.Second
+	maxRecoveryEvents               = 100
+)
+
+// RecoveryOptions 崩溃恢复参数, 只恢复默认浏览器和共享默认浏览器的会话,
+// 引擎或无头模式与默认浏览器不同的会话使用独立的浏览器, 该浏览器断开后会话被移除, 不会重启和恢复标签页
+type RecoveryOptions struct {
+	Enabled bool
+	// MaxAttempts 浏览器断开后最多重试启动的次数, 之后由下一个请求触发启动
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// SnapshotInterval 记录标签页快照的间隔, 浏览器断开时按最近的快照恢复
+	SnapshotInterval time.Duration
+}
+
+// DefaultRecoveryOptions 默认崩溃恢复参数
+func DefaultRecoveryOptions() RecoveryOptions {
+	return RecoveryOptions{
+		Enabled:          true,
+		MaxAttempts:      defaultRecoveryMaxAttempts,
+		InitialBackoff:   defaultRecoveryInitialBackoff,
+		MaxBackoff:       defaultRecoveryMaxBackoff,
+		SnapshotInterval: defaultRecoverySnapshotInterval,
+	}
+}
+
+// TabSnapshot 标签页快照
+type TabSnapshot struct {
+	URL    string
+	Active bool
+	Pinned bool
+}
+
+// RecoveryEvent 恢复事件
+type RecoveryEvent struct {
+	Type    string
+	Success bool
+	// SessionID 标签页恢复时所在的会话, 默认浏览器为空
+	SessionID string
+	// PageID 标签页恢复时为新页面的ID
+	PageID       string
+	Attempts     int
+	RestoredTabs int
+	Duration     time.Duration
+	Error        string
+	Time         time.Time
+}
+
+// OnRecovery 注册恢复事件回调, 返回取消注册的函数
+func (m *BrowserManager) OnRecovery(fn func(RecoveryEvent)) func() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.listenerSeq++
+	seq := m.listenerSeq
+	m.recoveryListeners[seq] = fn
+
+	return func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+
+		delete(m.recoveryListeners, seq)
+	}
+}
+
+// GetRecoveryEvents 返回最近的恢复事件, 按时间从旧到新
+func (m *BrowserManager) GetRecoveryEvents() []RecoveryEvent {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]RecoveryEvent{}, m.recoveryEvents...)
+}
+
+func (m *BrowserManager) emitRecovery(event RecoveryEvent) {
+	result := "success"
+	if !event.Success {
+		result = "failure"
+	}
+
+	metricRecoveryTotal.WithLabelValues(event.Type, result).Inc()
+	if event.Type == RecoveryBrowser && event.Success {
+		metricRecoveryDurations.WithLabelValues(event.Type).Observe(float64(event.Duration.Milliseconds()))
+	}
+
+	log.Infof("recovery event: type %s, success %v, attempts %d, restored tabs %d, duration %v, error %s",
+		event.Type, event.Success, event.Attempts, event.RestoredTabs, event.Duration.Round(time.Millisecond), event.Error)
+
+	m.mutex.Lock()
+	if len(m.recoveryEvents) >= maxRecoveryEvents {
+		m.recoveryEvents = m.recoveryEvents[1:]
+	}
+	m.recoveryEvents = append(m.recoveryEvents, event)
+
+	listeners := make([]func(RecoveryEvent), 0, len(m.recoveryListeners))
+	for _, fn := range m.recoveryListeners {
+		listeners = append(listeners, fn)
+	}
+	m.mutex.Unlock()
+
+	for _, fn := range listeners {
+		fn(event)
+	}
+
+	if event.Type == RecoveryBrowser && event.Success {
+		m.emitEvent(BrowserEvent{
+			Type:    EventBrowserRelaunched,
+			Message: fmt.Sprintf("relaunched after %d attempts, restored %d tabs", event.Attempts, event.RestoredTabs),
+			Data:    map[string]interface{}{"attempts": event.Attempts, "restored_tabs": event.RestoredTabs},
+			Time:    event.Time,
+		})
+	}
+}
+
+// supervise 监听浏览器断开和页面崩溃, sessionID 为空时表示默认浏览器, 只有默认浏览器断开后会重启
+func (m *BrowserManager) supervise(b *BrowserHandler, sessionID string) {
+	m.observe(b, sessionID)
+
+	if !m.opt.Recovery.Enabled {
+		return
+	}
+
+	b.stateMux.Lock()
+	defer b.stateMux.Unlock()
+
+	b.onCrash = func(event RecoveryEvent) {
+		event.SessionID = sessionID
+		m.emitRecovery(event)
+	}
+
+	// 会话跟随默认浏览器一起恢复
+	if sessionID == "" {
+		b.onDisconnect = func() {
+			m.onBrowserDisconnected(b)
+		}
+	}
+}
+
+// onBrowserDisconnected 保存默认浏览器和共享该浏览器的会话的标签页, 然后开始重启
+func (m *BrowserManager) onBrowserDisconnected(b *BrowserHandler) {
+	m.mutex.Lock()
+	if m.browserHandler != b {
+		m.mutex.Unlock()
+		return
+	}
+
+	m.pendingTabs = b.lastTabs()
+
+	m.sessions.mux.Lock()
+	for id, s := range m.sessions.sessions {
+		if s.handler.browser != b.browser {
+			continue
+		}
+
+		m.pendingSessions[id] = s.handler.lastTabs()
+		delete(m.sessions.sessions, id)
+	}
+	m.sessions.mux.Unlock()
+	m.mutex.Unlock()
+
+	log.Errorf("browser disconnected unexpectedly, recovering %d tabs", len(m.pendingTabs))
+
+	go m.recover()
+}
+
+// recover 按指数退避重启浏览器, 恢复工作由 GetOrCreateBrowser 完成
+func (m *BrowserManager) recover() {
+	opt := m.opt.Recovery
+	start := time.Now()
+	backoff := opt.InitialBackoff
+
+	var lastErr error
+	for attempt := 1; attempt <= opt.MaxAttempts; attempt++ {
+		restored, err := m.recoverOnce()
+		if err == nil {
+			m.emitRecovery(RecoveryEvent{
+				Type:         RecoveryBrowser,
+				Success:      true,
+				Attempts:     attempt,
+				RestoredTabs: restored,
+				Duration:     time.Since(start),
+				Time:         time.Now(),
+			})
+			return
+		}
+
+		lastErr = err
+		if m.isClosed() {
+			return
+		}
+
+		log.Errorf("recover browser attempt %d error: %v, retry in %v", attempt, err, backoff)
+
+		select {
+		case <-m.done:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, opt.MaxBackoff)
+	}
+
+	m.emitRecovery(RecoveryEvent{
+		Type:     RecoveryBrowser,
+		Success:  false,
+		Attempts: opt.MaxAttempts,
+		Duration: time.Since(start),
+		Error:    lastErr.Error(),
+		Time:     time.Now(),
+	})
+}
+
+func (m *BrowserManager) recoverOnce() (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.browserHandler != nil && !m.browserHandler.IsClosed() {
+		// 已经被请求触发重启过了
+		return m.restoredTabs, nil
+	}
+
+	_, err := m.createLocked()
+
+	return m.restoredTabs, err
+}
+
+// restorePendingLocked 在新浏览器中恢复断开前的标签页和会话, 需要持有 m.mutex 调用
+func (m *BrowserManager) restorePendingLocked(b *BrowserHandler) {
+	m.restoredTabs = b.restoreTabs(m.pendingTabs)
+	m.pendingTabs = nil
+
+	for id, tabs := range m.pendingSessions {
+		handler, err := b.NewSession()
+		if err != nil {
+			log.Errorf("restore session %s error: %v", id, err)
+			continue
+		}
+
+		m.supervise(handler, id)
+		m.restoredTabs += handler.restoreTabs(tabs)
+
+		now := time.Now()
+		m.sessions.mux.Lock()
+		m.sessions.sessions[id] = &session{id: id, handler: handler, createTime: now, lastUsed: now}
+		m.sessions.mux.Unlock()
+
+		log.Infof("session %s restored with %d tabs", id, len(tabs))
+	}
+
+	m.pendingSessions = make(map[string][]TabSnapshot)
+}
+
+// isPendingSession 会话是否在等待浏览器恢复
+func (m *BrowserManager) isPendingSession(id string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, pending := m.pendingSessions[id]
+
+	return pending
+}
+
+func (m *BrowserManager) discardPendingSession(id string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, pending := m.pendingSessions[id]
+	delete(m.pendingSessions, id)
+
+	return pending
+}
+
+// snapshotLoop 定期记录默认浏览器和会话的标签页
+func (m *BrowserManager) snapshotLoop() {
+	if !m.opt.Recovery.Enabled {
+		return
+	}
+
+	ticker := time.NewTicker(m.opt.Recovery.SnapshotInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+		}
+
+		m.mutex.Lock()
+		b := m.browserHandler
+		m.mutex.Unlock()
+
+		if b != nil {
+			b.recordTabs()
+		}
+
+		m.sessions.mux.Lock()
+		handlers := make([]*BrowserHandler, 0, len(m.sessions.sessions))
+		for _, s := range m.sessions.sessions {
+			handlers = append(handlers, s.handler)
+		}
+		m.sessions.mux.Unlock()
+
+		for _, handler := range handlers {
+			handler.recordTabs()
+		}
+	}
+}
+
+// snapshotTabs 返回当前打开的标签页
+func (h *BrowserHandler) snapshotTabs() []TabSnapshot {
+	active := h.pageList.GetActivePage()
+
+	tabs := make([]TabSnapshot, 0)
+	for _, page := range h.pageList.GetPages() {
+		if page.IsClosed() {
+			continue
+		}
+
+		tabs = append(tabs, TabSnapshot{
+			URL:    page.GetPage().URL(),
+			Active: page == active,
+			Pinned: page.IsPinned(),
+		})
+	}
+
+	return tabs
+}
+
+// recordTabs 浏览器正常时记录标签页快照
+func (h *BrowserHandler) recordTabs() {
+	if h.IsClosed() {
+		return
+	}
+
+	tabs := h.snapshotTabs()
+
+	h.stateMux.Lock()
+	h.tabs = tabs
+	h.stateMux.Unlock()
+}
+
+// lastTabs 断开时页面可能已经被移除, 这时使用最近一次记录的快照
+func (h *BrowserHandler) lastTabs() []TabSnapshot {
+	if tabs := h.snapshotTabs(); len(tabs) > 0 {
+		return tabs
+	}
+
+	h.stateMux.Lock()
+	defer h.stateMux.Unlock()
+
+	return append([]TabSnapshot{}, h.tabs...)
+}
+
+// restoreTabs 重新打开标签页并恢复活动页面, 页面在后台加载, 返回恢复的数量
+func (h *BrowserHandler) restoreTabs(tabs []TabSnapshot) int {
+	activeID := ""
+	restored := 0
+
+	for i, tab := range tabs {
+		var (
+			page *PageHandler
+			err  error
+		)
+
+		// 第一个标签页复用浏览器启动时的空白页
+		if i == 0 {
+			page, err = h.getEmptyPage()
+		} else {
+			page, err = h.createPage()
+		}
+
+		if err != nil {
+			log.Errorf("restore tab %s error: %v", tab.URL, err)
+			continue
+		}
+
+		page.SetPinned(tab.Pinned)
+		restored++
+
+		if tab.Active {
+			activeID = page.GetPageID()
+		}
+
+		if isBlankURL(tab.URL) {
+			continue
+		}
+
+		go func(page *PageHandler, url string) {
+			if err := page.Goto(context.Background(), url); err != nil {
+				log.Errorf("restore tab navigation error: %v", err)
+			}
+		}(page, tab.URL)
+	}
+
+	if activeID != "" {
+		h.pageList.SetActivePage(activeID)
+	}
+
+	return restored
+}
+
+// recoverCrashedTab 渲染进程崩溃后页面不可再用, 在新标签页中重新打开并关闭崩溃的页面
+func (h *BrowserHandler) recoverCrashedTab(pageID string) {
+	start := time.Now()
+
+	h.stateMux.Lock()
+	onCrash := h.onCrash
+	h.stateMux.Unlock()
+
+	// 没有被 supervisor 管理的浏览器, 例如池中的浏览器, 只恢复页面不发送事件
+	if onCrash == nil {
+		onCrash = func(RecoveryEvent) {}
+	}
+
+	crashed := h.pageList.GetPageByID(pageID)
+	if crashed == nil {
+		return
+	}
+
+	wasActive := h.pageList.GetActivePage() == crashed
+	url := crashed.GetPage().URL()
+
+	previous := h.pageList.GetActivePage()
+	page, err := h.createPage()
+	if err != nil {
+		onCrash(RecoveryEvent{Type: RecoveryTab, Success: false, Error: err.Error(), Duration: time.Since(start), Time: time.Now()})
+		return
+	}
+
+	page.SetPinned(crashed.IsPinned())
+	h.evict(crashed)
+
+	// 崩溃的不是活动页面时保持原来的活动页面
+	if !wasActive && previous != nil {
+		h.pageList.SetActivePage(previous.GetPageID())
+	}
+
+	if !isBlankURL(url) {
+		err = page.Goto(context.Background(), url)
+	}
+
+	event := RecoveryEvent{
+		Type:         RecoveryTab,
+		Success:      err == nil,
+		PageID:       page.GetPageID(),
+		Attempts:     1,
+		RestoredTabs: 1,
+		Duration:     time.Since(start),
+		Time:         time.Now(),
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	onCrash(event)
+}
+
+func isBlankURL(url string) bool {
+	return url == "" || url == "about:blank" || url == "chrome://new-tab-page/" || url == "chrome://newtab/"
+}
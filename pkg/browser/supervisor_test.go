@@ -0,0 +1,37 @@
+package browser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrowserManager_EmitRecovery(t *testing.T) {
+	m := NewBrowserManagerWithOptions(DefaultOptions())
+
+	received := make([]RecoveryEvent, 0)
+	unsubscribe := m.OnRecovery(func(event RecoveryEvent) {
+		received = append(received, event)
+	})
+
+	m.emitRecovery(RecoveryEvent{Type: RecoveryBrowser, Success: true, RestoredTabs: 3, Time: time.Now()})
+	unsubscribe()
+
+	for i := 0; i < maxRecoveryEvents+5; i++ {
+		m.emitRecovery(RecoveryEvent{Type: RecoveryTab, Success: i%2 == 0, Time: time.Now()})
+	}
+
+	assert.Len(t, received, 1)
+	assert.Equal(t, 3, received[0].RestoredTabs)
+
+	events := m.GetRecoveryEvents()
+	assert.Len(t, events, maxRecoveryEvents)
+	assert.Equal(t, RecoveryTab, events[0].Type)
+}
+
+func TestIsBlankURL(t *testing.T) {
+	assert.True(t, isBlankURL("about:blank"))
+	assert.True(t, isBlankURL("chrome://new-tab-page/"))
+	assert.False(t, isBlankURL("https://example.com"))
+}
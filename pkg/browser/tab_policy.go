@@ -0,0 +1,280 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTabPolicyInterval  = 30 * time.Second
+	minTabPolicyCheckInterval = time.Second
+	jsHeapUsedSizeMetricName  = "JSHeapUsedSize"
+	// jsHeapQueryTimeout 单个页面查询 JS 堆内存的超时时间, 卡死的页面不计入总量
+	jsHeapQueryTimeout = 3 * time.Second
+)
+
+// TabPolicy 标签页生命周期策略, 固定页面和当前活动页面不会被关闭
+// 数量, 空闲和内存回收默认都不开启, 需要显式设置
+type TabPolicy struct {
+	// MaxTabs 最多打开的标签页数量, 超出时关闭最久未使用的, 0 表示不限制
+	MaxTabs int
+	// IdleTimeout 标签页空闲超过该时间后关闭, 0 表示不限制
+	IdleTimeout time.Duration
+	// JSHeapThresholdMB 所有标签页已使用的JS堆内存之和超过该值时按最久未使用依次关闭, 0 表示不限制
+	// 只统计 JS 堆, 不包含 DOM, 图片和渲染进程的其他内存, 仅 Chromium 有效
+	JSHeapThresholdMB int
+	// CheckInterval 空闲和内存检查间隔
+	CheckInterval time.Duration
+	// ActivatePopups 弹出页面(window.open, target=_blank)是否自动成为活动页面
+	ActivatePopups bool
+	// Watchdog 卡死和崩溃检测
+	Watchdog WatchdogPolicy
+}
+
+// DefaultTabPolicy 默认标签页策略, 不主动关闭标签页
+func DefaultTabPolicy() TabPolicy {
+	return TabPolicy{
+		CheckInterval:  defaultTabPolicyInterval,
+		ActivatePopups: true,
+		Watchdog:       DefaultWatchdogPolicy(),
+	}
+}
+
+// SetTabPolicy 设置标签页策略, 第一次设置时启动后台检查
+func (h *BrowserHandler) SetTabPolicy(policy TabPolicy) {
+	if policy.CheckInterval <= 0 {
+		policy.CheckInterval = defaultTabPolicyInterval
+	}
+
+	policy.CheckInterval = max(policy.CheckInterval, minTabPolicyCheckInterval)
+
+	h.policyMux.Lock()
+	h.tabPolicy = &policy
+	started := h.policyStarted
+	h.policyStarted = true
+	h.policyMux.Unlock()
+
+	if !started {
+		go h.runTabPolicy()
+		go h.runWatchdog()
+	}
+
+	go h.enforceMaxTabs()
+}
+
+// GetTabPolicy 返回当前的标签页策略, 未设置时返回 nil
+func (h *BrowserHandler) GetTabPolicy() *TabPolicy {
+	h.policyMux.Lock()
+	defer h.policyMux.Unlock()
+
+	if h.tabPolicy == nil {
+		return nil
+	}
+
+	policy := *h.tabPolicy
+
+	return &policy
+}
+
+func (h *BrowserHandler) runTabPolicy() {
+	for {
+		policy := h.GetTabPolicy()
+		time.Sleep(policy.CheckInterval)
+
+		if h.IsClosed() {
+			return
+		}
+
+		h.evictIdleTabs()
+		h.evictByJSHeap()
+	}
+}
+
+// enforceMaxTabs 标签页数量超过上限时关闭最久未使用的
+// 每个新页面都会触发一次, 回收需要串行执行, 否则并发的检查会按同一个数量重复关闭
+func (h *BrowserHandler) enforceMaxTabs() {
+	policy := h.GetTabPolicy()
+	if policy == nil || policy.MaxTabs <= 0 {
+		return
+	}
+
+	h.evictMux.Lock()
+	defer h.evictMux.Unlock()
+
+	over := h.pageList.Count() - policy.MaxTabs
+	if over <= 0 {
+		return
+	}
+
+	for _, page := range h.pageList.GetEvictablePages() {
+		if over <= 0 {
+			break
+		}
+
+		log.Infof("tab limit %d exceeded, closing least recently used page %s", policy.MaxTabs, page.GetPageID())
+		h.evict(page)
+		over--
+	}
+}
+
+func (h *BrowserHandler) evictIdleTabs() {
+	policy := h.GetTabPolicy()
+	if policy == nil || policy.IdleTimeout <= 0 {
+		return
+	}
+
+	h.evictMux.Lock()
+	defer h.evictMux.Unlock()
+
+	for _, page := range h.pageList.GetEvictablePages() {
+		idle := time.Since(page.GetLastUsed())
+		if idle <= policy.IdleTimeout {
+			// 按最近使用时间排序, 后面的页面更新
+			break
+		}
+
+		log.Infof("page %s idle for %v, closing", page.GetPageID(), idle.Round(time.Second))
+		h.evict(page)
+	}
+}
+
+// evictByJSHeap JS堆内存超过阈值时按最久未使用依次关闭, 直到低于阈值
+// 查询内存可能很慢, 只在关闭页面时持有 evictMux
+func (h *BrowserHandler) evictByJSHeap() {
+	policy := h.GetTabPolicy()
+	if policy == nil || policy.JSHeapThresholdMB <= 0 || h.Engine() != EngineChromium {
+		return
+	}
+
+	threshold := int64(policy.JSHeapThresholdMB) << 20
+
+	usage := jsHeapUsage(h.pageList.GetPages(), jsHeapQueryTimeout)
+
+	var total int64
+	for _, size := range usage {
+		total += size
+	}
+
+	if total <= threshold {
+		return
+	}
+
+	h.evictMux.Lock()
+	defer h.evictMux.Unlock()
+
+	for _, page := range h.pageList.GetEvictablePages() {
+		if total <= threshold {
+			break
+		}
+
+		log.Infof("js heap %dMB over threshold %dMB, closing page %s", total>>20, policy.JSHeapThresholdMB, page.GetPageID())
+		h.evict(page)
+		total -= usage[page.GetPageID()]
+	}
+}
+
+// jsHeapUsage 并发查询每个页面已使用的JS堆内存, 查询失败或超时的页面不在结果中
+func jsHeapUsage(pages []*PageHandler, timeout time.Duration) map[string]int64 {
+	usage := make(map[string]int64, len(pages))
+	var mux sync.Mutex
+
+	var wg sync.WaitGroup
+	for _, page := range pages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			size, err := page.JSHeapUsedSize(timeout)
+			if err != nil {
+				log.Errorf("get js heap size of page %s error: %v", page.GetPageID(), err)
+				return
+			}
+
+			mux.Lock()
+			usage[page.GetPageID()] = size
+			mux.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return usage
+}
+
+func (h *BrowserHandler) evict(page *PageHandler) {
+	page.Close()
+	h.pageList.RemovePage(page.GetPageID())
+}
+
+// JSHeapUsedSize 通过CDP获取页面已使用的JS堆内存字节数, 超过 timeout 未返回时返回错误
+// 上一次查询还未返回时不再重复查询
+func (h *PageHandler) JSHeapUsedSize(timeout time.Duration) (int64, error) {
+	if err := h.requireCDP(); err != nil {
+		return 0, err
+	}
+
+	if !h.measuring.CompareAndSwap(false, true) {
+		return 0, errors.New("previous js heap query is still running")
+	}
+
+	type heapReturn struct {
+		size int64
+		err  error
+	}
+
+	done := make(chan heapReturn, 1)
+	go func() {
+		size, err := h.queryJSHeapUsedSize()
+		h.measuring.Store(false)
+		done <- heapReturn{size, err}
+	}()
+
+	select {
+	case ret := <-done:
+		return ret.size, ret.err
+	case <-time.After(timeout):
+		return 0, fmt.Errorf("js heap query timed out after %v", timeout)
+	}
+}
+
+func (h *PageHandler) queryJSHeapUsedSize() (int64, error) {
+
+	session, err := h.page.Context().NewCDPSession(h.page)
+	if err != nil {
+		return 0, errors.WithMessage(err, "new cdp session error")
+	}
+	defer func() {
+		_ = session.Detach()
+	}()
+
+	_, err = session.Send("Performance.enable", nil)
+	if err != nil {
+		return 0, errors.WithMessage(err, "enable performance metrics error")
+	}
+
+	result, err := session.Send("Performance.getMetrics", nil)
+	if err != nil {
+		return 0, errors.WithMessage(err, "get performance metrics error")
+	}
+
+	data, ok := result.(map[string]interface{})
+	if !ok {
+		return 0, errors.New("unexpected performance metrics result")
+	}
+
+	metrics, _ := data["metrics"].([]interface{})
+	for _, item := range metrics {
+		metric, ok := item.(map[string]interface{})
+		if !ok || metric["name"] != jsHeapUsedSizeMetricName {
+			continue
+		}
+
+		value, _ := metric["value"].(float64)
+
+		return int64(value), nil
+	}
+
+	return 0, errors.New("js heap metric not found")
+}
@@ -0,0 +1,114 @@
+package browser
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTabPolicy(t *testing.T) {
+	// 回收策略需要显式开启
+	policy := DefaultTabPolicy()
+	assert.Zero(t, policy.MaxTabs)
+	assert.Zero(t, policy.IdleTimeout)
+	assert.Zero(t, policy.JSHeapThresholdMB)
+}
+
+func TestBrowserHandler_EnforceMaxTabs(t *testing.T) {
+	pages := make([]*fakePage, 6)
+	list := make([]playwright.Page, len(pages))
+	for i := range pages {
+		pages[i] = &fakePage{}
+		list[i] = pages[i]
+	}
+
+	h := newFakeBrowserHandler(&fakeBrowser{}, &fakeContext{}, list...)
+	h.tabPolicy = &TabPolicy{MaxTabs: 3}
+	require.True(t, h.pageList.SetActivePage("page-1"))
+	h.pageList.GetPageByID("page-2").SetPinned(true)
+
+	// 每个新页面都会触发一次检查, 并发的检查不能关闭多余的页面
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.enforceMaxTabs()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, h.pageList.Count())
+
+	// 活动页面和固定页面保留, 其余按最久未使用关闭
+	for i, closed := range []int{0, 0, 1, 1, 1, 0} {
+		assert.Equal(t, closed, pages[i].closeCount(), "page-%d", i+1)
+	}
+}
+
+func TestBrowserHandler_EvictByJSHeap(t *testing.T) {
+	hang, querying := make(chan struct{}), make(chan struct{})
+	defer close(hang)
+
+	browser := &fakeBrowser{engine: EngineChromium}
+	ctx := &fakeContext{browser: browser}
+	pages := []*fakePage{{context: ctx}, {context: ctx}, {context: ctx}, {context: ctx}}
+
+	// page-1 卡死, 其余每个页面使用 300MB
+	ctx.cdpSend = func(page playwright.Page, method string) (interface{}, error) {
+		if page == pages[0] && method == "Performance.enable" {
+			close(querying)
+			<-hang
+		}
+		if method != "Performance.getMetrics" {
+			return nil, nil
+		}
+		return map[string]interface{}{"metrics": []interface{}{
+			map[string]interface{}{"name": jsHeapUsedSizeMetricName, "value": float64(300 << 20)},
+		}}, nil
+	}
+
+	list := make([]playwright.Page, len(pages))
+	for i := range pages {
+		list[i] = pages[i]
+	}
+	h := newFakeBrowserHandler(browser, ctx, list...)
+	h.tabPolicy = &TabPolicy{JSHeapThresholdMB: 500, MaxTabs: 3}
+	require.True(t, h.pageList.SetActivePage("page-4"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.evictByJSHeap()
+	}()
+
+	// 查询内存时不持有回收锁, 新页面触发的数量检查不会被卡死的页面阻塞
+	<-querying
+	enforced := make(chan struct{})
+	go func() {
+		defer close(enforced)
+		h.enforceMaxTabs()
+	}()
+
+	select {
+	case <-enforced:
+	case <-time.After(time.Second):
+		t.Fatal("enforceMaxTabs blocked by js heap query")
+	}
+	assert.Equal(t, 1, pages[0].closeCount())
+
+	// 卡死的页面超时后不计入总量, 900MB 超过阈值, 关闭最久未使用的 page-2 后为 600MB, 再关闭 page-3
+	select {
+	case <-done:
+	case <-time.After(2 * jsHeapQueryTimeout):
+		t.Fatal("evictByJSHeap blocked by hung page")
+	}
+
+	assert.Equal(t, 1, pages[1].closeCount())
+	assert.Equal(t, 1, pages[2].closeCount())
+	assert.Equal(t, 0, pages[3].closeCount())
+}
@@ -0,0 +1,232 @@
+package browser
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+	"cmp"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+const (
+	// TabStateOK 页面正常响应
+	TabStateOK = "ok"
+	// TabStateHung 页面主线程长时间无响应
+	TabStateHung = "hung"
+	// TabStateCrashed 页面渲染进程崩溃
+	TabStateCrashed = "crashed"
+
+	// HungActionNone 只标记, 截图等操作直接返回错误
+	HungActionNone = "none"
+	// HungActionReload 重新加载页面
+	HungActionReload = "reload"
+	// HungActionClose 关闭页面, 固定页面不会被关闭
+	HungActionClose = "close"
+
+	// CrashActionReopen 在新标签页中重新打开崩溃的页面
+	CrashActionReopen = "reopen"
+	// CrashActionClose 关闭崩溃的页面
+	CrashActionClose = "close"
+	// CrashActionNone 只标记
+	CrashActionNone = "none"
+
+	defaultWatchdogInterval      = 5 * time.Second
+	defaultWatchdogProbeTimeout  = 3 * time.Second
+	defaultWatchdogHungThreshold = 2
+	watchdogReloadTimeout        = 10 * time.Second
+
+	probeScript = "1"
+)
+
+var errProbeTimeout = errors.New("probe timeout")
+
+// WatchdogPolicy 页面看门狗策略, 定期在每个页面执行一个简单脚本检测是否卡死
+type WatchdogPolicy struct {
+	// Enabled 关闭时不检测卡死, 崩溃的页面只标记
+	Enabled bool
+	// Interval 检测间隔
+	Interval time.Duration
+	// ProbeTimeout 单次检测超时时间
+	ProbeTimeout time.Duration
+	// HungThreshold 连续多少次超时后认为页面卡死
+	HungThreshold int
+	// OnHung 页面卡死后的处理: none, reload 或 close
+	OnHung string
+	// OnCrash 渲染进程崩溃后的处理: reopen, close 或 none
+	OnCrash string
+}
+
+// DefaultWatchdogPolicy 默认看门狗策略, 需要显式开启
+func DefaultWatchdogPolicy() WatchdogPolicy {
+	return WatchdogPolicy{
+		Enabled:       false,
+		Interval:      defaultWatchdogInterval,
+		ProbeTimeout:  defaultWatchdogProbeTimeout,
+		HungThreshold: defaultWatchdogHungThreshold,
+		OnHung:        HungActionNone,
+		OnCrash:       CrashActionReopen,
+	}
+}
+
+// GetState 返回页面状态
+func (h *PageHandler) GetState() string {
+	if state, ok := h.state.Load().(string); ok {
+		return state
+	}
+
+	return TabStateOK
+}
+
+func (h *PageHandler) setState(state string) {
+	h.state.Store(state)
+}
+
+// checkResponsive 卡死或崩溃的页面直接返回错误, 避免请求一直等到超时
+func (h *PageHandler) checkResponsive() error {
+	switch state := h.GetState(); state {
+	case TabStateHung, TabStateCrashed:
+		return errors.WithMessagef(errors.ErrPageUnresponsive, "page %s is %s", h.pageID, state)
+	}
+
+	return nil
+}
+
+// Probe 在页面中执行一个简单脚本, 超过 timeout 未返回时返回 errProbeTimeout
+// 上一次检测还未返回时不再重复执行, 直接认为超时
+func (h *PageHandler) Probe(timeout time.Duration) error {
+	if !h.probing.CompareAndSwap(false, true) {
+		return errProbeTimeout
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := h.page.Evaluate(probeScript)
+		h.probing.Store(false)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return errProbeTimeout
+	}
+}
+
+func (h *BrowserHandler) runWatchdog() {
+	for {
+		policy := h.GetTabPolicy().Watchdog
+
+		interval := policy.Interval
+		if interval <= 0 {
+			interval = defaultWatchdogInterval
+		}
+
+		time.Sleep(interval)
+
+		if h.IsClosed() {
+			return
+		}
+
+		if !policy.Enabled {
+			continue
+		}
+
+		// 每个页面单独检测, 一个页面卡死不影响其他页面
+		for _, page := range h.pageList.GetPages() {
+			if page.IsClosed() || page.GetState() == TabStateCrashed {
+				continue
+			}
+
+			go h.probePage(page, policy)
+		}
+	}
+}
+
+func (h *BrowserHandler) probePage(page *PageHandler, policy WatchdogPolicy) {
+	timeout := policy.ProbeTimeout
+	if timeout <= 0 {
+		timeout = defaultWatchdogProbeTimeout
+	}
+
+	err := page.Probe(timeout)
+	if page.IsClosed() {
+		return
+	}
+
+	// 导航过程中执行上下文被销毁等错误不代表页面卡死
+	if err != errProbeTimeout {
+		page.probeFailures.Store(0)
+
+		if page.GetState() == TabStateHung {
+			page.setState(TabStateOK)
+			log.Infof("page %s is responsive again", page.GetPageID())
+		}
+		return
+	}
+
+	failures := page.probeFailures.Add(1)
+	if int(failures) < max(policy.HungThreshold, 1) || page.GetState() == TabStateHung {
+		return
+	}
+
+	page.setState(TabStateHung)
+	metricWatchdogTotal.WithLabelValues(TabStateHung).Inc()
+	log.Errorf("page %s not responding for %d probes, action %s", page.GetPageID(), failures, policy.OnHung)
+
+	switch policy.OnHung {
+	case HungActionReload:
+		h.reloadHungPage(page)
+	case HungActionClose:
+		if page.IsPinned() {
+			log.Infof("page %s is pinned, keep it open", page.GetPageID())
+			return
+		}
+
+		metricWatchdogTotal.WithLabelValues(HungActionClose).Inc()
+		h.evict(page)
+	}
+}
+
+func (h *BrowserHandler) reloadHungPage(page *PageHandler) {
+	metricWatchdogTotal.WithLabelValues(HungActionReload).Inc()
+
+	_, err := page.GetPage().Reload(playwright.PageReloadOptions{
+		Timeout: playwright.Float(float64(watchdogReloadTimeout.Milliseconds())),
+	})
+	if err != nil {
+		log.Errorf("reload hung page %s error: %v", page.GetPageID(), err)
+		return
+	}
+
+	page.probeFailures.Store(0)
+	page.setState(TabStateOK)
+	log.Infof("hung page %s reloaded", page.GetPageID())
+}
+
+// OnCrashPage 标记崩溃的页面, 并按看门狗策略重新打开或关闭
+func (h *BrowserHandler) OnCrashPage(pageID string) {
+	page := h.pageList.GetPageByID(pageID)
+	if page == nil {
+		return
+	}
+
+	page.setState(TabStateCrashed)
+	metricWatchdogTotal.WithLabelValues(TabStateCrashed).Inc()
+	h.emit(BrowserEvent{Type: EventPageCrashed, PageID: pageID, URL: page.GetPage().URL(), Message: "renderer crashed"})
+
+	// 没有开启看门狗时只标记, 不自动打开或关闭标签页
+	action := CrashActionNone
+	if policy := h.GetTabPolicy(); policy != nil && policy.Watchdog.Enabled {
+		action = cmp.Or(policy.Watchdog.OnCrash, CrashActionReopen)
+	}
+
+	// 事件回调中不能同步调用 playwright
+	switch action {
+	case CrashActionReopen:
+		go h.recoverCrashedTab(pageID)
+	case CrashActionClose:
+		go h.evict(page)
+	}
+}
@@ -0,0 +1,132 @@
+package browser
+
+import (
+	"browsertools/pkg/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageHandler_CheckResponsive(t *testing.T) {
+	h := &PageHandler{pageID: "page-1"}
+	assert.Equal(t, TabStateOK, h.GetState())
+	assert.NoError(t, h.checkResponsive())
+
+	h.setState(TabStateHung)
+	assert.True(t, errors.EqualCodeError(h.checkResponsive(), errors.ErrPageUnresponsive))
+
+	h.setState(TabStateCrashed)
+	assert.True(t, errors.EqualCodeError(h.checkResponsive(), errors.ErrPageUnresponsive))
+
+	h.setState(TabStateOK)
+	assert.NoError(t, h.checkResponsive())
+}
+
+func TestDefaultWatchdogPolicy(t *testing.T) {
+	// 看门狗需要显式开启
+	policy := DefaultWatchdogPolicy()
+	assert.False(t, policy.Enabled)
+	assert.Equal(t, CrashActionReopen, policy.OnCrash)
+}
+
+func TestBrowserHandler_ProbePage(t *testing.T) {
+	release := make(chan struct{})
+	page := &fakePage{evaluate: func(string, ...interface{}) (interface{}, error) {
+		<-release
+		return 1, nil
+	}}
+
+	h := newFakeBrowserHandler(&fakeBrowser{}, &fakeContext{}, page)
+	handler := h.pageList.GetPageByID("page-1")
+	policy := WatchdogPolicy{Enabled: true, ProbeTimeout: 20 * time.Millisecond, HungThreshold: 2, OnHung: HungActionNone}
+
+	// 第一次超时还不算卡死, 上一次检测未返回时直接算作超时
+	h.probePage(handler, policy)
+	assert.Equal(t, TabStateOK, handler.GetState())
+	h.probePage(handler, policy)
+	assert.Equal(t, TabStateHung, handler.GetState())
+	assert.Error(t, handler.checkResponsive())
+
+	// 页面恢复响应后清除标记
+	close(release)
+	require.Eventually(t, func() bool { return !handler.probing.Load() }, time.Second, 5*time.Millisecond)
+	h.probePage(handler, policy)
+	assert.Equal(t, TabStateOK, handler.GetState())
+	assert.Zero(t, handler.probeFailures.Load())
+	assert.Equal(t, 0, page.closeCount())
+}
+
+func TestBrowserHandler_ProbePageClose(t *testing.T) {
+	hang := make(chan struct{})
+	defer close(hang)
+
+	evaluate := func(string, ...interface{}) (interface{}, error) {
+		<-hang
+		return 1, nil
+	}
+	pinned, unpinned := &fakePage{evaluate: evaluate}, &fakePage{evaluate: evaluate}
+
+	h := newFakeBrowserHandler(&fakeBrowser{}, &fakeContext{}, pinned, unpinned)
+	h.pageList.GetPageByID("page-1").SetPinned(true)
+	policy := WatchdogPolicy{Enabled: true, ProbeTimeout: 10 * time.Millisecond, HungThreshold: 1, OnHung: HungActionClose}
+
+	for _, page := range h.pageList.GetPages() {
+		h.probePage(page, policy)
+	}
+
+	// 固定的页面只标记, 不关闭
+	assert.Equal(t, 0, pinned.closeCount())
+	assert.Equal(t, 1, unpinned.closeCount())
+	assert.NotNil(t, h.pageList.GetPageByID("page-1"))
+	assert.Nil(t, h.pageList.GetPageByID("page-2"))
+}
+
+func TestBrowserHandler_OnCrashPage(t *testing.T) {
+	newHandler := func(policy *TabPolicy) (*BrowserHandler, *fakeContext, *fakePage) {
+		browser := &fakeBrowser{engine: EngineFirefox}
+		ctx := &fakeContext{browser: browser}
+		page := &fakePage{context: ctx, url: "https://example.com/app"}
+
+		h := newFakeBrowserHandler(browser, ctx, page)
+		h.tabPolicy = policy
+		require.True(t, h.pageList.SetActivePage("page-1"))
+		h.pageList.GetPageByID("page-1").SetPinned(true)
+
+		return h, ctx, page
+	}
+
+	// 没有开启看门狗时只标记崩溃
+	disabled := DefaultTabPolicy()
+	h, ctx, page := newHandler(&disabled)
+	h.OnCrashPage("page-1")
+	assert.Equal(t, TabStateCrashed, h.pageList.GetPageByID("page-1").GetState())
+	assert.Empty(t, ctx.createdPages())
+	assert.Equal(t, 0, page.closeCount())
+
+	// 在新标签页中重新打开崩溃的页面, 保留固定状态和活动页面
+	enabled := DefaultTabPolicy()
+	enabled.Watchdog.Enabled = true
+	h, ctx, page = newHandler(&enabled)
+	h.OnCrashPage("page-1")
+
+	require.Eventually(t, func() bool { return page.closeCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, ctx.createdPages(), 1)
+	assert.Eventually(t, func() bool { return ctx.createdPages()[0].URL() == "https://example.com/app" }, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, h.pageList.GetPageByID("page-1"))
+
+	pages := h.pageList.GetPages()
+	require.Len(t, pages, 1)
+	assert.True(t, pages[0].IsPinned())
+	assert.Equal(t, pages[0], h.pageList.GetActivePage())
+
+	// close 关闭崩溃的页面, 不重新打开
+	closing := enabled
+	closing.Watchdog.OnCrash = CrashActionClose
+	h, ctx, page = newHandler(&closing)
+	h.OnCrashPage("page-1")
+
+	require.Eventually(t, func() bool { return page.closeCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, ctx.createdPages())
+}
@@ -0,0 +1,1055 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: browsertools/v1/browser.proto
+
+package browserpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	BrowserService_CreateSession_FullMethodName    = "/browsertools.v1.BrowserService/CreateSession"
+	BrowserService_CloseSession_FullMethodName     = "/browsertools.v1.BrowserService/CloseSession"
+	BrowserService_ListSessions_FullMethodName     = "/browsertools.v1.BrowserService/ListSessions"
+	BrowserService_OpenTab_FullMethodName          = "/browsertools.v1.BrowserService/OpenTab"
+	BrowserService_ListTabs_FullMethodName         = "/browsertools.v1.BrowserService/ListTabs"
+	BrowserService_PinTab_FullMethodName           = "/browsertools.v1.BrowserService/PinTab"
+	BrowserService_CloseTab_FullMethodName         = "/browsertools.v1.BrowserService/CloseTab"
+	BrowserService_Screenshot_FullMethodName       = "/browsertools.v1.BrowserService/Screenshot"
+	BrowserService_GetConsoleLogs_FullMethodName   = "/browsertools.v1.BrowserService/GetConsoleLogs"
+	BrowserService_Markdown_FullMethodName         = "/browsertools.v1.BrowserService/Markdown"
+	BrowserService_Extract_FullMethodName          = "/browsertools.v1.BrowserService/Extract"
+	BrowserService_Run_FullMethodName              = "/browsertools.v1.BrowserService/Run"
+	BrowserService_StartInteraction_FullMethodName = "/browsertools.v1.BrowserService/StartInteraction"
+	BrowserService_StopInteraction_FullMethodName  = "/browsertools.v1.BrowserService/StopInteraction"
+	BrowserService_StartRecording_FullMethodName   = "/browsertools.v1.BrowserService/StartRecording"
+	BrowserService_StopRecording_FullMethodName    = "/browsertools.v1.BrowserService/StopRecording"
+	BrowserService_ListRecordings_FullMethodName   = "/browsertools.v1.BrowserService/ListRecordings"
+	BrowserService_VisualCompare_FullMethodName    = "/browsertools.v1.BrowserService/VisualCompare"
+	BrowserService_VisualApprove_FullMethodName    = "/browsertools.v1.BrowserService/VisualApprove"
+	BrowserService_ListBaselines_FullMethodName    = "/browsertools.v1.BrowserService/ListBaselines"
+	BrowserService_LeaseBrowser_FullMethodName     = "/browsertools.v1.BrowserService/LeaseBrowser"
+	BrowserService_ReturnBrowser_FullMethodName    = "/browsertools.v1.BrowserService/ReturnBrowser"
+	BrowserService_PoolStatus_FullMethodName       = "/browsertools.v1.BrowserService/PoolStatus"
+	BrowserService_WatchPageEvents_FullMethodName  = "/browsertools.v1.BrowserService/WatchPageEvents"
+	BrowserService_StreamScreencast_FullMethodName = "/browsertools.v1.BrowserService/StreamScreencast"
+)
+
+// BrowserServiceClient is the client API for BrowserService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BrowserService mirrors the /browser HTTP API and shares its browsers and sessions.
+// Fields have the same names, defaults and validation rules as the HTTP request bodies.
+// Errors are returned as gRPC status codes; the original error code is in the
+// "browsertools-error-code" response trailer.
+type BrowserServiceClient interface {
+	CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*Session, error)
+	CloseSession(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	OpenTab(ctx context.Context, in *OpenTabRequest, opts ...grpc.CallOption) (*Tab, error)
+	ListTabs(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*ListTabsResponse, error)
+	PinTab(ctx context.Context, in *PinTabRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CloseTab(ctx context.Context, in *TabRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	Screenshot(ctx context.Context, in *ScreenshotRequest, opts ...grpc.CallOption) (*Image, error)
+	GetConsoleLogs(ctx context.Context, in *TabRequest, opts ...grpc.CallOption) (*ConsoleLogs, error)
+	Markdown(ctx context.Context, in *MarkdownRequest, opts ...grpc.CallOption) (*MarkdownResponse, error)
+	Extract(ctx context.Context, in *ExtractRequest, opts ...grpc.CallOption) (*ExtractResponse, error)
+	Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error)
+	StartInteraction(ctx context.Context, in *TabRequest, opts ...grpc.CallOption) (*Interaction, error)
+	StopInteraction(ctx context.Context, in *StopInteractionRequest, opts ...grpc.CallOption) (*Interaction, error)
+	StartRecording(ctx context.Context, in *StartRecordingRequest, opts ...grpc.CallOption) (*Recording, error)
+	StopRecording(ctx context.Context, in *RecordingRequest, opts ...grpc.CallOption) (*Recording, error)
+	ListRecordings(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListRecordingsResponse, error)
+	VisualCompare(ctx context.Context, in *VisualCompareRequest, opts ...grpc.CallOption) (*VisualCompareResponse, error)
+	VisualApprove(ctx context.Context, in *VisualApproveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ListBaselines(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListBaselinesResponse, error)
+	LeaseBrowser(ctx context.Context, in *LeaseBrowserRequest, opts ...grpc.CallOption) (*Lease, error)
+	ReturnBrowser(ctx context.Context, in *ReturnBrowserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	PoolStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PoolStatusResponse, error)
+	// WatchPageEvents streams console messages and network events of a tab until the tab is closed.
+	WatchPageEvents(ctx context.Context, in *WatchPageEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PageEvent], error)
+	// StreamScreencast streams JPEG frames of the active tab, following tab switches.
+	StreamScreencast(ctx context.Context, in *StreamScreencastRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScreencastFrame], error)
+}
+
+type browserServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBrowserServiceClient(cc grpc.ClientConnInterface) BrowserServiceClient {
+	return &browserServiceClient{cc}
+}
+
+func (c *browserServiceClient) CreateSession(ctx context.Context, in *CreateSessionRequest, opts ...grpc.CallOption) (*Session, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Session)
+	err := c.cc.Invoke(ctx, BrowserService_CreateSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) CloseSession(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, BrowserService_CloseSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) ListSessions(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, BrowserService_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) OpenTab(ctx context.Context, in *OpenTabRequest, opts ...grpc.CallOption) (*Tab, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Tab)
+	err := c.cc.Invoke(ctx, BrowserService_OpenTab_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) ListTabs(ctx context.Context, in *SessionRequest, opts ...grpc.CallOption) (*ListTabsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTabsResponse)
+	err := c.cc.Invoke(ctx, BrowserService_ListTabs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) PinTab(ctx context.Context, in *PinTabRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, BrowserService_PinTab_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) CloseTab(ctx context.Context, in *TabRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, BrowserService_CloseTab_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) Screenshot(ctx context.Context, in *ScreenshotRequest, opts ...grpc.CallOption) (*Image, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Image)
+	err := c.cc.Invoke(ctx, BrowserService_Screenshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) GetConsoleLogs(ctx context.Context, in *TabRequest, opts ...grpc.CallOption) (*ConsoleLogs, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConsoleLogs)
+	err := c.cc.Invoke(ctx, BrowserService_GetConsoleLogs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) Markdown(ctx context.Context, in *MarkdownRequest, opts ...grpc.CallOption) (*MarkdownResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MarkdownResponse)
+	err := c.cc.Invoke(ctx, BrowserService_Markdown_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) Extract(ctx context.Context, in *ExtractRequest, opts ...grpc.CallOption) (*ExtractResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExtractResponse)
+	err := c.cc.Invoke(ctx, BrowserService_Extract_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) Run(ctx context.Context, in *RunRequest, opts ...grpc.CallOption) (*RunResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunResponse)
+	err := c.cc.Invoke(ctx, BrowserService_Run_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) StartInteraction(ctx context.Context, in *TabRequest, opts ...grpc.CallOption) (*Interaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Interaction)
+	err := c.cc.Invoke(ctx, BrowserService_StartInteraction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) StopInteraction(ctx context.Context, in *StopInteractionRequest, opts ...grpc.CallOption) (*Interaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Interaction)
+	err := c.cc.Invoke(ctx, BrowserService_StopInteraction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) StartRecording(ctx context.Context, in *StartRecordingRequest, opts ...grpc.CallOption) (*Recording, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Recording)
+	err := c.cc.Invoke(ctx, BrowserService_StartRecording_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) StopRecording(ctx context.Context, in *RecordingRequest, opts ...grpc.CallOption) (*Recording, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Recording)
+	err := c.cc.Invoke(ctx, BrowserService_StopRecording_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) ListRecordings(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListRecordingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRecordingsResponse)
+	err := c.cc.Invoke(ctx, BrowserService_ListRecordings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) VisualCompare(ctx context.Context, in *VisualCompareRequest, opts ...grpc.CallOption) (*VisualCompareResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VisualCompareResponse)
+	err := c.cc.Invoke(ctx, BrowserService_VisualCompare_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) VisualApprove(ctx context.Context, in *VisualApproveRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, BrowserService_VisualApprove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) ListBaselines(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ListBaselinesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBaselinesResponse)
+	err := c.cc.Invoke(ctx, BrowserService_ListBaselines_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) LeaseBrowser(ctx context.Context, in *LeaseBrowserRequest, opts ...grpc.CallOption) (*Lease, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Lease)
+	err := c.cc.Invoke(ctx, BrowserService_LeaseBrowser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) ReturnBrowser(ctx context.Context, in *ReturnBrowserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, BrowserService_ReturnBrowser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) PoolStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*PoolStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PoolStatusResponse)
+	err := c.cc.Invoke(ctx, BrowserService_PoolStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *browserServiceClient) WatchPageEvents(ctx context.Context, in *WatchPageEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PageEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BrowserService_ServiceDesc.Streams[0], BrowserService_WatchPageEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchPageEventsRequest, PageEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BrowserService_WatchPageEventsClient = grpc.ServerStreamingClient[PageEvent]
+
+func (c *browserServiceClient) StreamScreencast(ctx context.Context, in *StreamScreencastRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScreencastFrame], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BrowserService_ServiceDesc.Streams[1], BrowserService_StreamScreencast_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamScreencastRequest, ScreencastFrame]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BrowserService_StreamScreencastClient = grpc.ServerStreamingClient[ScreencastFrame]
+
+// BrowserServiceServer is the server API for BrowserService service.
+// All implementations must embed UnimplementedBrowserServiceServer
+// for forward compatibility.
+//
+// BrowserService mirrors the /browser HTTP API and shares its browsers and sessions.
+// Fields have the same names, defaults and validation rules as the HTTP request bodies.
+// Errors are returned as gRPC status codes; the original error code is in the
+// "browsertools-error-code" response trailer.
+type BrowserServiceServer interface {
+	CreateSession(context.Context, *CreateSessionRequest) (*Session, error)
+	CloseSession(context.Context, *SessionRequest) (*emptypb.Empty, error)
+	ListSessions(context.Context, *emptypb.Empty) (*ListSessionsResponse, error)
+	OpenTab(context.Context, *OpenTabRequest) (*Tab, error)
+	ListTabs(context.Context, *SessionRequest) (*ListTabsResponse, error)
+	PinTab(context.Context, *PinTabRequest) (*emptypb.Empty, error)
+	CloseTab(context.Context, *TabRequest) (*emptypb.Empty, error)
+	Screenshot(context.Context, *ScreenshotRequest) (*Image, error)
+	GetConsoleLogs(context.Context, *TabRequest) (*ConsoleLogs, error)
+	Markdown(context.Context, *MarkdownRequest) (*MarkdownResponse, error)
+	Extract(context.Context, *ExtractRequest) (*ExtractResponse, error)
+	Run(context.Context, *RunRequest) (*RunResponse, error)
+	StartInteraction(context.Context, *TabRequest) (*Interaction, error)
+	StopInteraction(context.Context, *StopInteractionRequest) (*Interaction, error)
+	StartRecording(context.Context, *StartRecordingRequest) (*Recording, error)
+	StopRecording(context.Context, *RecordingRequest) (*Recording, error)
+	ListRecordings(context.Context, *emptypb.Empty) (*ListRecordingsResponse, error)
+	VisualCompare(context.Context, *VisualCompareRequest) (*VisualCompareResponse, error)
+	VisualApprove(context.Context, *VisualApproveRequest) (*emptypb.Empty, error)
+	ListBaselines(context.Context, *emptypb.Empty) (*ListBaselinesResponse, error)
+	LeaseBrowser(context.Context, *LeaseBrowserRequest) (*Lease, error)
+	ReturnBrowser(context.Context, *ReturnBrowserRequest) (*emptypb.Empty, error)
+	PoolStatus(context.Context, *emptypb.Empty) (*PoolStatusResponse, error)
+	// WatchPageEvents streams console messages and network events of a tab until the tab is closed.
+	WatchPageEvents(*WatchPageEventsRequest, grpc.ServerStreamingServer[PageEvent]) error
+	// StreamScreencast streams JPEG frames of the active tab, following tab switches.
+	StreamScreencast(*StreamScreencastRequest, grpc.ServerStreamingServer[ScreencastFrame]) error
+	mustEmbedUnimplementedBrowserServiceServer()
+}
+
+// UnimplementedBrowserServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBrowserServiceServer struct{}
+
+func (UnimplementedBrowserServiceServer) CreateSession(context.Context, *CreateSessionRequest) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateSession not implemented")
+}
+func (UnimplementedBrowserServiceServer) CloseSession(context.Context, *SessionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseSession not implemented")
+}
+func (UnimplementedBrowserServiceServer) ListSessions(context.Context, *emptypb.Empty) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedBrowserServiceServer) OpenTab(context.Context, *OpenTabRequest) (*Tab, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenTab not implemented")
+}
+func (UnimplementedBrowserServiceServer) ListTabs(context.Context, *SessionRequest) (*ListTabsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTabs not implemented")
+}
+func (UnimplementedBrowserServiceServer) PinTab(context.Context, *PinTabRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PinTab not implemented")
+}
+func (UnimplementedBrowserServiceServer) CloseTab(context.Context, *TabRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseTab not implemented")
+}
+func (UnimplementedBrowserServiceServer) Screenshot(context.Context, *ScreenshotRequest) (*Image, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Screenshot not implemented")
+}
+func (UnimplementedBrowserServiceServer) GetConsoleLogs(context.Context, *TabRequest) (*ConsoleLogs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConsoleLogs not implemented")
+}
+func (UnimplementedBrowserServiceServer) Markdown(context.Context, *MarkdownRequest) (*MarkdownResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Markdown not implemented")
+}
+func (UnimplementedBrowserServiceServer) Extract(context.Context, *ExtractRequest) (*ExtractResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Extract not implemented")
+}
+func (UnimplementedBrowserServiceServer) Run(context.Context, *RunRequest) (*RunResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Run not implemented")
+}
+func (UnimplementedBrowserServiceServer) StartInteraction(context.Context, *TabRequest) (*Interaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartInteraction not implemented")
+}
+func (UnimplementedBrowserServiceServer) StopInteraction(context.Context, *StopInteractionRequest) (*Interaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopInteraction not implemented")
+}
+func (UnimplementedBrowserServiceServer) StartRecording(context.Context, *StartRecordingRequest) (*Recording, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartRecording not implemented")
+}
+func (UnimplementedBrowserServiceServer) StopRecording(context.Context, *RecordingRequest) (*Recording, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopRecording not implemented")
+}
+func (UnimplementedBrowserServiceServer) ListRecordings(context.Context, *emptypb.Empty) (*ListRecordingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRecordings not implemented")
+}
+func (UnimplementedBrowserServiceServer) VisualCompare(context.Context, *VisualCompareRequest) (*VisualCompareResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VisualCompare not implemented")
+}
+func (UnimplementedBrowserServiceServer) VisualApprove(context.Context, *VisualApproveRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VisualApprove not implemented")
+}
+func (UnimplementedBrowserServiceServer) ListBaselines(context.Context, *emptypb.Empty) (*ListBaselinesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBaselines not implemented")
+}
+func (UnimplementedBrowserServiceServer) LeaseBrowser(context.Context, *LeaseBrowserRequest) (*Lease, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LeaseBrowser not implemented")
+}
+func (UnimplementedBrowserServiceServer) ReturnBrowser(context.Context, *ReturnBrowserRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReturnBrowser not implemented")
+}
+func (UnimplementedBrowserServiceServer) PoolStatus(context.Context, *emptypb.Empty) (*PoolStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PoolStatus not implemented")
+}
+func (UnimplementedBrowserServiceServer) WatchPageEvents(*WatchPageEventsRequest, grpc.ServerStreamingServer[PageEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPageEvents not implemented")
+}
+func (UnimplementedBrowserServiceServer) StreamScreencast(*StreamScreencastRequest, grpc.ServerStreamingServer[ScreencastFrame]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamScreencast not implemented")
+}
+func (UnimplementedBrowserServiceServer) mustEmbedUnimplementedBrowserServiceServer() {}
+func (UnimplementedBrowserServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeBrowserServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BrowserServiceServer will
+// result in compilation errors.
+type UnsafeBrowserServiceServer interface {
+	mustEmbedUnimplementedBrowserServiceServer()
+}
+
+func RegisterBrowserServiceServer(s grpc.ServiceRegistrar, srv BrowserServiceServer) {
+	// If the following call pancis, it indicates UnimplementedBrowserServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BrowserService_ServiceDesc, srv)
+}
+
+func _BrowserService_CreateSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).CreateSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_CreateSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).CreateSession(ctx, req.(*CreateSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_CloseSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).CloseSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_CloseSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).CloseSession(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).ListSessions(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_OpenTab_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenTabRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).OpenTab(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_OpenTab_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).OpenTab(ctx, req.(*OpenTabRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_ListTabs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).ListTabs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_ListTabs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).ListTabs(ctx, req.(*SessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_PinTab_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinTabRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).PinTab(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_PinTab_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).PinTab(ctx, req.(*PinTabRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_CloseTab_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TabRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).CloseTab(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_CloseTab_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).CloseTab(ctx, req.(*TabRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_Screenshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScreenshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).Screenshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_Screenshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).Screenshot(ctx, req.(*ScreenshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_GetConsoleLogs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TabRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).GetConsoleLogs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_GetConsoleLogs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).GetConsoleLogs(ctx, req.(*TabRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_Markdown_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MarkdownRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).Markdown(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_Markdown_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).Markdown(ctx, req.(*MarkdownRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_Extract_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExtractRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).Extract(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_Extract_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).Extract(ctx, req.(*ExtractRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_Run_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).Run(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_Run_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).Run(ctx, req.(*RunRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_StartInteraction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TabRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).StartInteraction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_StartInteraction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).StartInteraction(ctx, req.(*TabRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_StopInteraction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopInteractionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).StopInteraction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_StopInteraction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).StopInteraction(ctx, req.(*StopInteractionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_StartRecording_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartRecordingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).StartRecording(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_StartRecording_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).StartRecording(ctx, req.(*StartRecordingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_StopRecording_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).StopRecording(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_StopRecording_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).StopRecording(ctx, req.(*RecordingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_ListRecordings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).ListRecordings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_ListRecordings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).ListRecordings(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_VisualCompare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VisualCompareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).VisualCompare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_VisualCompare_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).VisualCompare(ctx, req.(*VisualCompareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_VisualApprove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VisualApproveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).VisualApprove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_VisualApprove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).VisualApprove(ctx, req.(*VisualApproveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_ListBaselines_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).ListBaselines(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_ListBaselines_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).ListBaselines(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_LeaseBrowser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LeaseBrowserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).LeaseBrowser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_LeaseBrowser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).LeaseBrowser(ctx, req.(*LeaseBrowserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_ReturnBrowser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReturnBrowserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).ReturnBrowser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_ReturnBrowser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).ReturnBrowser(ctx, req.(*ReturnBrowserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_PoolStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrowserServiceServer).PoolStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BrowserService_PoolStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrowserServiceServer).PoolStatus(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BrowserService_WatchPageEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPageEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BrowserServiceServer).WatchPageEvents(m, &grpc.GenericServerStream[WatchPageEventsRequest, PageEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BrowserService_WatchPageEventsServer = grpc.ServerStreamingServer[PageEvent]
+
+func _BrowserService_StreamScreencast_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamScreencastRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BrowserServiceServer).StreamScreencast(m, &grpc.GenericServerStream[StreamScreencastRequest, ScreencastFrame]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BrowserService_StreamScreencastServer = grpc.ServerStreamingServer[ScreencastFrame]
+
+// BrowserService_ServiceDesc is the grpc.ServiceDesc for BrowserService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BrowserService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "browsertools.v1.BrowserService",
+	HandlerType: (*BrowserServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateSession",
+			Handler:    _BrowserService_CreateSession_Handler,
+		},
+		{
+			MethodName: "CloseSession",
+			Handler:    _BrowserService_CloseSession_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _BrowserService_ListSessions_Handler,
+		},
+		{
+			MethodName: "OpenTab",
+			Handler:    _BrowserService_OpenTab_Handler,
+		},
+		{
+			MethodName: "ListTabs",
+			Handler:    _BrowserService_ListTabs_Handler,
+		},
+		{
+			MethodName: "PinTab",
+			Handler:    _BrowserService_PinTab_Handler,
+		},
+		{
+			MethodName: "CloseTab",
+			Handler:    _BrowserService_CloseTab_Handler,
+		},
+		{
+			MethodName: "Screenshot",
+			Handler:    _BrowserService_Screenshot_Handler,
+		},
+		{
+			MethodName: "GetConsoleLogs",
+			Handler:    _BrowserService_GetConsoleLogs_Handler,
+		},
+		{
+			MethodName: "Markdown",
+			Handler:    _BrowserService_Markdown_Handler,
+		},
+		{
+			MethodName: "Extract",
+			Handler:    _BrowserService_Extract_Handler,
+		},
+		{
+			MethodName: "Run",
+			Handler:    _BrowserService_Run_Handler,
+		},
+		{
+			MethodName: "StartInteraction",
+			Handler:    _BrowserService_StartInteraction_Handler,
+		},
+		{
+			MethodName: "StopInteraction",
+			Handler:    _BrowserService_StopInteraction_Handler,
+		},
+		{
+			MethodName: "StartRecording",
+			Handler:    _BrowserService_StartRecording_Handler,
+		},
+		{
+			MethodName: "StopRecording",
+			Handler:    _BrowserService_StopRecording_Handler,
+		},
+		{
+			MethodName: "ListRecordings",
+			Handler:    _BrowserService_ListRecordings_Handler,
+		},
+		{
+			MethodName: "VisualCompare",
+			Handler:    _BrowserService_VisualCompare_Handler,
+		},
+		{
+			MethodName: "VisualApprove",
+			Handler:    _BrowserService_VisualApprove_Handler,
+		},
+		{
+			MethodName: "ListBaselines",
+			Handler:    _BrowserService_ListBaselines_Handler,
+		},
+		{
+			MethodName: "LeaseBrowser",
+			Handler:    _BrowserService_LeaseBrowser_Handler,
+		},
+		{
+			MethodName: "ReturnBrowser",
+			Handler:    _BrowserService_ReturnBrowser_Handler,
+		},
+		{
+			MethodName: "PoolStatus",
+			Handler:    _BrowserService_PoolStatus_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchPageEvents",
+			Handler:       _BrowserService_WatchPageEvents_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamScreencast",
+			Handler:       _BrowserService_StreamScreencast_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "browsertools/v1/browser.proto",
+}
@@ -0,0 +1,4 @@
+// Package browserpb gRPC 接口的生成代码, 接口定义在 proto/browsertools/v1/browser.proto
+package browserpb
+
+//go:generate protoc -I ../../proto --go_out=../.. --go_opt=module=browsertools --go-grpc_out=../.. --go-grpc_opt=module=browsertools browsertools/v1/browser.proto
@@ -0,0 +1,420 @@
+package config
+
+import (
+	"browsertools/pkg/auth"
+	"browsertools/pkg/browser"
+	"browsertools/pkg/errors"
+	"browsertools/pkg/webhook"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// EnvPrefix 环境变量前缀, 例如 browser.cdp_endpoint 对应 BROWSERTOOLS_BROWSER_CDP_ENDPOINT
+	EnvPrefix = "BROWSERTOOLS_"
+	// EnvConfigFile 指定配置文件路径的环境变量
+	EnvConfigFile = EnvPrefix + "CONFIG"
+)
+
+// Config 服务配置, 优先级: 命令行参数 > 环境变量 > 配置文件 > 默认值
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Auth     Auth     `yaml:"auth"`
+	Browser  Browser  `yaml:"browser"`
+	Session  Session  `yaml:"session"`
+	Tabs     Tabs     `yaml:"tabs"`
+	Pool     Pool     `yaml:"pool"`
+	Recovery Recovery `yaml:"recovery"`
+	Storage  Storage  `yaml:"storage"`
+	Webhook  Webhook  `yaml:"webhook"`
+	MCP      MCP      `yaml:"mcp"`
+	GRPC     GRPC     `yaml:"grpc"`
+	// Processes 随服务启动和停止的辅助进程, 例如虚拟显示器, 只能在配置文件中设置
+	Processes []Process `yaml:"processes" validate:"dive"`
+}
+
+type Server struct {
+	Addr            string        `yaml:"addr" usage:"http listen address" validate:"required"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" usage:"max time to drain requests before closing browsers on shutdown" validate:"min=1s"`
+}
+
+type Auth struct {
+	Enabled bool `yaml:"enabled" usage:"require credentials for the browser API, MCP and gRPC; health checks stay public"`
+	// Token 方便在环境变量中设置单个管理员令牌
+	Token string `yaml:"token" usage:"static API token with the admin scope, use tokens in the config file for narrower scopes" validate:"omitempty,min=16"`
+	// Tokens 和 HMACKeys 只能在配置文件中设置
+	Tokens       []AuthToken   `yaml:"tokens" validate:"dive"`
+	HMACKeys     []AuthHMACKey `yaml:"hmac_keys" validate:"dive"`
+	MaxClockSkew time.Duration `yaml:"max_clock_skew" usage:"allowed clock difference for signed request timestamps and JWT expiry" validate:"min=1s"`
+	JWT          AuthJWT       `yaml:"jwt"`
+}
+
+type AuthToken struct {
+	Name   string   `yaml:"name" validate:"required"`
+	Token  string   `yaml:"token" validate:"required,min=16"`
+	Scopes []string `yaml:"scopes" validate:"min=1,dive,oneof=read interact admin"`
+}
+
+type AuthHMACKey struct {
+	ID     string   `yaml:"id" validate:"required"`
+	Secret string   `yaml:"secret" validate:"required,min=16"`
+	Scopes []string `yaml:"scopes" validate:"min=1,dive,oneof=read interact admin"`
+}
+
+type AuthJWT struct {
+	KeyFile  string `yaml:"key_file" usage:"verify JWT bearer tokens with this HMAC secret, PEM public key or certificate file, empty to disable"`
+	Issuer   string `yaml:"issuer" usage:"required JWT iss claim, empty to accept any"`
+	Audience string `yaml:"audience" usage:"required JWT aud claim, empty to accept any"`
+}
+
+type Browser struct {
+	Path           string        `yaml:"path" usage:"browser executable path, empty to search path_candidates"`
+	PathCandidates []string      `yaml:"path_candidates" usage:"comma separated browser executable candidates"`
+	CDPEndpoint    string        `yaml:"cdp_endpoint" usage:"connect to an existing browser first, empty to always launch" validate:"omitempty,url"`
+	Preset         string        `yaml:"preset" usage:"launch args preset: default, low-memory or debug" validate:"required"`
+	Args           []string      `yaml:"args" sep:" " usage:"space separated extra launch args"`
+	LaunchTimeout  time.Duration `yaml:"launch_timeout" usage:"browser launch timeout" validate:"min=1s"`
+	Engine         string        `yaml:"engine" usage:"browser engine: chromium, firefox or webkit" validate:"oneof=chromium firefox webkit"`
+	HeadlessMode   string        `yaml:"headless_mode" usage:"headful, headless or new-headless (chromium only)" validate:"oneof=headful headless new-headless"`
+	MaxLogs        int           `yaml:"max_logs" usage:"console logs kept per page" validate:"min=1,max=100000"`
+}
+
+type Session struct {
+	IdleTimeout time.Duration `yaml:"idle_timeout" usage:"close named sessions idle longer than this, 0 to disable" validate:"min=0"`
+}
+
+type Tabs struct {
+	MaxTabs           int           `yaml:"max_tabs" usage:"max open tabs per browser, 0 for unlimited" validate:"min=0"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" usage:"close tabs idle longer than this, 0 to disable" validate:"min=0"`
+	JSHeapThresholdMB int           `yaml:"js_heap_threshold_mb" usage:"evict tabs when their total used js heap exceeds this, not process memory, 0 to disable" validate:"min=0"`
+	CheckInterval     time.Duration `yaml:"check_interval" usage:"tab idle and memory check interval" validate:"min=1s"`
+	ActivatePopups    bool          `yaml:"activate_popups" usage:"switch the active tab to popups and target=_blank tabs"`
+	Watchdog          Watchdog      `yaml:"watchdog"`
+}
+
+type Watchdog struct {
+	Enabled       bool          `yaml:"enabled" usage:"probe tabs periodically to detect hung pages and apply on_crash to crashed tabs"`
+	Interval      time.Duration `yaml:"interval" usage:"tab probe interval" validate:"min=100ms"`
+	ProbeTimeout  time.Duration `yaml:"probe_timeout" usage:"a probe slower than this counts as a failure" validate:"min=10ms"`
+	HungThreshold int           `yaml:"hung_threshold" usage:"consecutive failed probes before a tab is marked hung" validate:"min=1"`
+	OnHung        string        `yaml:"on_hung" usage:"action for hung tabs: none, reload or close" validate:"oneof=none reload close"`
+	OnCrash       string        `yaml:"on_crash" usage:"action for crashed tabs when the watchdog is enabled: reopen, close or none" validate:"oneof=reopen close none"`
+}
+
+type Pool struct {
+	Enabled             bool          `yaml:"enabled" usage:"start the browser pool and warm up at startup"`
+	MinSize             int           `yaml:"min_size" usage:"browsers kept warm in the pool" validate:"min=0,ltefield=MaxSize"`
+	MaxSize             int           `yaml:"max_size" usage:"max browsers in the pool" validate:"min=1"`
+	MaxUses             int           `yaml:"max_uses" usage:"recycle a browser after this many leases, 0 for unlimited" validate:"min=0"`
+	MaxAge              time.Duration `yaml:"max_age" usage:"recycle a browser older than this, 0 for unlimited" validate:"min=0"`
+	MaxLeaseDuration    time.Duration `yaml:"max_lease_duration" usage:"reclaim leases held longer than this, 0 for unlimited" validate:"min=0"`
+	HealthCheckInterval time.Duration `yaml:"health_check_interval" usage:"pool health check interval" validate:"min=1s"`
+}
+
+type Recovery struct {
+	Enabled          bool          `yaml:"enabled" usage:"relaunch the browser and reopen tabs after a crash"`
+	MaxAttempts      int           `yaml:"max_attempts" usage:"relaunch attempts before waiting for the next request" validate:"min=1"`
+	InitialBackoff   time.Duration `yaml:"initial_backoff" usage:"delay before the second relaunch attempt, doubled each time" validate:"min=100ms"`
+	MaxBackoff       time.Duration `yaml:"max_backoff" usage:"max delay between relaunch attempts" validate:"gtefield=InitialBackoff"`
+	SnapshotInterval time.Duration `yaml:"snapshot_interval" usage:"interval of recording open tabs for recovery" validate:"min=100ms"`
+}
+
+type Storage struct {
+	Dir                string        `yaml:"dir" usage:"directory for recordings and visual baselines" validate:"required"`
+	RecordingRetention time.Duration `yaml:"recording_retention" usage:"delete finished recordings older than this when a recording starts, 0 to keep them" validate:"min=0"`
+	MaxRecordings      int           `yaml:"max_recordings" usage:"keep at most this many finished recordings, 0 for no limit" validate:"min=0"`
+}
+
+type Webhook struct {
+	// Endpoints 只能在配置文件中设置
+	Endpoints      []WebhookEndpoint `yaml:"endpoints" validate:"dive"`
+	MaxAttempts    int               `yaml:"max_attempts" usage:"webhook delivery attempts before writing to the dead letter file" validate:"min=1"`
+	InitialBackoff time.Duration     `yaml:"initial_backoff" usage:"delay before the second delivery attempt, doubled each time" validate:"min=100ms"`
+	MaxBackoff     time.Duration     `yaml:"max_backoff" usage:"max delay between delivery attempts" validate:"gtefield=InitialBackoff"`
+	Timeout        time.Duration     `yaml:"timeout" usage:"webhook request timeout" validate:"min=1s"`
+	QueueSize      int               `yaml:"queue_size" usage:"pending events per endpoint, overflow goes to the dead letter file" validate:"min=1"`
+	DeadLetterFile string            `yaml:"dead_letter_file" usage:"failed events file, empty for webhook_dead_letter.jsonl in the storage dir"`
+}
+
+type WebhookEndpoint struct {
+	URL    string   `yaml:"url" validate:"required,url"`
+	Secret string   `yaml:"secret"`
+	Events []string `yaml:"events"`
+	Format string   `yaml:"format" validate:"omitempty,oneof=json dingtalk wecom"`
+}
+
+type MCP struct {
+	Enabled bool `yaml:"enabled" usage:"serve MCP over streamable HTTP at /mcp"`
+	Stdio   bool `yaml:"stdio" usage:"serve MCP over stdin/stdout instead of starting the http server"`
+}
+
+type GRPC struct {
+	Enabled bool   `yaml:"enabled" usage:"serve the gRPC API on a separate port"`
+	Addr    string `yaml:"addr" usage:"gRPC listen address" validate:"required_if=Enabled true"`
+}
+
+// Process 由服务管理的进程, 异常退出后自动重启, 状态包含在就绪检查中
+type Process struct {
+	Name       string   `yaml:"name" validate:"required"`
+	Executable string   `yaml:"executable" validate:"required"`
+	Args       []string `yaml:"args"`
+	// DependsOn 依赖的进程名称, 必须有一个不依赖其他进程的基础进程
+	DependsOn      string        `yaml:"depends_on"`
+	MaxRestarts    int           `yaml:"max_restarts" validate:"min=0"`
+	RestartTimeout time.Duration `yaml:"restart_timeout" validate:"min=0"`
+}
+
+// Default 默认配置, 与引入配置之前的行为保持一致
+func Default() *Config {
+	opt := browser.DefaultOptions()
+	hook := webhook.DefaultOptions()
+
+	return &Config{
+		Server: Server{Addr: ":8888", ShutdownTimeout: 30 * time.Second},
+		Auth:   Auth{MaxClockSkew: auth.DefaultOptions().MaxClockSkew},
+		Browser: Browser{
+			PathCandidates: opt.PathCandidates,
+			CDPEndpoint:    opt.CDPEndpoint,
+			Preset:         opt.Preset,
+			LaunchTimeout:  opt.LaunchTimeout,
+			Engine:         opt.Engine,
+			HeadlessMode:   opt.HeadlessMode,
+			MaxLogs:        opt.MaxLogs,
+		},
+		Session: Session{IdleTimeout: opt.SessionIdleTimeout},
+		Tabs: Tabs{
+			MaxTabs:           opt.TabPolicy.MaxTabs,
+			IdleTimeout:       opt.TabPolicy.IdleTimeout,
+			JSHeapThresholdMB: opt.TabPolicy.JSHeapThresholdMB,
+			CheckInterval:     opt.TabPolicy.CheckInterval,
+			ActivatePopups:    opt.TabPolicy.ActivatePopups,
+			Watchdog: Watchdog{
+				Enabled:       opt.TabPolicy.Watchdog.Enabled,
+				Interval:      opt.TabPolicy.Watchdog.Interval,
+				ProbeTimeout:  opt.TabPolicy.Watchdog.ProbeTimeout,
+				HungThreshold: opt.TabPolicy.Watchdog.HungThreshold,
+				OnHung:        opt.TabPolicy.Watchdog.OnHung,
+				OnCrash:       opt.TabPolicy.Watchdog.OnCrash,
+			},
+		},
+		Pool: Pool{
+			MinSize:             opt.Pool.MinSize,
+			MaxSize:             opt.Pool.MaxSize,
+			MaxUses:             opt.Pool.MaxUses,
+			MaxAge:              opt.Pool.MaxAge,
+			MaxLeaseDuration:    opt.Pool.MaxLeaseDuration,
+			HealthCheckInterval: opt.Pool.HealthCheckInterval,
+		},
+		Recovery: Recovery{
+			Enabled:          opt.Recovery.Enabled,
+			MaxAttempts:      opt.Recovery.MaxAttempts,
+			InitialBackoff:   opt.Recovery.InitialBackoff,
+			MaxBackoff:       opt.Recovery.MaxBackoff,
+			SnapshotInterval: opt.Recovery.SnapshotInterval,
+		},
+		Storage: Storage{
+			Dir:                filepath.Join(os.TempDir(), "browsertools"),
+			RecordingRetention: 24 * time.Hour,
+			MaxRecordings:      100,
+		},
+		Webhook: Webhook{
+			MaxAttempts:    hook.MaxAttempts,
+			InitialBackoff: hook.InitialBackoff,
+			MaxBackoff:     hook.MaxBackoff,
+			Timeout:        hook.Timeout,
+			QueueSize:      hook.QueueSize,
+		},
+		MCP:  MCP{Enabled: true},
+		GRPC: GRPC{Addr: ":8889"},
+	}
+}
+
+// Validate 校验配置
+func (c *Config) Validate() error {
+	err := validator.New().Struct(c)
+	if err != nil {
+		return errors.WithMessage(err, "invalid config")
+	}
+
+	if err := browser.ValidateEngine(c.Browser.Engine, c.Browser.HeadlessMode); err != nil {
+		return errors.WithMessage(err, "invalid config")
+	}
+
+	if _, ok := browser.PresetArgs(c.Browser.Preset); !ok {
+		return fmt.Errorf("invalid config: unknown browser preset %q, available: %s",
+			c.Browser.Preset, strings.Join(browser.PresetNames(), ", "))
+	}
+
+	if c.Browser.Path != "" {
+		if _, err := os.Stat(c.Browser.Path); err != nil {
+			return errors.WithMessage(err, "invalid config: browser path")
+		}
+	}
+
+	if c.Auth.Enabled {
+		if c.Auth.Token == "" && len(c.Auth.Tokens) == 0 && len(c.Auth.HMACKeys) == 0 && c.Auth.JWT.KeyFile == "" {
+			return fmt.Errorf("invalid config: auth is enabled but no token, tokens, hmac_keys or jwt.key_file is set")
+		}
+
+		if _, err := auth.New(c.AuthOptions()); err != nil {
+			return errors.WithMessage(err, "invalid config: auth")
+		}
+	}
+
+	if err := validateProcesses(c.Processes); err != nil {
+		return errors.WithMessage(err, "invalid config")
+	}
+
+	return nil
+}
+
+// validateProcesses 进程名称不能重复, 依赖的进程必须存在, 并且至少有一个基础进程
+func validateProcesses(processes []Process) error {
+	if len(processes) == 0 {
+		return nil
+	}
+
+	names := make(map[string]bool, len(processes))
+	base := false
+	for _, p := range processes {
+		if names[p.Name] {
+			return fmt.Errorf("duplicate process %q", p.Name)
+		}
+		names[p.Name] = true
+		base = base || p.DependsOn == ""
+	}
+
+	if !base {
+		return fmt.Errorf("processes: one process must have an empty depends_on")
+	}
+
+	for _, p := range processes {
+		if p.DependsOn != "" && !names[p.DependsOn] {
+			return fmt.Errorf("process %q depends on unknown process %q", p.Name, p.DependsOn)
+		}
+	}
+
+	return nil
+}
+
+// BrowserOptions 转换为浏览器管理参数
+func (c *Config) BrowserOptions() browser.Options {
+	return browser.Options{
+		Path:               c.Browser.Path,
+		PathCandidates:     c.Browser.PathCandidates,
+		CDPEndpoint:        c.Browser.CDPEndpoint,
+		Preset:             c.Browser.Preset,
+		Args:               c.Browser.Args,
+		LaunchTimeout:      c.Browser.LaunchTimeout,
+		Engine:             c.Browser.Engine,
+		HeadlessMode:       c.Browser.HeadlessMode,
+		MaxLogs:            c.Browser.MaxLogs,
+		SessionIdleTimeout: c.Session.IdleTimeout,
+		TabPolicy: browser.TabPolicy{
+			MaxTabs:           c.Tabs.MaxTabs,
+			IdleTimeout:       c.Tabs.IdleTimeout,
+			JSHeapThresholdMB: c.Tabs.JSHeapThresholdMB,
+			CheckInterval:     c.Tabs.CheckInterval,
+			ActivatePopups:    c.Tabs.ActivatePopups,
+			Watchdog: browser.WatchdogPolicy{
+				Enabled:       c.Tabs.Watchdog.Enabled,
+				Interval:      c.Tabs.Watchdog.Interval,
+				ProbeTimeout:  c.Tabs.Watchdog.ProbeTimeout,
+				HungThreshold: c.Tabs.Watchdog.HungThreshold,
+				OnHung:        c.Tabs.Watchdog.OnHung,
+				OnCrash:       c.Tabs.Watchdog.OnCrash,
+			},
+		},
+		Pool: browser.PoolOptions{
+			MinSize:             c.Pool.MinSize,
+			MaxSize:             c.Pool.MaxSize,
+			MaxUses:             c.Pool.MaxUses,
+			MaxAge:              c.Pool.MaxAge,
+			MaxLeaseDuration:    c.Pool.MaxLeaseDuration,
+			HealthCheckInterval: c.Pool.HealthCheckInterval,
+		},
+		Recovery: browser.RecoveryOptions{
+			Enabled:          c.Recovery.Enabled,
+			MaxAttempts:      c.Recovery.MaxAttempts,
+			InitialBackoff:   c.Recovery.InitialBackoff,
+			MaxBackoff:       c.Recovery.MaxBackoff,
+			SnapshotInterval: c.Recovery.SnapshotInterval,
+		},
+	}
+}
+
+// WebhookOptions 转换为事件推送参数
+func (c *Config) WebhookOptions() webhook.Options {
+	endpoints := make([]webhook.Endpoint, 0, len(c.Webhook.Endpoints))
+	for _, endpoint := range c.Webhook.Endpoints {
+		endpoints = append(endpoints, webhook.Endpoint{
+			URL:    endpoint.URL,
+			Secret: endpoint.Secret,
+			Events: endpoint.Events,
+			Format: endpoint.Format,
+		})
+	}
+
+	deadLetterFile := c.Webhook.DeadLetterFile
+	if deadLetterFile == "" {
+		deadLetterFile = filepath.Join(c.Storage.Dir, "webhook_dead_letter.jsonl")
+	}
+
+	return webhook.Options{
+		Endpoints:      endpoints,
+		MaxAttempts:    c.Webhook.MaxAttempts,
+		InitialBackoff: c.Webhook.InitialBackoff,
+		MaxBackoff:     c.Webhook.MaxBackoff,
+		Timeout:        c.Webhook.Timeout,
+		QueueSize:      c.Webhook.QueueSize,
+		DeadLetterFile: deadLetterFile,
+	}
+}
+
+// AuthOptions 转换为认证参数, auth.token 作为名为 default 的管理员令牌
+func (c *Config) AuthOptions() auth.Options {
+	tokens := make([]auth.Token, 0, len(c.Auth.Tokens)+1)
+	if c.Auth.Token != "" {
+		tokens = append(tokens, auth.Token{Name: "default", Token: c.Auth.Token, Scopes: []string{auth.ScopeAdmin}})
+	}
+
+	for _, token := range c.Auth.Tokens {
+		tokens = append(tokens, auth.Token{Name: token.Name, Token: token.Token, Scopes: token.Scopes})
+	}
+
+	keys := make([]auth.HMACKey, 0, len(c.Auth.HMACKeys))
+	for _, key := range c.Auth.HMACKeys {
+		keys = append(keys, auth.HMACKey{ID: key.ID, Secret: key.Secret, Scopes: key.Scopes})
+	}
+
+	return auth.Options{
+		Tokens:       tokens,
+		HMACKeys:     keys,
+		MaxClockSkew: c.Auth.MaxClockSkew,
+		JWT: auth.JWTOptions{
+			KeyFile:  c.Auth.JWT.KeyFile,
+			Issuer:   c.Auth.JWT.Issuer,
+			Audience: c.Auth.JWT.Audience,
+		},
+	}
+}
+
+// RecordingDir 录屏文件目录
+func (c *Config) RecordingDir() string {
+	return filepath.Join(c.Storage.Dir, "recordings")
+}
+
+// RecordingRetention 已结束录制的保留策略
+func (c *Config) RecordingRetention() browser.RecordingRetention {
+	return browser.RecordingRetention{MaxAge: c.Storage.RecordingRetention, MaxCount: c.Storage.MaxRecordings}
+}
+
+// BaselineDir 视觉回归基准图目录
+func (c *Config) BaselineDir() string {
+	return filepath.Join(c.Storage.Dir, "baselines")
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+
+	return file
+}
+
+func TestLoad_Default(t *testing.T) {
+	cfg, err := Load("test", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, ":8888", cfg.Server.Addr)
+	assert.Equal(t, "http://localhost:29229", cfg.Browser.CDPEndpoint)
+	assert.Equal(t, 30*time.Second, cfg.Browser.LaunchTimeout)
+	assert.Equal(t, 1000, cfg.Browser.MaxLogs)
+	assert.Equal(t, "chromium", cfg.Browser.Engine)
+	assert.Equal(t, "headful", cfg.Browser.HeadlessMode)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	file := writeConfig(t, `
+server:
+  addr: ":7000"
+browser:
+  preset: low-memory
+  headless_mode: headless
+  max_logs: 200
+  args: ["--lang=zh-CN"]
+tabs:
+  max_tabs: 5
+`)
+
+	t.Setenv("BROWSERTOOLS_SERVER_ADDR", ":7001")
+	t.Setenv("BROWSERTOOLS_BROWSER_MAX_LOGS", "300")
+	t.Setenv("BROWSERTOOLS_BROWSER_ARGS", "--lang=en-US --disable-features=A,B")
+
+	cfg, err := Load("test", []string{"-config", file, "-server.addr", ":7002", "-browser.launch_timeout=1m"})
+	require.NoError(t, err)
+
+	// 命令行 > 环境变量 > 配置文件
+	assert.Equal(t, ":7002", cfg.Server.Addr)
+	assert.Equal(t, 300, cfg.Browser.MaxLogs)
+	assert.Equal(t, "low-memory", cfg.Browser.Preset)
+	assert.Equal(t, "headless", cfg.Browser.HeadlessMode)
+	assert.Equal(t, time.Minute, cfg.Browser.LaunchTimeout)
+	assert.Equal(t, []string{"--lang=en-US", "--disable-features=A,B"}, cfg.Browser.Args)
+	assert.Equal(t, 5, cfg.Tabs.MaxTabs)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	_, err := Load("test", []string{"-browser.preset", "unknown"})
+	assert.Error(t, err)
+
+	_, err = Load("test", []string{"-pool.min_size", "10", "-pool.max_size", "2"})
+	assert.Error(t, err)
+
+	_, err = Load("test", []string{"-config", writeConfig(t, "browser:\n  unknown_key: 1\n")})
+	assert.Error(t, err)
+
+	_, err = Load("test", []string{"-browser.max_logs", "abc"})
+	assert.Error(t, err)
+}
+
+func TestLoad_BoolFlag(t *testing.T) {
+	cfg, err := Load("test", []string{"-pool.enabled"})
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Pool.Enabled)
+}
+
+func TestLoad_Engine(t *testing.T) {
+	cfg, err := Load("test", []string{"-browser.engine", "firefox", "-browser.headless_mode", "headless"})
+	require.NoError(t, err)
+	assert.Equal(t, "firefox", cfg.Browser.Engine)
+
+	// new-headless 只有 Chromium 支持
+	_, err = Load("test", []string{"-browser.engine", "webkit", "-browser.headless_mode", "new-headless"})
+	assert.Error(t, err)
+
+	_, err = Load("test", []string{"-browser.engine", "edge"})
+	assert.Error(t, err)
+}
+
+func TestLoad_Example(t *testing.T) {
+	cfg, err := Load("test", []string{"-config", "../../config.example.yaml"})
+	require.NoError(t, err)
+
+	assert.Equal(t, Default().Server, cfg.Server)
+	assert.Equal(t, Default().Browser.PathCandidates, cfg.Browser.PathCandidates)
+	assert.Equal(t, Default().Tabs, cfg.Tabs)
+	assert.Equal(t, Default().Pool, cfg.Pool)
+	assert.Equal(t, Default().Recovery, cfg.Recovery)
+}
+
+func TestLoad_Auth(t *testing.T) {
+	// 开启认证时至少需要一种凭证
+	_, err := Load("test", []string{"-auth.enabled"})
+	assert.Error(t, err)
+
+	_, err = Load("test", []string{"-auth.enabled", "-auth.token", "short"})
+	assert.Error(t, err)
+
+	_, err = Load("test", []string{"-auth.enabled", "-auth.jwt.key_file", filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+
+	file := writeConfig(t, `
+auth:
+  enabled: true
+  tokens:
+    - name: viewer
+      token: viewer-token-0123456789
+      scopes: [read]
+  hmac_keys:
+    - id: ci
+      secret: ci-secret-0123456789
+      scopes: [interact]
+`)
+
+	t.Setenv("BROWSERTOOLS_AUTH_TOKEN", "admin-token-0123456789")
+
+	cfg, err := Load("test", []string{"-config", file})
+	require.NoError(t, err)
+
+	opt := cfg.AuthOptions()
+	require.Len(t, opt.Tokens, 2)
+	assert.Equal(t, "default", opt.Tokens[0].Name)
+	assert.Equal(t, []string{"admin"}, opt.Tokens[0].Scopes)
+	assert.Equal(t, "viewer", opt.Tokens[1].Name)
+	require.Len(t, opt.HMACKeys, 1)
+	assert.Equal(t, "ci", opt.HMACKeys[0].ID)
+
+	_, err = Load("test", []string{"-config", writeConfig(t, "auth:\n  tokens:\n    - name: x\n      token: x-token-0123456789\n      scopes: [write]\n")})
+	assert.Error(t, err)
+}
+
+func TestLoad_Processes(t *testing.T) {
+	cfg, err := Load("test", []string{"-config", writeConfig(t, `
+processes:
+  - name: Xvfb
+    executable: /usr/bin/Xvfb
+    args: [":99"]
+    max_restarts: 5
+    restart_timeout: 2s
+  - name: x11vnc
+    executable: /usr/bin/x11vnc
+    depends_on: Xvfb
+`)})
+	require.NoError(t, err)
+	require.Len(t, cfg.Processes, 2)
+	assert.Equal(t, []string{":99"}, cfg.Processes[0].Args)
+	assert.Equal(t, 2*time.Second, cfg.Processes[0].RestartTimeout)
+	assert.Equal(t, "Xvfb", cfg.Processes[1].DependsOn)
+
+	// 必须有基础进程, 依赖的进程必须存在
+	_, err = Load("test", []string{"-config", writeConfig(t, "processes:\n  - name: a\n    executable: a\n    depends_on: b\n")})
+	assert.Error(t, err)
+
+	_, err = Load("test", []string{"-config", writeConfig(t, "processes:\n  - name: a\n    executable: a\n  - name: b\n    executable: b\n    depends_on: c\n")})
+	assert.Error(t, err)
+
+	_, err = Load("test", []string{"-config", writeConfig(t, "processes:\n  - name: a\n    executable: a\n  - name: a\n    executable: b\n")})
+	assert.Error(t, err)
+
+	_, err = Load("test", []string{"-config", writeConfig(t, "processes:\n  - name: a\n")})
+	assert.Error(t, err)
+}
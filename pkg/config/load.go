@@ -0,0 +1,194 @@
+package config
+
+import (
+	"browsertools/pkg/errors"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+type field struct {
+	// name 以点分隔的配置路径, 例如 browser.cdp_endpoint
+	name  string
+	usage string
+	// sep 列表类型在环境变量和命令行中的分隔符
+	sep   string
+	value reflect.Value
+}
+
+type override struct {
+	name  string
+	value string
+}
+
+// Load 依次加载默认值、配置文件、环境变量和命令行参数, 最后校验
+// 配置文件通过 -config 参数或 BROWSERTOOLS_CONFIG 环境变量指定
+func Load(name string, args []string) (*Config, error) {
+	cfg := Default()
+	fields := collectFields(cfg)
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("config", os.Getenv(EnvConfigFile), "config file path (yaml)")
+
+	// 命令行参数先记录下来, 等配置文件和环境变量加载完之后再应用
+	overrides := make([]override, 0)
+	for _, f := range fields {
+		set := func(value string) error {
+			if err := setValue(reflect.New(f.value.Type()).Elem(), value, f.sep); err != nil {
+				return err
+			}
+
+			overrides = append(overrides, override{name: f.name, value: value})
+			return nil
+		}
+
+		// 布尔类型支持 -browser.headless 这种不带值的写法
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(f.name, f.usage, set)
+		} else {
+			fs.Func(f.name, f.usage, set)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *file != "" {
+		if err := loadFile(cfg, *file); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range fields {
+		value, ok := os.LookupEnv(envName(f.name))
+		if !ok {
+			continue
+		}
+
+		if err := setValue(f.value, value, f.sep); err != nil {
+			return nil, fmt.Errorf("invalid environment variable %s: %w", envName(f.name), err)
+		}
+	}
+
+	for _, o := range overrides {
+		for _, f := range fields {
+			if f.name == o.name {
+				_ = setValue(f.value, o.value, f.sep)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return errors.WithMessage(err, "read config file error")
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err = decoder.Decode(cfg); err != nil && err != io.EOF {
+		return errors.WithMessagef(err, "parse config file %s error", file)
+	}
+
+	return nil
+}
+
+// collectFields 展开配置结构体的所有叶子字段
+func collectFields(cfg *Config) []field {
+	fields := make([]field, 0)
+
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+
+			// 结构体切片只能在配置文件中设置
+			if sf.Type.Kind() == reflect.Slice && sf.Type.Elem().Kind() == reflect.Struct {
+				continue
+			}
+
+			if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+				walk(v.Field(i), name)
+				continue
+			}
+
+			sep := sf.Tag.Get("sep")
+			if sep == "" {
+				sep = ","
+			}
+
+			fields = append(fields, field{name: name, usage: sf.Tag.Get("usage"), sep: sep, value: v.Field(i)})
+		}
+	}
+
+	walk(reflect.ValueOf(cfg).Elem(), "")
+
+	return fields
+}
+
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, ".", "_"))
+}
+
+func setValue(v reflect.Value, raw string, sep string) error {
+	raw = strings.TrimSpace(raw)
+
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		items := make([]string, 0)
+		for _, item := range strings.Split(raw, sep) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Type())
+	}
+
+	return nil
+}
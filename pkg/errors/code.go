@@ -10,4 +10,28 @@ var (
 	ErrInvalidPlayground  = NewWithInfo(410, "Invalid playground ID")
 	BrowserNotInstalled   = NewWithInfo(411, "Browser not installed")
 	ErrCurrentPageEmpty   = NewWithInfo(412, "Browser not open any page")
+	ErrPageNotFound       = NewWithInfo(413, "Page not found")
+	ErrRecordingNotFound  = NewWithInfo(414, "Recording not found")
+	ErrRecordingRunning   = NewWithInfo(415, "Recording is still running")
+	ErrBaselineNotFound   = NewWithInfo(416, "Baseline not found")
+	ErrSessionNotFound    = NewWithInfo(417, "Session not found")
+	ErrSessionExists      = NewWithInfo(418, "Session already exists")
+	ErrLeaseNotFound      = NewWithInfo(419, "Browser lease not found")
+	ErrPoolExhausted      = NewWithInfo(420, "No browser available in pool, lease timeout")
+	ErrUnsupported        = NewWithInfo(421, "Not supported by this browser engine")
+	ErrPageUnresponsive   = NewWithInfo(422, "Page is not responding")
+	ErrHeadfulRequired    = NewWithInfo(423, "Only available for headful browsers")
+	ErrInteractionRunning = NewWithInfo(424, "Interaction recording is already running on this page")
+	ErrInteractionMissing = NewWithInfo(425, "Interaction recording not found on this page")
 )
+
+// Codes 所有预定义的错误码, 用于生成接口文档
+func Codes() []CodeError {
+	return []CodeError{
+		ErrInternalServer, ErrAdminAccountNotSet, ErrInvalidToken, ErrNoPermission, ErrRequestTimeout,
+		ErrArgument, ErrInvalidPlayground, BrowserNotInstalled, ErrCurrentPageEmpty, ErrPageNotFound,
+		ErrRecordingNotFound, ErrRecordingRunning, ErrBaselineNotFound, ErrSessionNotFound, ErrSessionExists,
+		ErrLeaseNotFound, ErrPoolExhausted, ErrUnsupported, ErrPageUnresponsive, ErrHeadfulRequired,
+		ErrInteractionRunning, ErrInteractionMissing,
+	}
+}
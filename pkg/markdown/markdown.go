@@ -0,0 +1,364 @@
+package markdown
+
+import (
+	"browsertools/pkg/errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	spaceRegexp     = regexp.MustCompile(`[ \t\r\n\f]+`)
+	blankLineRegexp = regexp.MustCompile(`\n{3,}`)
+)
+
+var blockTags = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Body: true,
+	atom.Dd: true, atom.Details: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Fieldset: true,
+	atom.Figcaption: true, atom.Figure: true, atom.Footer: true, atom.Form: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true,
+	atom.Hr: true, atom.Html: true, atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true,
+	atom.P: true, atom.Pre: true, atom.Section: true, atom.Summary: true, atom.Table: true, atom.Ul: true,
+}
+
+// Options 转换参数
+type Options struct {
+	// FullPage 为 true 时不做正文提取, 转换整个 body
+	FullPage bool
+}
+
+// Document 转换结果
+type Document struct {
+	Title    string
+	Markdown string
+}
+
+// Convert 将HTML转换为Markdown, 链接和图片地址按 pageURL 转为绝对地址
+func Convert(content string, pageURL string, opt Options) (*Document, error) {
+	root, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil, errors.WithMessage(err, "parse html error")
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		base = &url.URL{}
+	}
+
+	if baseNode := findFirst(root, func(n *html.Node) bool { return n.DataAtom == atom.Base }); baseNode != nil {
+		if href, ok := getAttr(baseNode, "href"); ok {
+			if ref, err := url.Parse(href); err == nil {
+				base = base.ResolveReference(ref)
+			}
+		}
+	}
+
+	doc := &Document{}
+	if title := findFirst(root, func(n *html.Node) bool { return n.DataAtom == atom.Title }); title != nil {
+		doc.Title = strings.TrimSpace(spaceRegexp.ReplaceAllString(textContent(title), " "))
+	}
+
+	body := findFirst(root, func(n *html.Node) bool { return n.DataAtom == atom.Body })
+	if body == nil {
+		body = root
+	}
+
+	clean(body, !opt.FullPage)
+
+	contentNode := body
+	if !opt.FullPage {
+		contentNode = findContent(body)
+	}
+
+	c := &converter{base: base}
+	text := strings.Join(c.blocks(contentNode), "\n\n")
+
+	// 正文中没有一级标题时补上页面标题
+	if doc.Title != "" && !strings.HasPrefix(text, "# ") && !strings.Contains(text, "\n# ") {
+		text = "# " + doc.Title + "\n\n" + text
+	}
+
+	doc.Markdown = strings.TrimSpace(blankLineRegexp.ReplaceAllString(text, "\n\n"))
+
+	return doc, nil
+}
+
+type converter struct {
+	base *url.URL
+}
+
+// blocks 把子节点渲染为一组块, 连续的行内节点合并成一个段落
+func (c *converter) blocks(n *html.Node) []string {
+	var (
+		out    []string
+		inline strings.Builder
+	)
+
+	flush := func() {
+		if text := trimLines(inline.String()); text != "" {
+			out = append(out, text)
+		}
+		inline.Reset()
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && blockTags[child.DataAtom] {
+			flush()
+
+			if text := c.block(child); text != "" {
+				out = append(out, text)
+			}
+			continue
+		}
+
+		inline.WriteString(c.inline(child))
+	}
+
+	flush()
+
+	return out
+}
+
+func (c *converter) block(n *html.Node) string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.ReplaceAll(trimLines(c.inlineChildren(n)), "\n", " ")
+		if text == "" {
+			return ""
+		}
+
+		level, _ := strconv.Atoi(n.Data[1:])
+		return strings.Repeat("#", level) + " " + text
+	case atom.Ul, atom.Ol:
+		return c.list(n)
+	case atom.Pre:
+		return "```\n" + strings.Trim(textContent(n), "\n") + "\n```"
+	case atom.Blockquote:
+		return prefixLines(strings.Join(c.blocks(n), "\n\n"), "> ")
+	case atom.Hr:
+		return "---"
+	case atom.Table:
+		return c.table(n)
+	case atom.Dt:
+		if text := trimLines(c.inlineChildren(n)); text != "" {
+			return "**" + text + "**"
+		}
+		return ""
+	default:
+		return strings.Join(c.blocks(n), "\n\n")
+	}
+}
+
+func (c *converter) list(n *html.Node) string {
+	ordered := n.DataAtom == atom.Ol
+	index := 1
+
+	if start, ok := getAttr(n, "start"); ok {
+		if v, err := strconv.Atoi(start); err == nil {
+			index = v
+		}
+	}
+
+	items := make([]string, 0)
+
+	for li := n.FirstChild; li != nil; li = li.NextSibling {
+		if li.Type != html.ElementNode {
+			continue
+		}
+
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+
+		text := strings.Join(c.blocks(li), "\n")
+		if text == "" {
+			continue
+		}
+
+		indent := strings.Repeat(" ", len(marker))
+		items = append(items, marker+strings.TrimPrefix(prefixLines(text, indent), indent))
+	}
+
+	return strings.Join(items, "\n")
+}
+
+func (c *converter) table(n *html.Node) string {
+	rows := make([][]string, 0)
+	hasHeader := false
+
+	walk(n, func(tr *html.Node) {
+		if tr.DataAtom != atom.Tr {
+			return
+		}
+
+		row := make([]string, 0)
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.DataAtom != atom.Td && cell.DataAtom != atom.Th {
+				continue
+			}
+
+			if cell.DataAtom == atom.Th && len(rows) == 0 {
+				hasHeader = true
+			}
+
+			text := strings.Join(c.blocks(cell), " ")
+			text = strings.ReplaceAll(strings.ReplaceAll(text, "\n", " "), "|", "\\|")
+			row = append(row, text)
+		}
+
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	})
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+
+	// 没有表头时使用空表头, 保证是合法的 GFM 表格
+	if !hasHeader {
+		rows = append([][]string{make([]string, cols)}, rows...)
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+
+		lines = append(lines, "| "+strings.Join(row, " | ")+" |")
+
+		if i == 0 {
+			lines = append(lines, "|"+strings.Repeat(" --- |", cols))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (c *converter) inlineChildren(n *html.Node) string {
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(c.inline(child))
+	}
+
+	return sb.String()
+}
+
+func (c *converter) inline(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return spaceRegexp.ReplaceAllString(n.Data, " ")
+	}
+
+	if n.Type != html.ElementNode {
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		return "\n"
+	case atom.A:
+		text := strings.TrimSpace(c.inlineChildren(n))
+		href, _ := getAttr(n, "href")
+		link := c.resolve(href)
+
+		if text == "" || link == "" || strings.HasPrefix(href, "#") {
+			return text
+		}
+		return "[" + text + "](" + link + ")"
+	case atom.Img:
+		alt, _ := getAttr(n, "alt")
+		src, _ := getAttr(n, "src")
+		alt = strings.TrimSpace(spaceRegexp.ReplaceAllString(alt, " "))
+
+		link := c.resolve(src)
+		if link == "" || strings.HasPrefix(src, "data:") {
+			if alt == "" {
+				return ""
+			}
+			return "[image: " + alt + "]"
+		}
+		return "![" + alt + "](" + link + ")"
+	case atom.Strong, atom.B:
+		return wrap(c.inlineChildren(n), "**")
+	case atom.Em, atom.I:
+		return wrap(c.inlineChildren(n), "*")
+	case atom.Del, atom.S:
+		return wrap(c.inlineChildren(n), "~~")
+	case atom.Code, atom.Kbd, atom.Samp:
+		text := textContent(n)
+		if strings.TrimSpace(text) == "" {
+			return text
+		}
+		return "`" + strings.ReplaceAll(text, "`", "'") + "`"
+	default:
+		return c.inlineChildren(n)
+	}
+}
+
+// resolve 将相对地址转换为绝对地址, 忽略 javascript: 等非导航地址
+func (c *converter) resolve(href string) string {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		return ""
+	}
+
+	ref, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+
+	return c.base.ResolveReference(ref).String()
+}
+
+func wrap(text string, mark string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+
+	// 保留两侧空格, 避免和相邻文字粘在一起
+	prefix := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	suffix := text[len(strings.TrimRight(text, " ")):]
+
+	return prefix + mark + trimmed + mark + suffix
+}
+
+// trimLines 去掉每行首尾空白以及多余空行
+func trimLines(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			out = append(out, line)
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+func prefixLines(text string, prefix string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = strings.TrimRight(prefix, " ")
+		} else {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testArticle = `<html><head><title>Test Page</title></head><body>
+<nav class="menu"><a href="/">Home</a><a href="/about">About</a></nav>
+<div class="cookie-banner">We use cookies</div>
+<article>
+  <h1>Hello <em>World</em></h1>
+  <p>This is the first paragraph of the article, it has enough text to be considered real content.
+     See <a href="/docs/intro?x=1">the docs</a> and <a href="javascript:void(0)">nothing</a>.</p>
+  <img src="img/logo.png" alt="Logo">
+  <ul><li>one</li><li>two<ol><li>nested</li></ol></li></ul>
+  <table><tr><th>Name</th><th>Value</th></tr><tr><td>a|b</td><td>1</td></tr></table>
+  <pre><code>fmt.Println("hi")</code></pre>
+  <script>alert(1)</script>
+  <p style="display:none">hidden text</p>
+  <p>Second paragraph, with some more words, so that the article passes the length check easily.</p>
+</article>
+<footer>Copyright</footer>
+</body></html>`
+
+func TestConvert(t *testing.T) {
+	doc, err := Convert(testArticle, "https://example.com/blog/post", Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Test Page", doc.Title)
+
+	md := doc.Markdown
+	assert.True(t, strings.HasPrefix(md, "# Hello *World*"))
+	assert.Contains(t, md, "[the docs](https://example.com/docs/intro?x=1)")
+	assert.Contains(t, md, "and nothing.")
+	assert.Contains(t, md, "![Logo](https://example.com/blog/img/logo.png)")
+	assert.Contains(t, md, "- one\n- two\n  1. nested")
+	assert.Contains(t, md, "| Name | Value |\n| --- | --- |\n| a\\|b | 1 |")
+	assert.Contains(t, md, "```\nfmt.Println(\"hi\")\n```")
+
+	for _, removed := range []string{"Home", "cookies", "Copyright", "alert", "hidden text"} {
+		assert.NotContains(t, md, removed)
+	}
+}
+
+func TestConvertFullPage(t *testing.T) {
+	doc, err := Convert(testArticle, "https://example.com/", Options{FullPage: true})
+	assert.NoError(t, err)
+	assert.Contains(t, doc.Markdown, "[About](https://example.com/about)")
+	assert.Contains(t, doc.Markdown, "Copyright")
+	assert.NotContains(t, doc.Markdown, "hidden text")
+}
+
+func TestConvertScoring(t *testing.T) {
+	content := `<html><body>
+<div id="links"><a href="/a">link one</a> <a href="/b">link two</a></div>
+<div id="story">
+  <p>The quick brown fox jumps over the lazy dog, again and again, until it gets tired.</p>
+  <p>Another long paragraph follows here, with commas, and more commas, to gain score.</p>
+</div></body></html>`
+
+	doc, err := Convert(content, "https://example.com/", Options{})
+	assert.NoError(t, err)
+	assert.Contains(t, doc.Markdown, "quick brown fox")
+	assert.NotContains(t, doc.Markdown, "link one")
+}
+
+func TestSplit(t *testing.T) {
+	text := "aaaa\n\nbbbb\n\ncccc"
+
+	assert.Equal(t, []string{text}, Split(text, 0, 0))
+	assert.Equal(t, []string{"aaaa\n\nbbbb", "cccc"}, Split(text, 10, 0))
+	assert.Equal(t, []string{"aaaa", "bbbb", "cccc"}, Split(text, 5, 0))
+	assert.Equal(t, []string{"aaa", "a", "bbb", "b", "ccc", "c"}, Split(text, 3, 0))
+	assert.Equal(t, []string{"aaaa", "bbbb", "cccc"}, Split(text, 0, 1))
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, EstimateTokens(""))
+	assert.Equal(t, 2, EstimateTokens("abcdefg"))
+	assert.Equal(t, 3, EstimateTokens("你好a"))
+}
@@ -0,0 +1,233 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// 类名或ID命中这些关键字的元素大概率是导航、广告等非正文内容
+	unlikelyRegexp = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|consent|disqus|extra|foot|header|legends|menu|related|remark|replies|rss|shoutbox|sidebar|skyscraper|social|sponsor|ad-break|advert|agegate|pagination|pager|popup|share|subscribe|newsletter|promo|modal`)
+	// 命中这些关键字时即使也命中了 unlikelyRegexp 也保留
+	positiveRegexp = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+
+	hiddenStyleRegexp = regexp.MustCompile(`(?i)display\s*:\s*none|visibility\s*:\s*hidden`)
+)
+
+// 无论是否开启正文提取都会移除的元素
+var removeTags = map[atom.Atom]bool{
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Iframe:   true,
+	atom.Svg:      true,
+	atom.Canvas:   true,
+	atom.Button:   true,
+	atom.Input:    true,
+	atom.Select:   true,
+	atom.Textarea: true,
+	atom.Link:     true,
+	atom.Meta:     true,
+}
+
+// 开启正文提取时额外移除的页面框架元素
+var boilerplateTags = map[atom.Atom]bool{
+	atom.Nav:    true,
+	atom.Footer: true,
+	atom.Aside:  true,
+	atom.Form:   true,
+	atom.Dialog: true,
+}
+
+// clean 移除脚本、隐藏元素等不需要的节点, readability 为 true 时同时移除导航、页脚等样板内容
+func clean(n *html.Node, readability bool) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+
+		if child.Type == html.CommentNode || (child.Type == html.ElementNode && shouldRemove(child, readability)) {
+			n.RemoveChild(child)
+		} else {
+			clean(child, readability)
+		}
+
+		child = next
+	}
+}
+
+func shouldRemove(n *html.Node, readability bool) bool {
+	if removeTags[n.DataAtom] {
+		return true
+	}
+
+	if _, ok := getAttr(n, "hidden"); ok {
+		return true
+	}
+
+	if v, _ := getAttr(n, "aria-hidden"); v == "true" {
+		return true
+	}
+
+	if v, _ := getAttr(n, "style"); hiddenStyleRegexp.MatchString(v) {
+		return true
+	}
+
+	if !readability {
+		return false
+	}
+
+	if boilerplateTags[n.DataAtom] {
+		return true
+	}
+
+	switch n.DataAtom {
+	case atom.Html, atom.Body, atom.Article, atom.Main, atom.Table, atom.Tbody, atom.Thead, atom.Tr, atom.Td, atom.Th:
+		return false
+	}
+
+	if v, _ := getAttr(n, "role"); v == "navigation" || v == "banner" || v == "contentinfo" || v == "complementary" {
+		return true
+	}
+
+	class, _ := getAttr(n, "class")
+	id, _ := getAttr(n, "id")
+	match := class + " " + id
+
+	return unlikelyRegexp.MatchString(match) && !positiveRegexp.MatchString(match)
+}
+
+// findContent 找出正文所在的节点, 优先使用语义化标签, 否则按段落文本打分选择
+func findContent(body *html.Node) *html.Node {
+	if main := findFirst(body, func(n *html.Node) bool {
+		role, _ := getAttr(n, "role")
+		return n.DataAtom == atom.Main || role == "main"
+	}); main != nil && textLength(main) >= 200 {
+		return main
+	}
+
+	var best *html.Node
+	bestLength := 0
+
+	walk(body, func(n *html.Node) {
+		if n.DataAtom == atom.Article {
+			if length := textLength(n); length > bestLength {
+				best, bestLength = n, length
+			}
+		}
+	})
+
+	if best != nil && bestLength >= 200 {
+		return best
+	}
+
+	return scoreCandidates(body)
+}
+
+// scoreCandidates 简化的 readability 打分: 段落为父节点加分, 祖父节点加一半, 最后按链接密度折算
+func scoreCandidates(body *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+
+	walk(body, func(n *html.Node) {
+		if n.DataAtom != atom.P && n.DataAtom != atom.Pre && n.DataAtom != atom.Td && n.DataAtom != atom.Blockquote {
+			return
+		}
+
+		text := textContent(n)
+		length := len([]rune(strings.TrimSpace(text)))
+		if length < 25 {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) + min(float64(length)/100, 3)
+
+		if parent := n.Parent; parent != nil {
+			scores[parent] += score
+
+			if grand := parent.Parent; grand != nil {
+				scores[grand] += score / 2
+			}
+		}
+	})
+
+	best := body
+	bestScore := 0.0
+
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+
+	return best
+}
+
+func linkDensity(n *html.Node) float64 {
+	total := textLength(n)
+	if total == 0 {
+		return 0
+	}
+
+	links := 0
+	walk(n, func(child *html.Node) {
+		if child.DataAtom == atom.A {
+			links += textLength(child)
+		}
+	})
+
+	return float64(links) / float64(total)
+}
+
+func textLength(n *html.Node) int {
+	return len([]rune(strings.Join(strings.Fields(textContent(n)), " ")))
+}
+
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		sb.WriteString(textContent(child))
+	}
+
+	return sb.String()
+}
+
+func walk(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walk(child, fn)
+	}
+}
+
+func findFirst(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findFirst(child, match); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func getAttr(n *html.Node, key string) (string, bool) {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val, true
+		}
+	}
+
+	return "", false
+}
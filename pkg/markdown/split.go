@@ -0,0 +1,99 @@
+package markdown
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// EstimateTokens 粗略估算 token 数: ASCII 字符按4个一个 token, 其他字符(中文等)按1个 token
+func EstimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+
+	return (ascii+3)/4 + other
+}
+
+// Split 按预算把 Markdown 在块边界切分成多页, maxChars 和 maxTokens 为0表示不限制;
+// 单个块超过预算时按字符强制切分
+func Split(text string, maxChars int, maxTokens int) []string {
+	if text == "" {
+		return []string{""}
+	}
+
+	fits := func(s string) bool {
+		if maxChars > 0 && utf8.RuneCountInString(s) > maxChars {
+			return false
+		}
+
+		return maxTokens <= 0 || EstimateTokens(s) <= maxTokens
+	}
+
+	if fits(text) {
+		return []string{text}
+	}
+
+	pages := make([]string, 0)
+	current := ""
+
+	for _, block := range strings.Split(text, "\n\n") {
+		candidate := block
+		if current != "" {
+			candidate = current + "\n\n" + block
+		}
+
+		if fits(candidate) {
+			current = candidate
+			continue
+		}
+
+		if current != "" {
+			pages = append(pages, current)
+			current = ""
+		}
+
+		if fits(block) {
+			current = block
+			continue
+		}
+
+		pieces := splitRunes(block, fits)
+		pages = append(pages, pieces[:len(pieces)-1]...)
+		current = pieces[len(pieces)-1]
+	}
+
+	if current != "" {
+		pages = append(pages, current)
+	}
+
+	return pages
+}
+
+// splitRunes 按字符把超长的块切成满足预算的若干段
+func splitRunes(block string, fits func(string) bool) []string {
+	runes := []rune(block)
+	pieces := make([]string, 0)
+
+	for len(runes) > 0 {
+		// 二分查找本段能放下的最多字符数
+		low, high := 1, len(runes)
+		for low < high {
+			mid := (low + high + 1) / 2
+			if fits(string(runes[:mid])) {
+				low = mid
+			} else {
+				high = mid - 1
+			}
+		}
+
+		pieces = append(pieces, string(runes[:low]))
+		runes = runes[low:]
+	}
+
+	return pieces
+}
@@ -0,0 +1,291 @@
+package mcp
+
+import (
+	"browsertools/log"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+const (
+	// LatestProtocolVersion 客户端请求的版本不支持时返回该版本
+	LatestProtocolVersion = "2025-06-18"
+
+	jsonrpcVersion = "2.0"
+
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+var supportedVersions = []string{LatestProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// Content 工具返回的内容, 文本或图片
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// TextContent 文本内容
+func TextContent(text string) Content {
+	return Content{Type: "text", Text: text}
+}
+
+// ImageContent 图片内容, 按 MCP 要求使用 base64 编码
+func ImageContent(data []byte, mimeType string) Content {
+	return Content{Type: "image", Data: base64.StdEncoding.EncodeToString(data), MimeType: mimeType}
+}
+
+// JSONContent 把结构化数据格式化为文本内容
+func JSONContent(v interface{}) Content {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return TextContent(fmt.Sprintf("marshal result error: %v", err))
+	}
+
+	return TextContent(string(data))
+}
+
+// ToolResult tools/call 的结果, 工具执行失败时 IsError 为 true, 错误信息在 Content 中
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// ToolHandler 工具的实现, 参数为客户端传入的 arguments
+// 返回的 error 和 panic 都会转换为 IsError 结果, 便于复用 errors.Check 风格的代码
+type ToolHandler func(ctx context.Context, args json.RawMessage) (*ToolResult, error)
+
+// Tool 一个工具, InputSchema 为 JSON Schema
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	Handler     ToolHandler            `json:"-"`
+}
+
+// Server MCP 服务端, 只提供工具, 传输层见 ServeStdio 和 Handler
+type Server struct {
+	name    string
+	version string
+	tools   map[string]Tool
+	mux     sync.RWMutex
+}
+
+// NewServer 创建 MCP 服务端
+func NewServer(name string, version string) *Server {
+	return &Server{name: name, version: version, tools: make(map[string]Tool)}
+}
+
+// AddTool 注册工具, 同名工具会被覆盖
+func (s *Server) AddTool(tool Tool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if tool.InputSchema == nil {
+		tool.InputSchema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+
+	s.tools[tool.Name] = tool
+}
+
+// Tools 按名称排序返回所有工具
+func (s *Server) Tools() []Tool {
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	tools := make([]Tool, 0, len(s.tools))
+	for _, tool := range s.tools {
+		tools = append(tools, tool)
+	}
+
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+
+	return tools
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// isNotification 没有 id 的请求是通知, 不需要响应; 客户端对服务端请求的响应同样没有 method
+func (r *request) isNotification() bool {
+	return len(r.ID) == 0 || r.Method == ""
+}
+
+// HandleMessage 处理一条 JSON-RPC 消息或批量消息, 只包含通知时返回 nil
+func (s *Server) HandleMessage(ctx context.Context, data []byte) []byte {
+	data = bytes.TrimSpace(data)
+
+	if len(data) > 0 && data[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(data, &batch); err != nil || len(batch) == 0 {
+			return marshalResponse(errorResponse(nil, codeParseError, "parse error"))
+		}
+
+		responses := make([]response, 0, len(batch))
+		for _, item := range batch {
+			if resp := s.handle(ctx, item); resp != nil {
+				responses = append(responses, *resp)
+			}
+		}
+
+		if len(responses) == 0 {
+			return nil
+		}
+
+		return marshalResponse(responses)
+	}
+
+	resp := s.handle(ctx, data)
+	if resp == nil {
+		return nil
+	}
+
+	return marshalResponse(resp)
+}
+
+func (s *Server) handle(ctx context.Context, data []byte) *response {
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errorResponse(nil, codeParseError, "parse error")
+	}
+
+	if req.JSONRPC != jsonrpcVersion {
+		return errorResponse(req.ID, codeInvalidRequest, "invalid jsonrpc version")
+	}
+
+	if req.isNotification() {
+		log.Debugf("mcp notification %s", req.Method)
+		return nil
+	}
+
+	result, rpcErr := s.dispatch(ctx, req)
+	if rpcErr != nil {
+		return &response{JSONRPC: jsonrpcVersion, ID: req.ID, Error: rpcErr}
+	}
+
+	return &response{JSONRPC: jsonrpcVersion, ID: req.ID, Result: result}
+}
+
+func (s *Server) dispatch(ctx context.Context, req request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+
+		version := params.ProtocolVersion
+		if !slices.Contains(supportedVersions, version) {
+			version = LatestProtocolVersion
+		}
+
+		return map[string]interface{}{
+			"protocolVersion": version,
+			"capabilities": map[string]interface{}{
+				"tools": map[string]interface{}{"listChanged": false},
+			},
+			"serverInfo": map[string]interface{}{"name": s.name, "version": s.version},
+		}, nil
+
+	case "ping":
+		return map[string]interface{}{}, nil
+
+	case "tools/list":
+		return map[string]interface{}{"tools": s.Tools()}, nil
+
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid params"}
+		}
+
+		s.mux.RLock()
+		tool, ok := s.tools[params.Name]
+		s.mux.RUnlock()
+
+		if !ok {
+			return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+
+		return s.callTool(ctx, tool, params.Arguments), nil
+	}
+
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+}
+
+func (s *Server) callTool(ctx context.Context, tool Tool, args json.RawMessage) (result *ToolResult) {
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("mcp tool %s error: %v", tool.Name, r)
+			result = &ToolResult{Content: []Content{TextContent(fmt.Sprint(r))}, IsError: true}
+		}
+	}()
+
+	result, err := tool.Handler(ctx, args)
+	if err != nil {
+		log.Errorf("mcp tool %s error: %v", tool.Name, err)
+		return &ToolResult{Content: []Content{TextContent(err.Error())}, IsError: true}
+	}
+
+	if result == nil {
+		result = &ToolResult{}
+	}
+
+	if result.Content == nil {
+		result.Content = []Content{}
+	}
+
+	return result
+}
+
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+
+	return &response{JSONRPC: jsonrpcVersion, ID: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+func marshalResponse(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(errorResponse(nil, codeInternalError, err.Error()))
+	}
+
+	return data
+}
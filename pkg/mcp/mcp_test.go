@@ -0,0 +1,231 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer() *Server {
+	server := NewServer("test", "1.0.0")
+	server.AddTool(Tool{
+		Name:        "echo",
+		Description: "echo the text argument",
+		Handler: func(ctx context.Context, args json.RawMessage) (*ToolResult, error) {
+			var req struct {
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal(args, &req); err != nil {
+				return nil, err
+			}
+
+			return &ToolResult{Content: []Content{TextContent(req.Text), ImageContent([]byte("png"), "image/png")}}, nil
+		},
+	})
+	server.AddTool(Tool{
+		Name: "fail",
+		Handler: func(ctx context.Context, args json.RawMessage) (*ToolResult, error) {
+			return nil, errors.New("failed")
+		},
+	})
+	server.AddTool(Tool{
+		Name: "panic",
+		Handler: func(ctx context.Context, args json.RawMessage) (*ToolResult, error) {
+			panic("boom")
+		},
+	})
+
+	return server
+}
+
+type testResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func call(t *testing.T, server *Server, message string) testResponse {
+	t.Helper()
+
+	var resp testResponse
+	require.NoError(t, json.Unmarshal(server.HandleMessage(context.Background(), []byte(message)), &resp))
+
+	return resp
+}
+
+func TestInitialize(t *testing.T) {
+	server := newTestServer()
+
+	resp := call(t, server, `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05"}}`)
+	require.Nil(t, resp.Error)
+
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name string `json:"name"`
+		} `json:"serverInfo"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Result, &result))
+	assert.Equal(t, "2024-11-05", result.ProtocolVersion)
+	assert.Equal(t, "test", result.ServerInfo.Name)
+
+	resp = call(t, server, `{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"protocolVersion":"1999-01-01"}}`)
+	require.NoError(t, json.Unmarshal(resp.Result, &result))
+	assert.Equal(t, LatestProtocolVersion, result.ProtocolVersion)
+}
+
+func TestToolsList(t *testing.T) {
+	resp := call(t, newTestServer(), `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	require.Nil(t, resp.Error)
+
+	var result struct {
+		Tools []Tool `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Result, &result))
+	require.Len(t, result.Tools, 3)
+	assert.Equal(t, "echo", result.Tools[0].Name)
+	assert.Equal(t, "object", result.Tools[1].InputSchema["type"])
+}
+
+func TestToolsCall(t *testing.T) {
+	server := newTestServer()
+
+	resp := call(t, server, `{"jsonrpc":"2.0","id":"a","method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`)
+	require.Nil(t, resp.Error)
+	assert.JSONEq(t, `"a"`, string(resp.ID))
+
+	var result ToolResult
+	require.NoError(t, json.Unmarshal(resp.Result, &result))
+	assert.False(t, result.IsError)
+	require.Len(t, result.Content, 2)
+	assert.Equal(t, TextContent("hi"), result.Content[0])
+	assert.Equal(t, Content{Type: "image", Data: "cG5n", MimeType: "image/png"}, result.Content[1])
+
+	for name, message := range map[string]string{"fail": "failed", "panic": "boom"} {
+		resp = call(t, server, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"`+name+`"}}`)
+		require.Nil(t, resp.Error)
+		require.NoError(t, json.Unmarshal(resp.Result, &result))
+		assert.True(t, result.IsError, name)
+		assert.Equal(t, message, result.Content[0].Text)
+	}
+
+	resp = call(t, server, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"missing"}}`)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codeInvalidParams, resp.Error.Code)
+}
+
+func TestHandleMessageErrors(t *testing.T) {
+	server := newTestServer()
+
+	assert.Nil(t, server.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)))
+
+	resp := call(t, server, `{"jsonrpc":"2.0","id":1,"method":"resources/list"}`)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codeMethodNotFound, resp.Error.Code)
+
+	resp = call(t, server, `not json`)
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, codeParseError, resp.Error.Code)
+
+	var batch []testResponse
+	data := server.HandleMessage(context.Background(), []byte(`[
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":1,"method":"ping"},
+		{"jsonrpc":"2.0","id":2,"method":"ping"}
+	]`))
+	require.NoError(t, json.Unmarshal(data, &batch))
+	assert.Len(t, batch, 2)
+}
+
+func TestServeStdio(t *testing.T) {
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hi"}}}`,
+	}, "\n")
+
+	var out bytes.Buffer
+	require.NoError(t, newTestServer().ServeStdio(context.Background(), strings.NewReader(input), &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+
+	ids := make([]string, 0, len(lines))
+	for _, line := range lines {
+		var resp testResponse
+		require.NoError(t, json.Unmarshal([]byte(line), &resp))
+		assert.Nil(t, resp.Error)
+		ids = append(ids, string(resp.ID))
+	}
+	assert.ElementsMatch(t, []string{"1", "2"}, ids)
+}
+
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(newTestServer()))
+	defer srv.Close()
+
+	post := func(sessionID string, body string, header ...string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		if sessionID != "" {
+			req.Header.Set(HeaderSessionID, sessionID)
+		}
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		return resp
+	}
+
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	sessionID := resp.Header.Get(HeaderSessionID)
+	require.NotEmpty(t, sessionID)
+
+	resp = post(sessionID, `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	resp = post(sessionID, `{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	resp = post("unknown", `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = post("", `{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = post(sessionID, `{"jsonrpc":"2.0","id":4,"method":"ping"}`, "Origin", "http://evil.example.com")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodDelete, srv.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set(HeaderSessionID, sessionID)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = post(sessionID, `{"jsonrpc":"2.0","id":5,"method":"ping"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+const (
+	HeaderSessionID = "Mcp-Session-Id"
+
+	// maxMessageSize 单条消息的最大长度, 截图等结果只在响应中出现, 请求不会很大
+	maxMessageSize = 16 << 20
+)
+
+// ServeStdio 从 r 按行读取 JSON-RPC 消息, 响应按行写入 w, r 结束或 ctx 结束时返回
+// 请求并发处理, 一个耗时的工具调用不会阻塞 ping 等请求
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		writeMux sync.Mutex
+	)
+
+	lines := make(chan []byte)
+	readErr := make(chan error, 1)
+
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+		for scanner.Scan() {
+			line := append([]byte{}, scanner.Bytes()...)
+			if len(line) == 0 {
+				continue
+			}
+
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		readErr <- scanner.Err()
+	}()
+
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case line := <-lines:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				resp := s.HandleMessage(ctx, line)
+				if resp == nil {
+					return
+				}
+
+				writeMux.Lock()
+				defer writeMux.Unlock()
+
+				_, _ = w.Write(append(resp, '\n'))
+			}()
+		}
+	}
+}
+
+// Handler Streamable HTTP 传输, 会话 ID 在 initialize 时分配
+// 只实现 POST 请求和 JSON 响应, 服务端不主动推送消息, 因此 GET 返回 405
+type Handler struct {
+	server   *Server
+	sessions sync.Map
+}
+
+// NewHandler 创建 Streamable HTTP 处理器
+func NewHandler(server *Server) *Handler {
+	return &Handler{server: server}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// 防止 DNS 重绑定, 浏览器发起的请求必须与服务同源
+	if !sameOrigin(r) {
+		http.Error(w, "forbidden origin", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodDelete:
+		sessionID := r.Header.Get(HeaderSessionID)
+		if _, ok := h.sessions.LoadAndDelete(sessionID); !ok {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+	if err != nil {
+		http.Error(w, "read body error", http.StatusBadRequest)
+		return
+	}
+
+	var probe struct {
+		Method string `json:"method"`
+	}
+	initialize := json.Unmarshal(body, &probe) == nil && probe.Method == "initialize"
+
+	// 除 initialize 之外的请求必须携带已分配的会话 ID
+	sessionID := r.Header.Get(HeaderSessionID)
+	switch {
+	case initialize:
+		sessionID = newSessionID()
+		h.sessions.Store(sessionID, struct{}{})
+	case sessionID == "":
+		http.Error(w, "missing session id", http.StatusBadRequest)
+		return
+	default:
+		if _, ok := h.sessions.Load(sessionID); !ok {
+			http.Error(w, "session not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	resp := h.server.HandleMessage(r.Context(), body)
+
+	w.Header().Set(HeaderSessionID, sessionID)
+
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(resp)
+}
+
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return u.Host == r.Host
+}
+
+func newSessionID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+
+	return hex.EncodeToString(buf)
+}
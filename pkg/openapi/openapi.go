@@ -0,0 +1,333 @@
+package openapi
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+// Document OpenAPI 3 文档, 只包含本服务用到的字段
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem 以小写的 HTTP 方法为键
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string              `json:"operationId"`
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema JSON Schema 的 OpenAPI 3.0 子集
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// Ref 引用 components 中的 schema
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// Reflector 根据 Go 类型的 json 和 validate 标签生成 schema, 命名的结构体注册到 components 中
+type Reflector struct {
+	Schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func NewReflector() *Reflector {
+	return &Reflector{Schemas: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+}
+
+// Schema 返回 v 的类型对应的 schema, 结构体返回引用
+func (r *Reflector) Schema(v interface{}) *Schema {
+	if v == nil {
+		return &Schema{}
+	}
+
+	return r.schemaOf(reflect.TypeOf(v))
+}
+
+func (r *Reflector) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: r.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: r.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return r.structSchema(t)
+		}
+		return Ref(r.register(t))
+	}
+
+	// interface{} 可以是任意 JSON 值
+	return &Schema{}
+}
+
+func (r *Reflector) register(t reflect.Type) string {
+	if name, ok := r.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, exists := r.Schemas[name]; exists {
+		// 不同包的同名类型加上包名, 例如 browser.Table 为 browser_Table
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + "_" + name
+	}
+
+	// 先占位, 支持递归引用
+	r.names[t] = name
+	r.Schemas[name] = &Schema{}
+	*r.Schemas[name] = *r.structSchema(t)
+
+	return name
+}
+
+func (r *Reflector) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// 与 encoding/json 一致, 未导出的嵌入结构体的字段仍然会提升
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		name, skip := jsonName(field)
+		if skip {
+			continue
+		}
+
+		// 匿名嵌入的结构体字段提升到外层
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner := r.structSchema(embedded)
+				for k, v := range inner.Properties {
+					schema.Properties[k] = v
+				}
+				schema.Required = append(schema.Required, inner.Required...)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := r.schemaOf(field.Type)
+		if applyValidate(prop, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+
+		schema.Properties[name] = prop
+	}
+
+	return schema
+}
+
+func jsonName(field reflect.StructField) (name string, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", true
+	}
+
+	name, _, _ = strings.Cut(tag, ",")
+
+	return name, false
+}
+
+// applyValidate 把 validator 的规则转换为 schema 约束, 返回字段是否必填
+// 只转换常用的规则, 其余规则写入描述
+func applyValidate(schema *Schema, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	target := schema
+	required := false
+	var notes []string
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+
+		switch key {
+		case "required":
+			if target == schema {
+				required = true
+			}
+		case "omitempty", "":
+		case "dive":
+			// 之后的规则作用于数组元素
+			if schema.Items != nil && schema.Items.Ref == "" {
+				target = schema.Items
+			} else {
+				target = &Schema{}
+			}
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				target.Enum = append(target.Enum, enumValue(target, v))
+			}
+		case "min", "gte":
+			setBound(target, value, true, false)
+		case "max", "lte":
+			setBound(target, value, false, false)
+		case "gt":
+			setBound(target, value, true, true)
+		case "lt":
+			setBound(target, value, false, true)
+		case "url":
+			target.Format = "uri"
+		case "email":
+			target.Format = "email"
+		default:
+			notes = append(notes, rule)
+		}
+	}
+
+	if len(notes) > 0 {
+		schema.Description = strings.TrimSpace(schema.Description + " Validation: " + strings.Join(notes, ", "))
+	}
+
+	return required
+}
+
+func enumValue(schema *Schema, v string) interface{} {
+	switch schema.Type {
+	case "integer":
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+
+	return v
+}
+
+// setBound 数字约束取值范围, 字符串约束长度, 数组约束元素个数
+func setBound(schema *Schema, value string, lower bool, exclusive bool) {
+	switch schema.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return
+		}
+		if lower {
+			schema.Minimum, schema.ExclusiveMinimum = &n, exclusive
+		} else {
+			schema.Maximum, schema.ExclusiveMaximum = &n, exclusive
+		}
+	case "string", "array":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return
+		}
+		if exclusive {
+			if lower {
+				n++
+			} else {
+				n--
+			}
+		}
+
+		switch {
+		case schema.Type == "string" && lower:
+			schema.MinLength = &n
+		case schema.Type == "string":
+			schema.MaxLength = &n
+		case lower:
+			schema.MinItems = &n
+		default:
+			schema.MaxItems = &n
+		}
+	}
+}
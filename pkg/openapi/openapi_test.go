@@ -0,0 +1,68 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name string `json:"name" validate:"required,max=10"`
+}
+
+type testBase struct {
+	SessionID string `json:"session_id"`
+}
+
+type testRequest struct {
+	testBase
+	URL     string            `json:"url" validate:"required,url"`
+	Mode    string            `json:"mode" validate:"omitempty,oneof=fast slow"`
+	Count   int               `json:"count" validate:"omitempty,min=1,max=5"`
+	Ratio   float64           `json:"ratio" validate:"omitempty,gt=0,lte=1"`
+	Items   []testItem        `json:"items" validate:"required,min=1,dive"`
+	Tags    []string          `json:"tags" validate:"dive,oneof=a b"`
+	Labels  map[string]string `json:"labels"`
+	Key     string            `json:"key,omitempty" validate:"required_if=Mode fast"`
+	Any     interface{}       `json:"any"`
+	Ignored string            `json:"-"`
+	hidden  string
+}
+
+func TestReflectorSchema(t *testing.T) {
+	r := NewReflector()
+
+	ref := r.Schema(testRequest{})
+	assert.Equal(t, "#/components/schemas/testRequest", ref.Ref)
+
+	schema := r.Schemas["testRequest"]
+	require.NotNil(t, schema)
+	assert.ElementsMatch(t, []string{"url", "items"}, schema.Required)
+	assert.NotContains(t, schema.Properties, "Ignored")
+	assert.NotContains(t, schema.Properties, "hidden")
+
+	assert.Equal(t, "string", schema.Properties["session_id"].Type)
+	assert.Equal(t, "uri", schema.Properties["url"].Format)
+	assert.Equal(t, []interface{}{"fast", "slow"}, schema.Properties["mode"].Enum)
+
+	count := schema.Properties["count"]
+	assert.Equal(t, "integer", count.Type)
+	assert.Equal(t, 1.0, *count.Minimum)
+	assert.Equal(t, 5.0, *count.Maximum)
+
+	ratio := schema.Properties["ratio"]
+	assert.True(t, ratio.ExclusiveMinimum)
+	assert.Equal(t, 1.0, *ratio.Maximum)
+
+	items := schema.Properties["items"]
+	assert.Equal(t, 1, *items.MinItems)
+	assert.Equal(t, "#/components/schemas/testItem", items.Items.Ref)
+	assert.Equal(t, []string{"name"}, r.Schemas["testItem"].Required)
+	assert.Equal(t, 10, *r.Schemas["testItem"].Properties["name"].MaxLength)
+
+	assert.Equal(t, []interface{}{"a", "b"}, schema.Properties["tags"].Items.Enum)
+	assert.Equal(t, "string", schema.Properties["labels"].AdditionalProperties.Type)
+	assert.Contains(t, schema.Properties["key"].Description, "required_if=Mode fast")
+	assert.Equal(t, &Schema{}, schema.Properties["any"])
+}
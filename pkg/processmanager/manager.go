@@ -1,7 +1,7 @@
 package processmanager
 
 import (
-	"agent/utils/log"
+	"browsertools/log"
 	"bufio"
 	"context"
 	"fmt"
@@ -36,6 +36,10 @@ type ProcessManager struct {
 	stopping  bool
 	ctx       context.Context
 	cancel    context.CancelFunc
+	// onRestart 进程异常退出并重启成功后的回调, 由 mutex 保护
+	onRestart func(status ProcessStatus, exitErr error)
+	// stdout 进程标准输出的写入位置
+	stdout io.Writer
 }
 
 // 创建新的进程管理器
@@ -46,9 +50,15 @@ func NewProcessManager() *ProcessManager {
 		stopping:  false,
 		ctx:       ctx,
 		cancel:    cancel,
+		stdout:    os.Stdout,
 	}
 }
 
+// 设置进程标准输出的写入位置, 需要在 StartAll 之前调用, 例如标准输出用于 MCP 消息时改为标准错误
+func (pm *ProcessManager) SetStdout(w io.Writer) {
+	pm.stdout = w
+}
+
 // 添加进程
 func (pm *ProcessManager) AddProcess(name, executable string, args []string, dependsOn string, maxRestarts int) *Process {
 	process := &Process{
@@ -131,10 +141,10 @@ func (pm *ProcessManager) createCommand(p *Process) *exec.Cmd {
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
 		log.Infof("警告: 无法创建标准输出管道: %v\n", err)
-		cmd.Stdout = os.Stdout
+		cmd.Stdout = pm.stdout
 	} else {
 		// 创建带前缀的输出读取器
-		stdoutReader := NewPrefixedReader(p.Name, stdoutPipe, os.Stdout)
+		stdoutReader := NewPrefixedReader(p.Name, stdoutPipe, pm.stdout)
 		stdoutReader.Start()
 		p.mutex.Lock()
 		p.readers = append(p.readers, stdoutReader)
@@ -357,6 +367,20 @@ func (pm *ProcessManager) monitorProcess(p *Process) {
 
 				log.Infof("%s 已重启，新 PID: %d\n", p.Name, cmd.Process.Pid)
 
+				pm.mutex.Lock()
+				onRestart := pm.onRestart
+				pm.mutex.Unlock()
+
+				if onRestart != nil {
+					onRestart(ProcessStatus{
+						Name:        p.Name,
+						PID:         cmd.Process.Pid,
+						Running:     true,
+						Restarts:    restartCount,
+						MaxRestarts: p.MaxRestarts,
+					}, err)
+				}
+
 				// 等待旧的readers完成工作
 				for _, reader := range oldReaders {
 					reader.Wait()
@@ -516,3 +540,44 @@ func (pm *ProcessManager) StopAll() {
 
 	log.Println("所有进程已终止")
 }
+
+// 设置进程重启后的回调, 回调在监控协程中执行, 不能阻塞
+func (pm *ProcessManager) OnRestart(fn func(status ProcessStatus, exitErr error)) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.onRestart = fn
+}
+
+// ProcessStatus 进程运行状态
+type ProcessStatus struct {
+	Name        string
+	PID         int
+	Running     bool
+	Restarts    int
+	MaxRestarts int
+}
+
+// 返回所有进程的运行状态
+func (pm *ProcessManager) Status() []ProcessStatus {
+	pm.mutex.Lock()
+	processes := append([]*Process{}, pm.processes...)
+	pm.mutex.Unlock()
+
+	list := make([]ProcessStatus, 0, len(processes))
+	for _, p := range processes {
+		p.mutex.Lock()
+		status := ProcessStatus{Name: p.Name, Restarts: p.Restarts, MaxRestarts: p.MaxRestarts}
+		p.mutex.Unlock()
+
+		// 与启动依赖进程时一样, 通过信号0检查进程是否仍在运行
+		if p.Cmd != nil && p.Cmd.Process != nil {
+			status.PID = p.Cmd.Process.Pid
+			status.Running = p.Cmd.Process.Signal(syscall.Signal(0)) == nil
+		}
+
+		list = append(list, status)
+	}
+
+	return list
+}
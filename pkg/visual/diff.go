@@ -0,0 +1,350 @@
+package visual
+
+import (
+	"browsertools/pkg/errors"
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+)
+
+const (
+	// YIQ 色差的最大值, 用于把 0-1 的阈值映射到色差
+	maxYIQDelta = 35215
+
+	// 合并差异区域时使用的网格大小
+	regionCellSize = 16
+
+	defaultThreshold = 0.1
+)
+
+var (
+	diffColor = color.RGBA{R: 255, A: 255}
+	aaColor   = color.RGBA{R: 255, G: 255, A: 255}
+	boxColor  = color.RGBA{R: 255, B: 255, A: 255}
+)
+
+// Options 对比参数
+type Options struct {
+	// Threshold 像素色差阈值 0-1, 越小越敏感, 0 时使用默认值 0.1
+	Threshold float64
+	// IncludeAA 为 true 时抗锯齿像素也计为差异
+	IncludeAA bool
+}
+
+// Result 对比结果
+type Result struct {
+	Width           int
+	Height          int
+	DiffPixels      int
+	TotalPixels     int
+	MismatchPercent float64
+	SizeMismatch    bool
+	Regions         []image.Rectangle
+	DiffImage       *image.RGBA
+}
+
+// Diff 逐像素对比两张图片, 尺寸不同时按较大的尺寸对比, 超出部分计为差异
+func Diff(expected image.Image, actual image.Image, opt Options) *Result {
+	img1 := toRGBA(expected)
+	img2 := toRGBA(actual)
+
+	width := max(img1.Bounds().Dx(), img2.Bounds().Dx())
+	height := max(img1.Bounds().Dy(), img2.Bounds().Dy())
+
+	threshold := opt.Threshold
+	if threshold <= 0 {
+		threshold = defaultThreshold
+	}
+	maxDelta := maxYIQDelta * threshold * threshold
+
+	result := &Result{
+		Width:        width,
+		Height:       height,
+		TotalPixels:  width * height,
+		SizeMismatch: img1.Bounds().Size() != img2.Bounds().Size(),
+		DiffImage:    image.NewRGBA(image.Rect(0, 0, width, height)),
+	}
+
+	cols := (width + regionCellSize - 1) / regionCellSize
+	rows := (height + regionCellSize - 1) / regionCellSize
+	cells := make([]bool, cols*rows)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			in1 := image.Pt(x, y).In(img1.Bounds())
+			in2 := image.Pt(x, y).In(img2.Bounds())
+
+			if !in1 || !in2 {
+				result.DiffImage.SetRGBA(x, y, diffColor)
+				result.DiffPixels++
+				cells[(y/regionCellSize)*cols+x/regionCellSize] = true
+				continue
+			}
+
+			delta := colorDelta(img1, img2, x, y, x, y, false)
+			if abs(delta) <= maxDelta {
+				result.DiffImage.SetRGBA(x, y, grayPixel(img1, x, y))
+				continue
+			}
+
+			if !opt.IncludeAA && (antialiased(img1, x, y, img2) || antialiased(img2, x, y, img1)) {
+				result.DiffImage.SetRGBA(x, y, aaColor)
+				continue
+			}
+
+			result.DiffImage.SetRGBA(x, y, diffColor)
+			result.DiffPixels++
+			cells[(y/regionCellSize)*cols+x/regionCellSize] = true
+		}
+	}
+
+	if result.TotalPixels > 0 {
+		result.MismatchPercent = float64(result.DiffPixels) * 100 / float64(result.TotalPixels)
+	}
+
+	result.Regions = mergeRegions(cells, cols, rows, width, height)
+	for _, region := range result.Regions {
+		drawBox(result.DiffImage, region)
+	}
+
+	return result
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Bounds().Min == image.Pt(0, 0) {
+		return rgba
+	}
+
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	return rgba
+}
+
+// colorDelta 计算两个像素在 YIQ 空间的色差, 参考 pixelmatch 的实现
+func colorDelta(img1 *image.RGBA, img2 *image.RGBA, x1, y1, x2, y2 int, yOnly bool) float64 {
+	c1 := img1.RGBAAt(x1, y1)
+	c2 := img2.RGBAAt(x2, y2)
+
+	if c1 == c2 {
+		return 0
+	}
+
+	r1, g1, b1 := blend(c1)
+	r2, g2, b2 := blend(c2)
+
+	yy1 := rgb2y(r1, g1, b1)
+	yy2 := rgb2y(r2, g2, b2)
+	y := yy1 - yy2
+
+	if yOnly {
+		return y
+	}
+
+	i := rgb2i(r1, g1, b1) - rgb2i(r2, g2, b2)
+	q := rgb2q(r1, g1, b1) - rgb2q(r2, g2, b2)
+
+	delta := 0.5053*y*y + 0.299*i*i + 0.1957*q*q
+	if yy1 > yy2 {
+		return -delta
+	}
+
+	return delta
+}
+
+// blend 将半透明像素混合到白色背景上
+func blend(c color.RGBA) (float64, float64, float64) {
+	r, g, b := float64(c.R), float64(c.G), float64(c.B)
+	if c.A == 255 {
+		return r, g, b
+	}
+
+	a := float64(c.A) / 255
+
+	return 255 + (r-255)*a, 255 + (g-255)*a, 255 + (b-255)*a
+}
+
+func rgb2y(r, g, b float64) float64 { return r*0.29889531 + g*0.58662247 + b*0.11448223 }
+func rgb2i(r, g, b float64) float64 { return r*0.59597799 - g*0.27417610 - b*0.32180189 }
+func rgb2q(r, g, b float64) float64 { return r*0.21147017 - g*0.52261711 + b*0.31114694 }
+
+// antialiased 判断像素是否是抗锯齿产生的, 参考 pixelmatch 的实现
+func antialiased(img *image.RGBA, x1, y1 int, other *image.RGBA) bool {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	x0, y0 := max(x1-1, 0), max(y1-1, 0)
+	x2, y2 := min(x1+1, width-1), min(y1+1, height-1)
+
+	zeroes := 0
+	if x1 == x0 || x1 == x2 || y1 == y0 || y1 == y2 {
+		zeroes = 1
+	}
+
+	var minDelta, maxDelta float64
+	var minX, minY, maxX, maxY int
+
+	for x := x0; x <= x2; x++ {
+		for y := y0; y <= y2; y++ {
+			if x == x1 && y == y1 {
+				continue
+			}
+
+			delta := colorDelta(img, img, x1, y1, x, y, true)
+			switch {
+			case delta == 0:
+				zeroes++
+				if zeroes > 2 {
+					return false
+				}
+			case delta < minDelta:
+				minDelta, minX, minY = delta, x, y
+			case delta > maxDelta:
+				maxDelta, maxX, maxY = delta, x, y
+			}
+		}
+	}
+
+	if minDelta == 0 || maxDelta == 0 {
+		return false
+	}
+
+	return (hasManySiblings(img, minX, minY) && hasManySiblings(other, minX, minY)) ||
+		(hasManySiblings(img, maxX, maxY) && hasManySiblings(other, maxX, maxY))
+}
+
+// hasManySiblings 判断像素周围是否有超过2个相同颜色的像素
+func hasManySiblings(img *image.RGBA, x1, y1 int) bool {
+	if !image.Pt(x1, y1).In(img.Bounds()) {
+		return false
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	x0, y0 := max(x1-1, 0), max(y1-1, 0)
+	x2, y2 := min(x1+1, width-1), min(y1+1, height-1)
+
+	zeroes := 0
+	if x1 == x0 || x1 == x2 || y1 == y0 || y1 == y2 {
+		zeroes = 1
+	}
+
+	c := img.RGBAAt(x1, y1)
+
+	for x := x0; x <= x2; x++ {
+		for y := y0; y <= y2; y++ {
+			if x == x1 && y == y1 {
+				continue
+			}
+
+			if img.RGBAAt(x, y) == c {
+				zeroes++
+			}
+
+			if zeroes > 2 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// grayPixel 未变化的像素以淡化的灰度显示
+func grayPixel(img *image.RGBA, x, y int) color.RGBA {
+	r, g, b := blend(img.RGBAAt(x, y))
+	v := uint8(255 + (rgb2y(r, g, b)-255)*0.1)
+
+	return color.RGBA{R: v, G: v, B: v, A: 255}
+}
+
+// mergeRegions 将相邻的差异网格合并为矩形区域
+func mergeRegions(cells []bool, cols, rows, width, height int) []image.Rectangle {
+	visited := make([]bool, len(cells))
+	regions := make([]image.Rectangle, 0)
+
+	for start := range cells {
+		if !cells[start] || visited[start] {
+			continue
+		}
+
+		rect := image.Rectangle{}
+		stack := []int{start}
+		visited[start] = true
+
+		for len(stack) > 0 {
+			idx := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			cx, cy := idx%cols, idx/cols
+			cell := image.Rect(cx*regionCellSize, cy*regionCellSize,
+				min((cx+1)*regionCellSize, width), min((cy+1)*regionCellSize, height))
+			rect = rect.Union(cell)
+
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := cx+dx, cy+dy
+					if nx < 0 || ny < 0 || nx >= cols || ny >= rows {
+						continue
+					}
+
+					next := ny*cols + nx
+					if cells[next] && !visited[next] {
+						visited[next] = true
+						stack = append(stack, next)
+					}
+				}
+			}
+		}
+
+		regions = append(regions, rect)
+	}
+
+	return regions
+}
+
+// drawBox 在差异图上画出区域边框
+func drawBox(img *image.RGBA, rect image.Rectangle) {
+	for x := rect.Min.X; x < rect.Max.X; x++ {
+		img.SetRGBA(x, rect.Min.Y, boxColor)
+		img.SetRGBA(x, rect.Max.Y-1, boxColor)
+	}
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		img.SetRGBA(rect.Min.X, y, boxColor)
+		img.SetRGBA(rect.Max.X-1, y, boxColor)
+	}
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+// DiffPNG 对比两张 PNG 图片
+func DiffPNG(expected []byte, actual []byte, opt Options) (*Result, error) {
+	img1, err := png.Decode(bytes.NewReader(expected))
+	if err != nil {
+		return nil, errors.WithMessage(err, "decode baseline image error")
+	}
+
+	img2, err := png.Decode(bytes.NewReader(actual))
+	if err != nil {
+		return nil, errors.WithMessage(err, "decode actual image error")
+	}
+
+	return Diff(img1, img2, opt), nil
+}
+
+// EncodeDiffImage 将差异图编码为 PNG
+func (r *Result) EncodeDiffImage() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, r.DiffImage); err != nil {
+		return nil, errors.WithMessage(err, "encode diff image error")
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,108 @@
+package visual
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newImage(width int, height int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestDiffIdentical(t *testing.T) {
+	img := newImage(40, 30, color.RGBA{R: 10, G: 20, B: 30, A: 255})
+
+	result := Diff(img, img, Options{})
+	assert.Equal(t, 0, result.DiffPixels)
+	assert.Equal(t, 1200, result.TotalPixels)
+	assert.Equal(t, float64(0), result.MismatchPercent)
+	assert.False(t, result.SizeMismatch)
+	assert.Empty(t, result.Regions)
+}
+
+func TestDiffRegions(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	black := color.RGBA{A: 255}
+
+	expected := newImage(100, 100, white)
+	actual := newImage(100, 100, white)
+
+	// 两块不相邻的差异区域
+	for y := 2; y < 10; y++ {
+		for x := 2; x < 10; x++ {
+			actual.SetRGBA(x, y, black)
+		}
+	}
+	for y := 70; y < 80; y++ {
+		for x := 60; x < 90; x++ {
+			actual.SetRGBA(x, y, black)
+		}
+	}
+
+	result := Diff(expected, actual, Options{})
+	assert.Equal(t, 64+300, result.DiffPixels)
+	assert.InDelta(t, 3.64, result.MismatchPercent, 0.001)
+	assert.Len(t, result.Regions, 2)
+	assert.Equal(t, diffColor, result.DiffImage.RGBAAt(5, 5))
+	assert.True(t, image.Pt(75, 75).In(result.Regions[1]) || image.Pt(75, 75).In(result.Regions[0]))
+}
+
+func TestDiffThreshold(t *testing.T) {
+	expected := newImage(10, 10, color.RGBA{R: 200, G: 200, B: 200, A: 255})
+	actual := newImage(10, 10, color.RGBA{R: 205, G: 205, B: 205, A: 255})
+
+	assert.Equal(t, 0, Diff(expected, actual, Options{Threshold: 0.1}).DiffPixels)
+	assert.Equal(t, 100, Diff(expected, actual, Options{Threshold: 0.01}).DiffPixels)
+}
+
+func TestDiffSizeMismatch(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+	result := Diff(newImage(10, 10, white), newImage(10, 12, white), Options{})
+	assert.True(t, result.SizeMismatch)
+	assert.Equal(t, 20, result.DiffPixels)
+	assert.Equal(t, 120, result.TotalPixels)
+}
+
+func TestValidName(t *testing.T) {
+	assert.True(t, ValidName("home-page_v1.mobile"))
+	assert.False(t, ValidName("../etc/passwd"))
+	assert.False(t, ValidName("a/b"))
+	assert.False(t, ValidName(""))
+	assert.False(t, ValidName("home.actual"))
+}
+
+func TestBaselineStore(t *testing.T) {
+	store := NewBaselineStore(t.TempDir())
+
+	_, err := store.Load("home")
+	assert.Error(t, err)
+
+	assert.NoError(t, store.Save("home", []byte("v1")))
+	assert.NoError(t, store.SaveActual("home", []byte("v2")))
+
+	data, err := store.Load("home")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+
+	assert.NoError(t, store.Approve("home"))
+	data, err = store.Load("home")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(data))
+	assert.Error(t, store.Approve("home"))
+
+	list, err := store.List()
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "home", list[0].Name)
+}
@@ -0,0 +1,159 @@
+package visual
+
+import (
+	"browsertools/pkg/errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	baselineExt = ".png"
+	actualExt   = ".actual.png"
+)
+
+var baselineNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_\-.]{0,127}$`)
+
+// Baseline 基准图信息
+type Baseline struct {
+	Name       string
+	Size       int64
+	UpdateTime time.Time
+}
+
+// BaselineStore 基于目录的基准图存储, 每个基准图保存为 <name>.png,
+// 最近一次对比的实际截图保存为 <name>.actual.png 以便审核通过
+type BaselineStore struct {
+	dir string
+	mux *sync.Mutex
+}
+
+// NewBaselineStore 创建基准图存储
+func NewBaselineStore(dir string) *BaselineStore {
+	return &BaselineStore{dir: dir, mux: &sync.Mutex{}}
+}
+
+// ValidName 校验基准图名称, 防止路径穿越
+func ValidName(name string) bool {
+	return baselineNameRegexp.MatchString(name) && !strings.HasSuffix(name, ".actual")
+}
+
+// Load 读取基准图
+func (s *BaselineStore) Load(name string) ([]byte, error) {
+	return s.read(name, baselineExt)
+}
+
+// Save 保存基准图
+func (s *BaselineStore) Save(name string, data []byte) error {
+	return s.write(name, baselineExt, data)
+}
+
+// SaveActual 保存最近一次对比的实际截图
+func (s *BaselineStore) SaveActual(name string, data []byte) error {
+	return s.write(name, actualExt, data)
+}
+
+// Approve 将最近一次对比的实际截图设为新的基准图
+func (s *BaselineStore) Approve(name string) error {
+	if !ValidName(name) {
+		return errors.ErrArgument
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	actual := filepath.Join(s.dir, name+actualExt)
+	if _, err := os.Stat(actual); err != nil {
+		return errors.ErrBaselineNotFound
+	}
+
+	return os.Rename(actual, filepath.Join(s.dir, name+baselineExt))
+}
+
+// List 按名称返回所有基准图
+func (s *BaselineStore) List() ([]Baseline, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Baseline{}, nil
+		}
+
+		return nil, errors.WithMessage(err, "read baseline dir error")
+	}
+
+	list := make([]Baseline, 0, len(entries))
+
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, baselineExt) || strings.HasSuffix(fileName, actualExt) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		list = append(list, Baseline{
+			Name:       strings.TrimSuffix(fileName, baselineExt),
+			Size:       info.Size(),
+			UpdateTime: info.ModTime(),
+		})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list, nil
+}
+
+func (s *BaselineStore) read(name string, ext string) ([]byte, error) {
+	if !ValidName(name) {
+		return nil, errors.ErrArgument
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(s.dir, name+ext))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.ErrBaselineNotFound
+		}
+
+		return nil, errors.WithMessage(err, "read baseline error")
+	}
+
+	return data, nil
+}
+
+func (s *BaselineStore) write(name string, ext string, data []byte) error {
+	if !ValidName(name) {
+		return errors.ErrArgument
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return errors.WithMessage(err, "create baseline dir error")
+	}
+
+	// 先写临时文件再重命名, 避免对比时读到写了一半的文件
+	path := filepath.Join(s.dir, name+ext)
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return errors.WithMessage(err, "write baseline error")
+	}
+
+	return os.Rename(tmp, path)
+}
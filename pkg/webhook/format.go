@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"browsertools/log"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// eventTitles 机器人消息中事件的中文标题
+var eventTitles = map[string]string{
+	EventTabOpened:           "标签页已打开",
+	EventTabClosed:           "标签页已关闭",
+	EventNavigationFailed:    "页面导航失败",
+	EventPageCrashed:         "页面崩溃",
+	EventBrowserDisconnected: "浏览器断开连接",
+	EventBrowserRelaunched:   "浏览器已重启",
+	EventDownloadFinished:    "下载完成",
+	EventProcessRestarted:    "进程已重启",
+}
+
+func encode(format string, event Event) ([]byte, error) {
+	switch format {
+	case FormatDingTalk:
+		msg := Markdown(event)
+		msg.Markdown.Text = msg.Markdown.Content
+		return json.Marshal(msg)
+	case FormatWeCom:
+		return json.Marshal(Markdown(event))
+	default:
+		return json.Marshal(event)
+	}
+}
+
+// Markdown 把事件格式化为钉钉和企业微信机器人的 markdown 消息
+func Markdown(event Event) log.Message {
+	title, ok := eventTitles[event.Type]
+	if !ok {
+		title = event.Type
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n", title)
+	fmt.Fprintf(&b, "> 事件: %s\n\n", event.Type)
+	fmt.Fprintf(&b, "> 时间: %s\n\n", event.Time.Format("2006-01-02 15:04:05"))
+
+	if event.Host != "" {
+		fmt.Fprintf(&b, "> 主机: %s\n\n", event.Host)
+	}
+
+	if event.SessionID != "" {
+		fmt.Fprintf(&b, "> 会话: %s\n\n", event.SessionID)
+	}
+
+	if event.PageID != "" {
+		fmt.Fprintf(&b, "> 页面: %s\n\n", event.PageID)
+	}
+
+	if event.URL != "" {
+		fmt.Fprintf(&b, "> 地址: %s\n\n", event.URL)
+	}
+
+	if event.Message != "" {
+		fmt.Fprintf(&b, "> 详情: %s\n\n", event.Message)
+	}
+
+	keys := make([]string, 0, len(event.Data))
+	for key := range event.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(&b, "> %s: %v\n\n", key, event.Data[key])
+	}
+
+	msg := log.Message{MsgType: "markdown"}
+	msg.Markdown.Title = title
+	msg.Markdown.Content = strings.TrimRight(b.String(), "\n")
+
+	return msg
+}
+
+// signDingTalkURL 钉钉加签: 在地址中加入 timestamp 和 sign=Base64(HmacSHA256(timestamp+"\n"+secret))
+func signDingTalkURL(rawURL string, secret string, timestamp int64) string {
+	ts := strconv.FormatInt(timestamp, 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "\n" + secret))
+	sign := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+
+	return rawURL + sep + "timestamp=" + ts + "&sign=" + url.QueryEscape(sign)
+}
+
+// checkRobotResponse 钉钉和企业微信出错时也返回 200, 需要检查 errcode
+func checkRobotResponse(format string, resp *http.Response) error {
+	if format != FormatDingTalk && format != FormatWeCom {
+		return nil
+	}
+
+	var result struct {
+		ErrCode int    `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decode robot response error: %w", err)
+	}
+
+	if result.ErrCode != 0 {
+		return fmt.Errorf("robot error %d: %s", result.ErrCode, result.ErrMsg)
+	}
+
+	return nil
+}
+
+// redact 日志中隐藏地址里的 access_token, key 等查询参数
+func redact(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "invalid url"
+	}
+
+	u.RawQuery = ""
+	u.User = nil
+
+	return u.String()
+}
@@ -0,0 +1,16 @@
+package webhook
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const webhookNamespace = "webhook"
+
+var metricDeliveryTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace:   webhookNamespace,
+	Subsystem:   "delivery",
+	Name:        "total",
+	Help:        "webhook delivery count by event type and result.",
+	ConstLabels: map[string]string{},
+}, []string{"type", "result"})
@@ -0,0 +1,378 @@
+package webhook
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	EventTabOpened           = "tab.opened"
+	EventTabClosed           = "tab.closed"
+	EventNavigationFailed    = "navigation.failed"
+	EventPageCrashed         = "page.crashed"
+	EventBrowserDisconnected = "browser.disconnected"
+	EventBrowserRelaunched   = "browser.relaunched"
+	EventDownloadFinished    = "download.finished"
+	EventProcessRestarted    = "process.restarted"
+
+	// FormatJSON 原样发送事件 JSON, 使用 HMAC 签名请求头
+	FormatJSON = "json"
+	// FormatDingTalk 钉钉机器人 markdown 消息, 按钉钉的方式在地址中签名
+	FormatDingTalk = "dingtalk"
+	// FormatWeCom 企业微信机器人 markdown 消息, 地址中的 key 即为凭证
+	FormatWeCom = "wecom"
+
+	HeaderEvent     = "X-Browsertools-Event"
+	HeaderTimestamp = "X-Browsertools-Timestamp"
+	HeaderSignature = "X-Browsertools-Signature"
+
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultTimeout        = 10 * time.Second
+	defaultQueueSize      = 1000
+)
+
+// Event 推送给 webhook 的事件
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	Time      time.Time              `json:"time"`
+	Host      string                 `json:"host,omitempty"`
+	SessionID string                 `json:"session_id,omitempty"`
+	PageID    string                 `json:"page_id,omitempty"`
+	URL       string                 `json:"url,omitempty"`
+	Message   string                 `json:"message,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Endpoint 一个推送地址
+type Endpoint struct {
+	URL string
+	// Secret 为空时不签名
+	Secret string
+	// Events 订阅的事件类型, 为空时订阅所有事件
+	Events []string
+	// Format json, dingtalk 或 wecom
+	Format string
+}
+
+// Subscribed 是否订阅了该类型的事件
+func (e Endpoint) Subscribed(eventType string) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, eventType)
+}
+
+// Options 推送参数
+type Options struct {
+	Endpoints      []Endpoint
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Timeout        time.Duration
+	QueueSize      int
+	// DeadLetterFile 重试失败的事件按行追加到该文件, 为空时只记录日志
+	DeadLetterFile string
+}
+
+// DefaultOptions 默认推送参数, 不包含推送地址
+func DefaultOptions() Options {
+	return Options{
+		MaxAttempts:    defaultMaxAttempts,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+		Timeout:        defaultTimeout,
+		QueueSize:      defaultQueueSize,
+	}
+}
+
+type delivery struct {
+	endpoint Endpoint
+	event    Event
+}
+
+// Dispatcher 异步推送事件, 失败时按指数退避重试, 最终失败的事件写入死信文件
+type Dispatcher struct {
+	opt    Options
+	client *http.Client
+	host   string
+	// queues 每个地址一个队列和发送协程, 一个地址不可用时不影响其他地址
+	queues []chan delivery
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+	// deadMux 保护死信文件的写入
+	deadMux sync.Mutex
+	// closeMux 保护 closed, 关闭队列后不能再发送
+	closeMux sync.RWMutex
+	closed   bool
+}
+
+// New 创建并启动推送器
+func New(opt Options) *Dispatcher {
+	if opt.MaxAttempts <= 0 {
+		opt.MaxAttempts = defaultMaxAttempts
+	}
+
+	if opt.InitialBackoff <= 0 {
+		opt.InitialBackoff = defaultInitialBackoff
+	}
+
+	if opt.MaxBackoff < opt.InitialBackoff {
+		opt.MaxBackoff = max(defaultMaxBackoff, opt.InitialBackoff)
+	}
+
+	if opt.Timeout <= 0 {
+		opt.Timeout = defaultTimeout
+	}
+
+	if opt.QueueSize <= 0 {
+		opt.QueueSize = defaultQueueSize
+	}
+
+	host, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := &Dispatcher{
+		opt:    opt,
+		client: &http.Client{Timeout: opt.Timeout},
+		host:   host,
+		queues: make([]chan delivery, len(opt.Endpoints)),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for i := range opt.Endpoints {
+		d.queues[i] = make(chan delivery, opt.QueueSize)
+
+		d.wg.Add(1)
+		go d.run(d.queues[i])
+	}
+
+	return d
+}
+
+// Publish 把事件放入发送队列, 不会阻塞; 队列已满时直接写入死信
+func (d *Dispatcher) Publish(event Event) {
+	if event.ID == "" {
+		event.ID = newEventID()
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if event.Host == "" {
+		event.Host = d.host
+	}
+
+	d.closeMux.RLock()
+	defer d.closeMux.RUnlock()
+
+	if d.closed {
+		return
+	}
+
+	for i, endpoint := range d.opt.Endpoints {
+		if !endpoint.Subscribed(event.Type) {
+			continue
+		}
+
+		select {
+		case d.queues[i] <- delivery{endpoint: endpoint, event: event}:
+		default:
+			d.deadLetter(delivery{endpoint: endpoint, event: event}, 0, errors.New("webhook queue is full"))
+		}
+	}
+}
+
+// Close 停止接收新事件, 在 ctx 结束前尽量发送完队列中的事件, 未发送的写入死信
+func (d *Dispatcher) Close(ctx context.Context) {
+	d.closeMux.Lock()
+	if d.closed {
+		d.closeMux.Unlock()
+		return
+	}
+
+	d.closed = true
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.closeMux.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// 中断重试等待和进行中的请求
+		d.cancel()
+		<-done
+	}
+
+	d.cancel()
+}
+
+func (d *Dispatcher) run(queue chan delivery) {
+	defer d.wg.Done()
+
+	for item := range queue {
+		if d.ctx.Err() != nil {
+			d.deadLetter(item, 0, d.ctx.Err())
+			continue
+		}
+
+		d.deliver(item)
+	}
+}
+
+func (d *Dispatcher) deliver(item delivery) {
+	backoff := d.opt.InitialBackoff
+
+	var err error
+	for attempt := 1; attempt <= d.opt.MaxAttempts; attempt++ {
+		err = d.send(item)
+		if err == nil {
+			metricDeliveryTotal.WithLabelValues(item.event.Type, "success").Inc()
+			return
+		}
+
+		if attempt == d.opt.MaxAttempts {
+			break
+		}
+
+		log.Infof("webhook %s to %s attempt %d error: %v, retry in %v",
+			item.event.Type, redact(item.endpoint.URL), attempt, err, backoff)
+
+		select {
+		case <-d.ctx.Done():
+			d.deadLetter(item, attempt, err)
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, d.opt.MaxBackoff)
+	}
+
+	d.deadLetter(item, d.opt.MaxAttempts, err)
+}
+
+func (d *Dispatcher) send(item delivery) error {
+	body, err := encode(item.endpoint.Format, item.event)
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().UnixMilli()
+
+	url := item.endpoint.URL
+	if item.endpoint.Format == FormatDingTalk && item.endpoint.Secret != "" {
+		url = signDingTalkURL(url, item.endpoint.Secret, timestamp)
+	}
+
+	req, err := http.NewRequestWithContext(d.ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.WithMessage(err, "new webhook request error")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, item.event.Type)
+
+	if item.endpoint.Format != FormatDingTalk && item.endpoint.Secret != "" {
+		ts := strconv.FormatInt(timestamp, 10)
+		req.Header.Set(HeaderTimestamp, ts)
+		req.Header.Set(HeaderSignature, Sign(item.endpoint.Secret, ts, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return checkRobotResponse(item.endpoint.Format, resp)
+}
+
+// deadLetter 记录最终发送失败的事件
+func (d *Dispatcher) deadLetter(item delivery, attempts int, err error) {
+	metricDeliveryTotal.WithLabelValues(item.event.Type, "dead").Inc()
+	log.Errorf("webhook %s %s to %s failed after %d attempts: %v",
+		item.event.Type, item.event.ID, redact(item.endpoint.URL), attempts, err)
+
+	if d.opt.DeadLetterFile == "" {
+		return
+	}
+
+	line, _ := json.Marshal(struct {
+		Endpoint string    `json:"endpoint"`
+		Attempts int       `json:"attempts"`
+		Error    string    `json:"error"`
+		Time     time.Time `json:"time"`
+		Event    Event     `json:"event"`
+	}{redact(item.endpoint.URL), attempts, err.Error(), time.Now(), item.event})
+
+	d.deadMux.Lock()
+	defer d.deadMux.Unlock()
+
+	if ferr := os.MkdirAll(filepath.Dir(d.opt.DeadLetterFile), 0o755); ferr != nil {
+		log.Errorf("create webhook dead letter dir error: %v", ferr)
+		return
+	}
+
+	f, ferr := os.OpenFile(d.opt.DeadLetterFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if ferr != nil {
+		log.Errorf("open webhook dead letter file error: %v", ferr)
+		return
+	}
+	defer f.Close()
+
+	if _, ferr = f.Write(append(line, '\n')); ferr != nil {
+		log.Errorf("write webhook dead letter file error: %v", ferr)
+	}
+}
+
+// Sign 计算 HMAC-SHA256 签名, 签名内容为 "时间戳.请求体", 接收方应同时校验时间戳防止重放
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验 Sign 生成的签名
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+func newEventID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,163 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"type":"tab.opened"}`)
+	signature := Sign("secret", "1700000000000", body)
+
+	assert.True(t, strings.HasPrefix(signature, "sha256="))
+	assert.True(t, Verify("secret", "1700000000000", body, signature))
+	assert.False(t, Verify("other", "1700000000000", body, signature))
+	assert.False(t, Verify("secret", "1700000000001", body, signature))
+}
+
+func TestMarkdown(t *testing.T) {
+	msg := Markdown(Event{
+		Type:    EventPageCrashed,
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local),
+		PageID:  "p1",
+		URL:     "https://example.com",
+		Message: "renderer crashed",
+		Data:    map[string]interface{}{"b": 2, "a": 1},
+	})
+
+	assert.Equal(t, "markdown", msg.MsgType)
+	assert.Equal(t, "页面崩溃", msg.Markdown.Title)
+	assert.Contains(t, msg.Markdown.Content, "### 页面崩溃")
+	assert.Contains(t, msg.Markdown.Content, "2024-01-02 03:04:05")
+	assert.Contains(t, msg.Markdown.Content, "https://example.com")
+	assert.Less(t, strings.Index(msg.Markdown.Content, "> a: 1"), strings.Index(msg.Markdown.Content, "> b: 2"))
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan Event, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 第一次请求失败, 验证重试
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, EventTabOpened, r.Header.Get(HeaderEvent))
+		assert.True(t, Verify("secret", r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature)))
+
+		var event Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+		received <- event
+	}))
+	defer server.Close()
+
+	d := New(Options{
+		Endpoints:      []Endpoint{{URL: server.URL, Secret: "secret"}},
+		InitialBackoff: 10 * time.Millisecond,
+	})
+	defer d.Close(context.Background())
+
+	d.Publish(Event{Type: EventTabOpened, PageID: "p1"})
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "p1", event.PageID)
+		assert.NotEmpty(t, event.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestDispatcher_DeadLetter(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "dead", "letter.jsonl")
+	d := New(Options{
+		Endpoints: []Endpoint{
+			{URL: server.URL + "?access_token=hidden", Events: []string{EventPageCrashed}},
+		},
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+		DeadLetterFile: file,
+	})
+
+	// 未订阅的事件不发送
+	d.Publish(Event{Type: EventTabOpened})
+	d.Publish(Event{Type: EventPageCrashed, PageID: "p1"})
+	d.Close(context.Background())
+
+	assert.Equal(t, int32(3), calls.Load())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	var line struct {
+		Endpoint string `json:"endpoint"`
+		Attempts int    `json:"attempts"`
+		Event    Event  `json:"event"`
+	}
+	require.NoError(t, json.Unmarshal(data, &line))
+
+	assert.Equal(t, 3, line.Attempts)
+	assert.Equal(t, "p1", line.Event.PageID)
+	assert.NotContains(t, line.Endpoint, "hidden")
+}
+
+func TestDispatcher_RobotError(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.NotEmpty(t, r.URL.Query().Get("sign"))
+
+		var msg struct {
+			MsgType  string `json:"msgtype"`
+			Markdown struct {
+				Title string `json:"title"`
+				Text  string `json:"text"`
+			} `json:"markdown"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		assert.Equal(t, "markdown", msg.MsgType)
+		assert.Equal(t, "浏览器已重启", msg.Markdown.Title)
+		assert.NotEmpty(t, msg.Markdown.Text)
+
+		_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+	}))
+	defer server.Close()
+
+	d := New(Options{
+		Endpoints:      []Endpoint{{URL: server.URL, Secret: "SECxxx", Format: FormatDingTalk}},
+		MaxAttempts:    2,
+		InitialBackoff: 10 * time.Millisecond,
+	})
+
+	d.Publish(Event{Type: EventBrowserRelaunched})
+	d.Close(context.Background())
+
+	// 机器人返回错误码时按失败处理并重试
+	assert.Equal(t, int32(2), calls.Load())
+}
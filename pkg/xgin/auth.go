@@ -0,0 +1,32 @@
+package xgin
+
+import (
+	"browsertools/pkg/auth"
+	"browsertools/pkg/errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Auth 校验请求凭证并要求 scope 权限, 凭证无效时返回 ErrInvalidToken, 权限不足时返回 ErrNoPermission
+// a 为 nil 时不校验, 路由可以在未开启认证时使用同样的分组
+func Auth(a *auth.Authenticator, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if a == nil {
+			c.Next()
+			return
+		}
+
+		principal, err := a.Authenticate(c.Request)
+		if err != nil {
+			errors.Throw(errors.ErrInvalidToken, err.Error())
+		}
+
+		if !principal.Allows(scope) {
+			errors.Throw(errors.ErrNoPermission, fmt.Sprintf("%s requires scope %s", principal, scope))
+		}
+
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), principal))
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package xgin
+
+import (
+	"browsertools/pkg/auth"
+	"browsertools/pkg/errors"
+	"browsertools/pkg/response"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth(t *testing.T) {
+	a, err := auth.New(auth.Options{Tokens: []auth.Token{{Name: "viewer", Token: "viewer-token-0123456789", Scopes: []string{auth.ScopeRead}}}})
+	require.NoError(t, err)
+
+	router := New()
+	handler := func(c *gin.Context) {
+		p, ok := auth.FromContext(c.Request.Context())
+		require.True(t, ok)
+		c.JSON(http.StatusOK, response.New(p.Subject))
+	}
+	router.GET("/read", Auth(a, auth.ScopeRead), handler)
+	router.GET("/admin", Auth(a, auth.ScopeAdmin), handler)
+
+	serve := func(path string, token string) (int, response.Response) {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		var resp response.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+		return w.Code, resp
+	}
+
+	status, resp := serve("/read", "")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, errors.ErrInvalidToken.Code(), resp.ErrorCode)
+
+	status, resp = serve("/read", "viewer-token-0123456789")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, response.SuccessCode, resp.ErrorCode)
+	assert.Equal(t, "viewer", resp.Data)
+
+	status, resp = serve("/admin", "viewer-token-0123456789")
+	assert.Equal(t, http.StatusForbidden, status)
+	assert.Equal(t, errors.ErrNoPermission.Code(), resp.ErrorCode)
+}
+
+func TestAuthDisabled(t *testing.T) {
+	router := New()
+	router.GET("/", Auth(nil, auth.ScopeAdmin), func(c *gin.Context) {
+		c.JSON(http.StatusOK, response.New(nil))
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
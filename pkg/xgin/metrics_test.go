@@ -1,7 +1,7 @@
 package xgin
 
 import (
-	"agent/pkg/errors"
+	"browsertools/pkg/errors"
 	"context"
 	"net/http"
 	"net/http/httptest"
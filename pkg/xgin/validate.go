@@ -63,6 +63,11 @@ func ContextBindQueryWithValid(ctx *gin.Context, obj interface{}) (err error) {
 	return err
 }
 
+// Validate 按 validate 标签校验非 HTTP 请求的参数, 与 MustBindContext 使用相同的规则
+func Validate(obj interface{}) error {
+	return _validate.Struct(obj)
+}
+
 func TelephoneValid(phone string) bool {
 	// reg := `^1([387][0-9]|14[579]|5[^4]|16[6]|7[1-35-8]|9[189])\d{8}$`
 	reg := `^1\d{10}$`
@@ -0,0 +1,71 @@
+package xgrpc
+
+import (
+	"browsertools/pkg/auth"
+	"browsertools/pkg/errors"
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// ScopeFunc 返回方法需要的权限范围
+type ScopeFunc func(fullMethod string) string
+
+// UnaryAuth 校验 authorization 元数据中的 Bearer 令牌或 JWT, gRPC 不支持 HMAC 签名请求
+// 需要放在 UnaryRecovery 之后, 由其转换为 Unauthenticated 和 PermissionDenied 状态
+func UnaryAuth(a *auth.Authenticator, scope ScopeFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, a, scope(info.FullMethod))
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuth 流式接口的 UnaryAuth
+func StreamAuth(a *auth.Authenticator, scope ScopeFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), a, scope(info.FullMethod))
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func authenticate(ctx context.Context, a *auth.Authenticator, scope string) (context.Context, error) {
+	var token string
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+		scheme, value, ok := strings.Cut(values[0], " ")
+		if ok && strings.EqualFold(scheme, "Bearer") {
+			token = strings.TrimSpace(value)
+		}
+	}
+
+	principal, err := a.AuthenticateToken(token)
+	if err != nil {
+		return nil, errors.WithMessage(errors.ErrInvalidToken, err.Error())
+	}
+
+	if !principal.Allows(scope) {
+		return nil, errors.WithMessage(errors.ErrNoPermission, fmt.Sprintf("%s requires scope %s", principal, scope))
+	}
+
+	return auth.NewContext(ctx, principal), nil
+}
+
+// authStream 替换流的 context, 处理函数可以通过 auth.FromContext 获取调用方
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
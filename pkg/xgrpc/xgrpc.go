@@ -0,0 +1,139 @@
+package xgrpc
+
+import (
+	"browsertools/log"
+	"browsertools/pkg/errors"
+	"context"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorCodeKey 响应 trailer 中的原始错误码, 与 HTTP 接口响应中的 code 相同
+const ErrorCodeKey = "browsertools-error-code"
+
+// statusCodes 错误码对应的 gRPC 状态码, 未列出的错误码都作为 Internal
+var statusCodes = map[int]codes.Code{
+	errors.ErrInvalidToken.Code():       codes.Unauthenticated,
+	errors.ErrNoPermission.Code():       codes.PermissionDenied,
+	errors.ErrRequestTimeout.Code():     codes.DeadlineExceeded,
+	errors.ErrArgument.Code():           codes.InvalidArgument,
+	errors.ErrInvalidPlayground.Code():  codes.InvalidArgument,
+	errors.BrowserNotInstalled.Code():   codes.Unavailable,
+	errors.ErrCurrentPageEmpty.Code():   codes.NotFound,
+	errors.ErrPageNotFound.Code():       codes.NotFound,
+	errors.ErrRecordingNotFound.Code():  codes.NotFound,
+	errors.ErrRecordingRunning.Code():   codes.FailedPrecondition,
+	errors.ErrBaselineNotFound.Code():   codes.NotFound,
+	errors.ErrSessionNotFound.Code():    codes.NotFound,
+	errors.ErrSessionExists.Code():      codes.AlreadyExists,
+	errors.ErrLeaseNotFound.Code():      codes.NotFound,
+	errors.ErrPoolExhausted.Code():      codes.ResourceExhausted,
+	errors.ErrUnsupported.Code():        codes.Unimplemented,
+	errors.ErrPageUnresponsive.Code():   codes.Unavailable,
+	errors.ErrHeadfulRequired.Code():    codes.FailedPrecondition,
+	errors.ErrInteractionRunning.Code(): codes.FailedPrecondition,
+	errors.ErrInteractionMissing.Code(): codes.NotFound,
+}
+
+// Code 错误码对应的 gRPC 状态码
+func Code(code int) codes.Code {
+	if c, ok := statusCodes[code]; ok {
+		return c
+	}
+
+	return codes.Internal
+}
+
+// Status 把错误转换为 gRPC 状态, 与 HTTP 接口一样内部错误只返回通用的错误信息
+func Status(err error) *status.Status {
+	if s, ok := status.FromError(err); ok {
+		return s
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err)
+	}
+
+	var codeError errors.CodeError
+	if !errors.As(err, &codeError) {
+		codeError = errors.ErrInternalServer
+	}
+
+	if codeError.Code() == errors.InternalErrorCode {
+		return status.New(codes.Internal, errors.ErrInternalServer.Error())
+	}
+
+	return status.New(Code(codeError.Code()), codeError.Error())
+}
+
+// UnaryRecovery 把处理函数中 errors.Check 和 errors.Throw 抛出的错误转换为 gRPC 状态
+func UnaryRecovery() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(info.FullMethod, r)
+			}
+
+			if err != nil {
+				err = toStatus(info.FullMethod, err, func(md metadata.MD) { _ = grpc.SetTrailer(ctx, md) })
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecovery 流式接口的 UnaryRecovery
+func StreamRecovery() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(info.FullMethod, r)
+			}
+
+			if err != nil {
+				err = toStatus(info.FullMethod, err, ss.SetTrailer)
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// recovered 与 xgin.RecoveryWriter 一样, 非预期的 panic 记录堆栈
+func recovered(method string, r interface{}) error {
+	switch e := r.(type) {
+	case runtime.Error:
+		log.Errorf("grpc %s runtime error: %v\n%s", method, e, debug.Stack())
+		return errors.ErrInternalServer
+	case error:
+		return e
+	default:
+		log.Errorf("grpc %s panic recovered: %v\n%s", method, r, debug.Stack())
+		return errors.ErrInternalServer
+	}
+}
+
+func toStatus(method string, err error, setTrailer func(metadata.MD)) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	s := Status(err)
+
+	var codeError errors.CodeError
+	if errors.As(err, &codeError) {
+		log.Errorf("grpc %s error, code %d, description %s", method, codeError.Code(), err.Error())
+		setTrailer(metadata.Pairs(ErrorCodeKey, strconv.Itoa(codeError.Code())))
+	} else if s.Code() != codes.Canceled {
+		log.Errorf("grpc %s error: %v", method, err)
+	}
+
+	return s.Err()
+}
@@ -0,0 +1,112 @@
+package xgrpc
+
+import (
+	"browsertools/pkg/auth"
+	"browsertools/pkg/errors"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+		msg  string
+	}{
+		{errors.ErrArgument, codes.InvalidArgument, errors.ErrArgument.Error()},
+		{errors.ErrSessionNotFound, codes.NotFound, errors.ErrSessionNotFound.Error()},
+		{errors.WithMessage(errors.ErrSessionExists, "create"), codes.AlreadyExists, errors.ErrSessionExists.Error()},
+		{errors.ErrPoolExhausted, codes.ResourceExhausted, errors.ErrPoolExhausted.Error()},
+		{errors.NewWithInfo(500, "secret detail"), codes.Internal, errors.ErrInternalServer.Error()},
+		{errors.New("plain error"), codes.Internal, errors.ErrInternalServer.Error()},
+		{context.Canceled, codes.Canceled, context.Canceled.Error()},
+		{status.Error(codes.Aborted, "aborted"), codes.Aborted, "aborted"},
+	}
+
+	for _, tt := range tests {
+		s := Status(tt.err)
+		assert.Equal(t, tt.code, s.Code(), tt.err.Error())
+		assert.Equal(t, tt.msg, s.Message(), tt.err.Error())
+	}
+}
+
+func TestCodeCoversAllErrors(t *testing.T) {
+	for _, codeError := range errors.Codes() {
+		if codeError.Code() == errors.InternalErrorCode || codeError.Code() == errors.ErrAdminAccountNotSet.Code() {
+			continue
+		}
+
+		assert.NotEqual(t, codes.Internal, Code(codeError.Code()), codeError.Error())
+	}
+}
+
+func TestUnaryRecovery(t *testing.T) {
+	interceptor := UnaryRecovery()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test/Method"}
+
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		errors.Throw(errors.ErrPageNotFound, "page p1")
+		return nil, nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "page p1")
+
+	_, err = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		var m map[string]int
+		m["x"] = 1
+		return nil, nil
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	resp, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+}
+
+func TestUnaryAuth(t *testing.T) {
+	a, err := auth.New(auth.Options{Tokens: []auth.Token{{Name: "viewer", Token: "viewer-token-0123456789", Scopes: []string{auth.ScopeRead}}}})
+	require.NoError(t, err)
+
+	recovery := UnaryRecovery()
+	interceptor := UnaryAuth(a, func(fullMethod string) string {
+		if fullMethod == "/test/Read" {
+			return auth.ScopeRead
+		}
+		return auth.ScopeAdmin
+	})
+
+	call := func(method string, token string) (interface{}, error) {
+		ctx := context.Background()
+		if token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+		}
+
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		return recovery(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				p, _ := auth.FromContext(ctx)
+				return p.Subject, nil
+			})
+		})
+	}
+
+	_, err = call("/test/Read", "")
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = call("/test/Admin", "viewer-token-0123456789")
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	resp, err := call("/test/Read", "viewer-token-0123456789")
+	require.NoError(t, err)
+	assert.Equal(t, "viewer", resp)
+}